@@ -0,0 +1,232 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+const (
+	maxBulkOperations = 10000
+	maxBulkBodyBytes  = 32 << 20
+)
+
+// Résultat d'une opération du lot, renvoyé au client
+type bulkItemResult struct {
+	Index      int          `json:"index"`
+	Op         string       `json:"op"`
+	ID         int          `json:"id,omitempty"`
+	Status     int          `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	ExistingID int          `json:"existing_id,omitempty"`
+	Movie      *store.Movie `json:"movie,omitempty"`
+}
+
+// BulkMovies godoc
+// @Summary      Créer, modifier et supprimer des films par lot
+// @Description  Accepte un tableau JSON, XML ou MessagePack (ou un flux NDJSON avec Content-Type
+// @Description  application/x-ndjson) d'opérations {"op": "create|update|delete", "id": 1, "movie": {...}}.
+// @Description  Les opérations sont appliquées dans l'ordre du lot.
+// @Description  Avec atomic=true tout est appliqué dans une seule transaction, ou rien (422).
+// @Description  Sinon chaque opération est indépendante et son statut est renvoyé dans "results".
+// @Tags         movies
+// @Accept       json,application/xml,application/msgpack,application/x-ndjson
+// @Produce      json
+// @Param        atomic  query  bool                   false  "Tout ou rien"
+// @Param        input   body   []store.BulkOperation  true   "Opérations"
+// @Success      200  {object}  map[string]any
+// @Failure      400  {string}  string "Requête invalide"
+// @Failure      422  {object}  map[string]any
+// @Router       /movies/bulk [post]
+// @Security     BearerAuth
+func (app *application) bulkMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	atomic := false
+	if a := r.URL.Query().Get("atomic"); a != "" {
+		b, err := strconv.ParseBool(a)
+		if err != nil {
+			http.Error(w, "atomic must be true or false", http.StatusBadRequest)
+			return
+		}
+		atomic = b
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodyBytes)
+
+	ops, err := decodeBulkOperations(r)
+	if err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	results := make([]bulkItemResult, len(ops))
+	var valid []store.BulkOperation
+	var positions []int
+	invalid := 0
+
+	for i := range ops {
+		op := &ops[i]
+		results[i] = bulkItemResult{Index: i, Op: op.Op, ID: op.ID}
+
+		if err := validateBulkOperation(op); err != nil {
+			results[i].Status = http.StatusBadRequest
+			results[i].Error = err.Error()
+			invalid++
+			continue
+		}
+		valid = append(valid, *op)
+		positions = append(positions, i)
+	}
+
+	if atomic && invalid > 0 {
+		markNotApplied(results)
+		respond(w, r, http.StatusUnprocessableEntity, map[string]any{"atomic": true, "results": results})
+		return
+	}
+
+	applied, err := app.store.Movies.BulkApply(valid, atomic)
+	aborted := errors.Is(err, store.ErrBulkAborted)
+	if err != nil && !aborted {
+		log.Println("Error applying bulk operations:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	succeeded := 0
+	for n, res := range applied {
+		item := &results[positions[n]]
+		if res.Err != nil {
+			item.Status, item.Error = bulkErrorStatus(res.Err)
+
+			var dupErr *store.DuplicateMovieError
+			if errors.As(res.Err, &dupErr) {
+				item.ExistingID = dupErr.ExistingID
+			}
+			continue
+		}
+
+		item.ID = res.ID
+		switch item.Op {
+		case store.BulkCreate:
+			item.Status = http.StatusCreated
+			item.Movie = res.Movie
+		case store.BulkUpdate:
+			item.Status = http.StatusOK
+		case store.BulkDelete:
+			item.Status = http.StatusNoContent
+		}
+		succeeded++
+	}
+
+	if aborted {
+		markNotApplied(results)
+		respond(w, r, http.StatusUnprocessableEntity, map[string]any{"atomic": true, "results": results})
+		return
+	}
+
+	for _, item := range results {
+		if item.Op == store.BulkDelete && item.Status == http.StatusNoContent {
+			app.removePosterFiles(item.ID)
+		}
+	}
+
+	response := map[string]any{
+		"atomic":    atomic,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// Lit les opérations depuis un flux NDJSON, ou un tableau en JSON, XML ou
+// MessagePack comme les autres corps de requête
+func decodeBulkOperations(r *http.Request) ([]store.BulkOperation, error) {
+	var ops []store.BulkOperation
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-ndjson" {
+		dec := json.NewDecoder(r.Body)
+		for {
+			var op store.BulkOperation
+			err := dec.Decode(&op)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid NDJSON at line %d", len(ops)+1)
+			}
+			ops = append(ops, op)
+			if len(ops) > maxBulkOperations {
+				return nil, fmt.Errorf("too many operations (max %d)", maxBulkOperations)
+			}
+		}
+	} else if err := decodeBody(r, &ops); err != nil {
+		return nil, err
+	}
+
+	if len(ops) == 0 {
+		return nil, errors.New("no operations to apply")
+	}
+	if len(ops) > maxBulkOperations {
+		return nil, fmt.Errorf("too many operations (max %d)", maxBulkOperations)
+	}
+
+	return ops, nil
+}
+
+func validateBulkOperation(op *store.BulkOperation) error {
+	switch op.Op {
+	case store.BulkCreate:
+		return op.Movie.Validate()
+	case store.BulkUpdate:
+		if op.ID <= 0 {
+			return errors.New("id is required for update")
+		}
+		return op.Movie.Validate()
+	case store.BulkDelete:
+		if op.ID <= 0 {
+			return errors.New("id is required for delete")
+		}
+		return nil
+	default:
+		return store.ErrInvalidBulkOp
+	}
+}
+
+// Associe une erreur du store au statut HTTP de l'opération
+func bulkErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound, "movie not found"
+	case errors.Is(err, store.ErrDuplicateMovie):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, store.ErrGenreNotFound), errors.Is(err, store.ErrInvalidBulkOp):
+		return http.StatusBadRequest, err.Error()
+	default:
+		log.Println("Error in bulk operation:", err)
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
+
+// En mode atomique, les opérations sans erreur n'ont pas été appliquées
+func markNotApplied(results []bulkItemResult) {
+	for i := range results {
+		if results[i].Error == "" {
+			results[i].Status = http.StatusFailedDependency
+			results[i].Error = "not applied"
+			results[i].Movie = nil
+			if results[i].Op == store.BulkCreate {
+				results[i].ID = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+const (
+	// Durée de vie du cache des films, modifiable par MOVIE_CACHE_TTL (0 le désactive)
+	defaultMovieCacheTTL = 30 * time.Second
+	// Nombre d'entrées gardées en mémoire, modifiable par MOVIE_CACHE_SIZE
+	defaultMovieCacheSize = 1000
+)
+
+// Vide le cache à chaque changement du catalogue notifié,
+// y compris par une autre instance, jusqu'à l'annulation de ctx
+func (app *application) invalidateOnEvents(ctx context.Context, cache *store.CachedMovieRepository) {
+	wake := app.events.subscribe()
+	defer app.events.unsubscribe(wake)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+			cache.Invalidate()
+		}
+	}
+}
+
+// En-têtes de cache HTTP des lectures du catalogue : les réponses publiques
+// peuvent être gardées aussi longtemps que le cache serveur et portent
+// Last-Modified, la date du dernier changement du catalogue (une liste ou
+// une note moyenne peuvent changer sans que leurs films soient modifiés).
+// Les réponses filtrées pour un utilisateur ne sont pas partagées.
+// Vary: Accept-Language est ajouté par la traduction des films.
+// Renvoie true (après avoir répondu 304) si le client a déjà cette version.
+func (app *application) catalogueNotModified(w http.ResponseWriter, r *http.Request, personal bool) (bool, error) {
+	switch {
+	case personal:
+		w.Header().Set("Cache-Control", "private, no-cache")
+	case app.cacheTTL > 0:
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(app.cacheTTL.Seconds())))
+	default:
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	// Une réponse personnelle dépend aussi des listes de l'utilisateur,
+	// que la date du catalogue ne suit pas
+	if personal {
+		return false, nil
+	}
+
+	lastModified, err := app.store.Movies.LastModified()
+	if err != nil || lastModified.IsZero() {
+		return false, err
+	}
+	w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+
+	// If-None-Match l'emporte quand il est présent (RFC 9110)
+	if r.Header.Get("If-None-Match") != "" {
+		return false, nil
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.Truncate(time.Second).After(since) {
+		return false, nil
+	}
+
+	// respond ajoute Vary: Accept aux autres réponses
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(http.StatusNotModified)
+	return true, nil
+}
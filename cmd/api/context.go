@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+type contextKey string
+
+const userContextKey = contextKey("user")
+
+// Renvoie une copie de la requête portant l'utilisateur authentifié
+func (app *application) contextSetUser(r *http.Request, user store.User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	return r.WithContext(ctx)
+}
+
+// Renvoie l'utilisateur de la requête, AnonymousUser si aucun
+// n'a été placé dans le contexte par authMiddleware
+func (app *application) contextGetUser(r *http.Request) store.User {
+	return contextUser(r.Context())
+}
+
+// Variante de contextGetUser pour le code qui n'a que le contexte (resolvers GraphQL)
+func contextUser(ctx context.Context) store.User {
+	user, ok := ctx.Value(userContextKey).(store.User)
+	if !ok {
+		return store.AnonymousUser
+	}
+	return user
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var errUnsupportedMediaType = errors.New("Content-Type must be application/json, application/xml or application/msgpack")
+
+// Corps de requête illisible dans le format annoncé
+type bodyError struct {
+	format string
+	err    error
+}
+
+func (e *bodyError) Error() string {
+	return fmt.Sprintf("Invalid %s", e.format)
+}
+
+func (e *bodyError) Unwrap() error {
+	return e.err
+}
+
+// Décode le corps de la requête dans dst selon son Content-Type : JSON, XML
+// ou MessagePack. Sans Content-Type, ou avec celui que curl -d envoie par
+// défaut, le corps est lu comme du JSON. XML et MessagePack sont convertis
+// en JSON, pour que les trois formats soient validés de la même façon.
+func decodeBody(r *http.Request, dst any) error {
+	format := formatJSON
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return errUnsupportedMediaType
+		}
+
+		switch f, ok := mediaTypeFormats[mediaType]; {
+		case ok && f != formatCSV:
+			format = f
+		case strings.HasSuffix(mediaType, "+json"),
+			mediaType == "application/x-www-form-urlencoded", mediaType == "text/plain":
+		default:
+			return errUnsupportedMediaType
+		}
+	}
+
+	switch format {
+	case formatXML:
+		data, err := xmlToJSON(r.Body, reflect.TypeOf(dst).Elem())
+		if err != nil {
+			return &bodyError{"XML", err}
+		}
+		if err := json.Unmarshal(data, dst); err != nil {
+			return &bodyError{"XML", err}
+		}
+
+	case formatMsgpack:
+		var value any
+		dec := msgpack.NewDecoder(r.Body)
+		dec.SetMapDecoder(func(d *msgpack.Decoder) (any, error) {
+			return d.DecodeMap()
+		})
+		if err := dec.Decode(&value); err != nil {
+			return &bodyError{"MessagePack", err}
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return &bodyError{"MessagePack", err}
+		}
+		if err := json.Unmarshal(data, dst); err != nil {
+			return &bodyError{"MessagePack", err}
+		}
+
+	default:
+		if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+			return &bodyError{"JSON", err}
+		}
+	}
+
+	return nil
+}
+
+// Répond 415 pour un Content-Type non géré, 400 pour un corps illisible
+func respondBodyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errUnsupportedMediaType) {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// --- XML ---
+
+// Élément XML lu en entier, avant sa conversion
+type xmlNode struct {
+	name     string
+	text     string
+	children []*xmlNode
+}
+
+// Convertit un document XML en JSON d'après le type qui le recevra :
+// c'est lui qui dit si un élément est un nombre, une liste ou un objet.
+// Le document suit la forme des réponses XML (<movie><title>...</title>
+// <genres><genre>Action</genre></genres></movie>), le nom de la racine
+// et des éléments de liste est libre.
+func xmlToJSON(body io.Reader, t reflect.Type) ([]byte, error) {
+	root, err := parseXML(xml.NewDecoder(body))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := xmlValue(root, t)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+func parseXML(dec *xml.Decoder) (*xmlNode, error) {
+	var stack []*xmlNode
+	var root *xmlNode
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local}
+			// <entry key="..."> porte les clés qui ne sont pas des noms XML
+			for _, attr := range t.Attr {
+				if t.Name.Local == "entry" && attr.Name.Local == "key" {
+					node.name = attr.Value
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root != nil {
+				return nil, errors.New("more than one root element")
+			} else {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("empty document")
+	}
+	return root, nil
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+func xmlValue(n *xmlNode, t reflect.Type) (any, error) {
+	// Types qui se lisent depuis une chaîne (dates...)
+	if t.Kind() != reflect.Pointer && (reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType)) {
+		return n.text, nil
+	}
+
+	text := strings.TrimSpace(n.text)
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		// Un élément vide est une valeur absente
+		if text == "" && len(n.children) == 0 {
+			return nil, nil
+		}
+		return xmlValue(n, t.Elem())
+
+	case reflect.Struct:
+		fields := jsonFields(t)
+		obj := make(map[string]any)
+		for _, child := range n.children {
+			ft, ok := fields[child.name]
+			if !ok {
+				continue
+			}
+			value, err := xmlValue(child, ft)
+			if err != nil {
+				return nil, err
+			}
+			obj[child.name] = value
+		}
+		return obj, nil
+
+	case reflect.Map:
+		obj := make(map[string]any)
+		for _, child := range n.children {
+			value, err := xmlValue(child, t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			obj[child.name] = value
+		}
+		return obj, nil
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return n.text, nil
+		}
+		list := []any{}
+		for _, child := range n.children {
+			value, err := xmlValue(child, t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+
+	case reflect.Bool:
+		return strconv.ParseBool(text)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(text, 10, 64)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(text, 10, 64)
+
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(text, 64)
+
+	case reflect.Interface:
+		if len(n.children) > 0 {
+			return xmlValue(n, reflect.TypeFor[map[string]any]())
+		}
+		return n.text, nil
+	}
+
+	return n.text, nil
+}
+
+// Champs d'une struct par nom JSON, champs embarqués compris
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+
+	return fields
+}
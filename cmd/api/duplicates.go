@@ -0,0 +1,131 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+type MergeMovieRequest struct {
+	DuplicateID int `json:"duplicate_id" example:"42"`
+}
+
+// GetDuplicates godoc
+// @Summary      Lister les doublons probables
+// @Description  Renvoie les paires de films dont les titres se ressemblent (similarité trigramme,
+// @Description  casse, espaces et accents ignorés) et sortis à year_tolerance ans d'écart au plus.
+// @Tags         movies
+// @Produce      json
+// @Param        threshold       query  number  false  "Similarité minimale entre 0 et 1 (défaut 0.6)"
+// @Param        year_tolerance  query  int     false  "Écart d'années toléré (défaut 1)"
+// @Success      200  {object}  map[string]any
+// @Failure      400  {string}  string "Paramètre invalide"
+// @Router       /movies/duplicates [get]
+func (app *application) getDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	queryValues := r.URL.Query()
+
+	threshold := 0.6
+	if t := queryValues.Get("threshold"); t != "" {
+		f, err := strconv.ParseFloat(t, 64)
+		if err != nil || f <= 0 || f > 1 {
+			http.Error(w, "threshold must be a number between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		threshold = f
+	}
+
+	yearTolerance := 1
+	if y := queryValues.Get("year_tolerance"); y != "" {
+		n, err := strconv.Atoi(y)
+		if err != nil || n < 0 {
+			http.Error(w, "year_tolerance must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		yearTolerance = n
+	}
+
+	filters, err := parseMovieFilters(queryValues)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pairs, metadata, err := app.store.Movies.FindDuplicates(threshold, yearTolerance, filters)
+	if err != nil {
+		log.Println("Error fetching duplicates:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"metadata":   metadata,
+		"duplicates": pairs,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// MergeMovie godoc
+// @Summary      Fusionner un doublon
+// @Description  Ajoute les genres du doublon au film, complète sa note et sa critique,
+// @Description  puis supprime le doublon. Renvoie le film fusionné.
+// @Tags         movies
+// @Accept       json
+// @Produce      json
+// @Param        id     path  int                true  "ID du film conservé"
+// @Param        input  body  MergeMovieRequest  true  "ID du doublon à supprimer"
+// @Success      200  {object}  Movie
+// @Failure      400  {string}  string "Requête invalide"
+// @Failure      404  {string}  string "Film non trouvé"
+// @Router       /movies/{id}/merge [post]
+// @Security     BearerAuth
+func (app *application) mergeMovieHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input MergeMovieRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	movie, err := app.store.Movies.MergeMovies(id, input.DuplicateID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrSelfMerge):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Println("Error merging movies:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Le doublon est supprimé, son affiche avec lui
+	app.removePosterFiles(input.DuplicateID)
+
+	respond(w, r, http.StatusOK, versionedMovie(r, movie))
+}
+
+// Renvoie 409 avec l'ID du film existant
+func respondDuplicate(w http.ResponseWriter, r *http.Request, err error) {
+	response := map[string]any{"error": store.ErrDuplicateMovie.Error()}
+
+	var dupErr *store.DuplicateMovieError
+	if errors.As(err, &dupErr) && dupErr.ExistingID != 0 {
+		response["existing_id"] = dupErr.ExistingID
+		w.Header().Set("Location", fmt.Sprintf("/movies/%d", dupErr.ExistingID))
+	}
+
+	respond(w, r, http.StatusConflict, response)
+}
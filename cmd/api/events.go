@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Nombre d'événements relus par requête
+	eventsBatchSize = 100
+	// Commentaire envoyé régulièrement pour garder la connexion ouverte
+	eventsHeartbeat = 15 * time.Second
+	// Délai de reconnexion, pour le client SSE comme pour l'écoute PostgreSQL
+	eventsRetryInterval = 5 * time.Second
+	// Durée de conservation du journal : une reprise plus ancienne perd des événements
+	eventsRetention = 7 * 24 * time.Hour
+)
+
+// Réveille les flux SSE ouverts sur cette instance quand un événement
+// est notifié ; chaque flux relit ensuite le journal depuis son dernier ID
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[chan struct{}]struct{})}
+}
+
+func (b *eventBroker) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *eventBroker) unsubscribe(ch chan struct{}) {
+	b.mu.Lock()
+	delete(b.subscribers, ch)
+	b.mu.Unlock()
+}
+
+// Ne bloque jamais : un flux déjà réveillé relira de toute façon tout le journal
+func (b *eventBroker) broadcast() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Écoute les notifications PostgreSQL (LISTEN/NOTIFY) pour que les changements
+// faits sur n'importe quelle instance atteignent les flux de celle-ci.
+// Se reconnecte en cas d'erreur, jusqu'à l'annulation de ctx.
+func (app *application) listenForEvents(ctx context.Context) {
+	go app.pruneEvents(ctx)
+
+	for {
+		err := app.store.Events.Listen(ctx, app.events.broadcast)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Println("Error listening for movie events:", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsRetryInterval):
+		}
+	}
+}
+
+// Purge toutes les heures les événements sortis de la période de conservation
+func (app *application) pruneEvents(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		n, err := app.store.Events.DeleteEventsBefore(time.Now().Add(-eventsRetention))
+		if err != nil {
+			log.Println("Error pruning movie events:", err)
+		} else if n > 0 {
+			log.Printf("Pruned %d movie events", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MovieEvents godoc
+// @Summary      Flux des changements du catalogue
+// @Description  Flux Server-Sent Events des créations, modifications et suppressions de films
+// @Description  (événements movie.created, movie.updated et movie.deleted).
+// @Description  data contient le film modifié, ou son id et ses genres pour une suppression.
+// @Description  Sans Last-Event-ID, seuls les nouveaux événements sont envoyés ; sinon le flux
+// @Description  reprend juste après cet événement (journal conservé 7 jours).
+// @Tags         movies
+// @Produce      text/event-stream
+// @Param        genre          query   string  false  "Ne garder que les films de ce genre"
+// @Param        last_event_id  query   int     false  "Reprendre après cet événement (à défaut de l'en-tête)"
+// @Param        Last-Event-ID  header  int     false  "Dernier événement reçu, renvoyé par EventSource à la reconnexion"
+// @Success      200  {string}  string  "Flux text/event-stream"
+// @Failure      400  {string}  string  "Last-Event-ID invalide"
+// @Router       /movies/events [get]
+func (app *application) movieEventsHandler(w http.ResponseWriter, r *http.Request) {
+	genre := strings.TrimSpace(r.URL.Query().Get("genre"))
+
+	lastID, ok, err := lastEventID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Abonnement avant la première lecture : un événement notifié
+	// entre-temps réveille le flux au lieu d'être manqué
+	wake := app.events.subscribe()
+	defer app.events.unsubscribe(wake)
+
+	if !ok {
+		lastID, err = app.store.Events.LastEventID()
+		if err != nil {
+			log.Println("Error getting last event id:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Le flux reste ouvert bien au-delà du WriteTimeout du serveur
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Println("Error clearing write deadline:", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Désactive la mise en tampon des reverse proxies (nginx)
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetryInterval.Milliseconds())
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		if err := app.sendMovieEvents(w, rc, genre, &lastID); err != nil {
+			if r.Context().Err() == nil {
+				log.Println("Error streaming movie events:", err)
+			}
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-wake:
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Envoie tous les événements postérieurs à lastID et avance lastID
+func (app *application) sendMovieEvents(w io.Writer, rc *http.ResponseController, genre string, lastID *int64) error {
+	for {
+		events, err := app.store.Events.GetEventsSince(*lastID, genre, eventsBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			// Le JSON d'un jsonb tient sur une ligne, comme l'exige data:
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Payload); err != nil {
+				return err
+			}
+			*lastID = e.ID
+		}
+
+		if len(events) > 0 {
+			if err := rc.Flush(); err != nil {
+				return err
+			}
+		}
+		if len(events) < eventsBatchSize {
+			return nil
+		}
+	}
+}
+
+// Lit le point de reprise : l'en-tête Last-Event-ID (envoyé par EventSource
+// à la reconnexion) ou le paramètre last_event_id. ok vaut false sans l'un ni l'autre.
+func lastEventID(r *http.Request) (int64, bool, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, errors.New("Last-Event-ID must be a non-negative integer")
+	}
+
+	return id, true, nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+// Nombre de films écrits entre deux envois au client
+const exportFlushEvery = 500
+
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"json":   "application/json",
+}
+
+var csvHeader = []string{"id", "title", "release_year", "rating", "review", "genres", "created_at", "updated_at"}
+
+// ExportMovies godoc
+// @Summary      Exporter le catalogue
+// @Description  Renvoie en flux tous les films (genres compris) correspondant aux mêmes filtres
+// @Description  que GET /movies (title, sort, updated_since), sans pagination.
+// @Description  En CSV, les genres sont séparés par "|".
+// @Tags         movies
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      json
+// @Param        format  query  string  false  "csv, ndjson ou json (défaut)"
+// @Param        title   query  string  false  "Recherche par titre"
+// @Param        sort    query  string  false  "Tri (ex: -rating)"
+// @Success      200  {array}   Movie
+// @Failure      400  {string}  string "Format inconnu"
+// @Router       /movies/export [get]
+func (app *application) exportMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	queryValues := r.URL.Query()
+
+	format := queryValues.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		http.Error(w, "format must be one of csv, ndjson, json", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := parseMovieFilters(queryValues)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := app.setFilterUser(r, &filters); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// L'export peut durer bien plus que le WriteTimeout du serveur :
+	// on lève la limite pour cette réponse uniquement
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Println("Error clearing write deadline:", err)
+	}
+
+	exp := newMovieExporter(w, format)
+	started := false
+	count := 0
+
+	err = app.store.Movies.ExportMovies(queryValues.Get("title"), filters, func(movie store.Movie) error {
+		if !started {
+			startExport(w, contentType, format)
+			started = true
+		}
+
+		if err := exp.write(movie); err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushEvery == 0 {
+			return exp.flush(rc)
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Println("Error exporting movies:", err)
+		if !started {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		// Sinon la réponse est déjà partie : on ne peut que l'interrompre
+		return
+	}
+
+	if !started {
+		startExport(w, contentType, format)
+	}
+	if err := exp.close(); err != nil {
+		log.Println("Error finishing export:", err)
+	}
+}
+
+func startExport(w http.ResponseWriter, contentType, format string) {
+	filename := fmt.Sprintf("movies-%s.%s", time.Now().Format("20060102"), format)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Écrit les films un par un dans le format demandé
+type movieExporter struct {
+	w      io.Writer
+	format string
+	csv    *csv.Writer
+	json   *json.Encoder
+	count  int
+}
+
+func newMovieExporter(w io.Writer, format string) *movieExporter {
+	exp := &movieExporter{w: w, format: format}
+
+	switch format {
+	case "csv":
+		exp.csv = csv.NewWriter(w)
+	default:
+		exp.json = json.NewEncoder(w)
+	}
+
+	return exp
+}
+
+func (e *movieExporter) write(movie store.Movie) error {
+	defer func() { e.count++ }()
+
+	switch e.format {
+	case "csv":
+		if e.count == 0 {
+			if err := e.csv.Write(csvHeader); err != nil {
+				return err
+			}
+		}
+		return e.csv.Write(movieCSVRecord(movie))
+	case "json":
+		sep := ","
+		if e.count == 0 {
+			sep = "["
+		}
+		if _, err := io.WriteString(e.w, sep); err != nil {
+			return err
+		}
+	}
+
+	// json.Encoder termine chaque valeur par un saut de ligne,
+	// ce qui donne directement du NDJSON
+	return e.json.Encode(movie)
+}
+
+func (e *movieExporter) flush(rc *http.ResponseController) error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// Termine le document (en-tête CSV ou tableau JSON vide compris)
+func (e *movieExporter) close() error {
+	switch e.format {
+	case "csv":
+		if e.count == 0 {
+			if err := e.csv.Write(csvHeader); err != nil {
+				return err
+			}
+		}
+		e.csv.Flush()
+		return e.csv.Error()
+	case "json":
+		end := "]\n"
+		if e.count == 0 {
+			end = "[]\n"
+		}
+		_, err := io.WriteString(e.w, end)
+		return err
+	}
+	return nil
+}
+
+// encoding/csv se charge d'échapper les virgules,
+// guillemets et retours à la ligne des critiques
+func movieCSVRecord(movie store.Movie) []string {
+	rating := ""
+	if movie.Rating != nil {
+		rating = strconv.FormatFloat(*movie.Rating, 'f', 1, 64)
+	}
+
+	review := ""
+	if movie.Review != nil {
+		review = *movie.Review
+	}
+
+	return []string{
+		strconv.Itoa(movie.ID),
+		movie.Title,
+		strconv.Itoa(movie.ReleaseYear),
+		rating,
+		review,
+		strings.Join(movie.Genres, "|"),
+		movie.CreatedAt.Format(time.RFC3339),
+		movie.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+// Champs (?fields=) et relations (?include=) demandés par le client
+type fieldset struct {
+	fields  []string
+	include []string
+}
+
+// Lit ?fields=id,title et ?include=genres,credits en les vérifiant.
+// L'id est toujours renvoyé dès qu'une liste de champs est donnée.
+func parseFieldset(values url.Values) (fieldset, error) {
+	var fs fieldset
+
+	if fields := splitList(values.Get("fields")); len(fields) > 0 {
+		if err := store.CheckMovieFields(fields); err != nil {
+			return fieldset{}, err
+		}
+		if !slices.Contains(fields, "id") {
+			fields = append([]string{"id"}, fields...)
+		}
+		fs.fields = fields
+	}
+
+	fs.include = splitList(values.Get("include"))
+	if err := store.CheckMovieRelations(fs.include); err != nil {
+		return fieldset{}, err
+	}
+
+	return fs, nil
+}
+
+// Découpe une liste séparée par des virgules, sans doublons ni éléments vides
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" && !slices.Contains(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Charge en une requête par relation les genres et le générique demandés
+func (app *application) includeRelations(movies []store.Movie, include []string) error {
+	if len(movies) == 0 || len(include) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(movies))
+	for i, m := range movies {
+		ids[i] = m.ID
+	}
+
+	if slices.Contains(include, store.RelationGenres) {
+		genres, err := app.store.Genres.GetMovieGenres(ids)
+		if err != nil {
+			return err
+		}
+		for i := range movies {
+			names := []string{}
+			for _, g := range genres[movies[i].ID] {
+				names = append(names, g.Name)
+			}
+			movies[i].Genres = names
+		}
+	}
+
+	if slices.Contains(include, store.RelationCredits) {
+		credits, err := app.store.Movies.GetMovieCredits(ids)
+		if err != nil {
+			return err
+		}
+		for i := range movies {
+			movies[i].Credits = credits[movies[i].ID]
+			if movies[i].Credits == nil {
+				movies[i].Credits = []store.Credit{}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Champs à lire en base : ceux demandés et, en v2, les votes
+// dont rating donne la moyenne
+func (fs fieldset) storeFields(r *http.Request) []string {
+	if requestAPIVersion(r) != "v1" && slices.Contains(fs.fields, "rating") && !slices.Contains(fs.fields, "user_ratings") {
+		return append(slices.Clone(fs.fields), "user_ratings")
+	}
+	return fs.fields
+}
+
+// Réduit chaque film, dans la version de la requête, aux champs et
+// relations demandés. Sans liste de champs, les films sont renvoyés entiers.
+func (fs fieldset) project(r *http.Request, movies []store.Movie) ([]any, error) {
+	projected := make([]any, len(movies))
+	for i, movie := range movies {
+		projected[i] = versionedMovie(r, movie)
+	}
+	if len(fs.fields) == 0 {
+		return projected, nil
+	}
+
+	keep := append(slices.Clone(fs.fields), fs.include...)
+
+	for i, movie := range projected {
+		data, err := json.Marshal(movie)
+		if err != nil {
+			return nil, err
+		}
+
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		// Les champs gardent leur type Go, pour les formats autres que JSON
+		values := jsonFieldValues(reflect.ValueOf(movie))
+
+		// Les champs suivent l'ordre de fields= puis d'include=
+		selected := &orderedObject{values: make(map[string]any, len(keep))}
+		for _, key := range keep {
+			var value any = json.RawMessage("[]")
+			if _, ok := all[key]; ok {
+				value = values[key].Interface()
+			} else if !slices.Contains(fs.include, key) {
+				// Un générique vide est omis du film, mais il a été demandé
+				continue
+			}
+			selected.keys = append(selected.keys, key)
+			selected.values[key] = value
+		}
+		projected[i] = selected
+	}
+
+	return projected, nil
+}
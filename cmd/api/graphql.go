@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Limites appliquées avant l'exécution d'une requête GraphQL
+const (
+	maxGraphQLDepth      = 8
+	maxGraphQLComplexity = 5000
+	maxGraphQLBodyBytes  = 1 << 20
+)
+
+// Corps d'une requête GraphQL (POST /graphql)
+type GraphQLRequest struct {
+	Query         string         `json:"query" example:"{ movies(page_size: 5) { movies { id title genres { name } } } }"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// GraphQL godoc
+// @Summary      Requête GraphQL
+// @Description  Expose les films, les genres et leurs relations. Les arguments de "movies" reprennent
+// @Description  les filtres de GET /movies (title, page, page_size, sort, language, min_runtime, ...).
+// @Description  Les mutations create_movie, update_movie et delete_movie demandent le rôle editor.
+// @Description  Les requêtes trop profondes (8 niveaux) ou trop coûteuses sont refusées.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        input  body      GraphQLRequest  true  "Requête, variables et opération"
+// @Success      200    {object}  map[string]any  "data et errors"
+// @Failure      400    {object}  map[string]any  "Requête invalide ou trop coûteuse"
+// @Router       /graphql [post]
+func (app *application) graphqlHandler() http.HandlerFunc {
+	schema, err := app.graphqlSchema()
+	if err != nil {
+		log.Fatal("Failed to build GraphQL schema: ", err)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := readGraphQLRequest(w, r)
+		if err != nil {
+			respondGraphQLError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		lang, err := requestLanguage(r)
+		if err != nil {
+			respondGraphQLError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		doc, err := parser.Parse(parser.ParseParams{
+			Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+		})
+		if err != nil {
+			respondGraphQLError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if result := graphql.ValidateDocument(&schema, doc, nil); !result.IsValid {
+			respondWithJSON(w, http.StatusBadRequest, map[string]any{"errors": result.Errors})
+			return
+		}
+
+		op := findOperation(doc, req.OperationName)
+		if op == nil {
+			respondGraphQLError(w, http.StatusBadRequest, fmt.Errorf("unknown operation %q", req.OperationName))
+			return
+		}
+
+		// Un GET ne doit rien modifier
+		if r.Method == http.MethodGet && op.Operation == ast.OperationTypeMutation {
+			w.Header().Set("Allow", http.MethodPost)
+			respondGraphQLError(w, http.StatusMethodNotAllowed, errors.New("mutations must be sent with POST"))
+			return
+		}
+
+		if err := checkGraphQLLimits(&schema, doc, op, req.Variables); err != nil {
+			respondGraphQLError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), graphqlContextKey, app.newGraphQLState(r, lang))
+
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:        schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       ctx,
+		})
+
+		setContentLanguage(w, lang)
+		respondWithJSON(w, http.StatusOK, result)
+	}
+}
+
+// --- HELPERS ---
+
+// Lit la requête depuis le corps JSON (POST) ou les paramètres de l'URL (GET)
+func readGraphQLRequest(w http.ResponseWriter, r *http.Request) (GraphQLRequest, error) {
+	var req GraphQLRequest
+
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, errors.New("variables must be a JSON object")
+			}
+		}
+	} else {
+		r.Body = http.MaxBytesReader(w, r.Body, maxGraphQLBodyBytes)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, errors.New("Invalid JSON")
+		}
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		return req, errors.New("query is required")
+	}
+
+	return req, nil
+}
+
+// Renvoie l'opération à exécuter : celle nommée, ou la seule du document
+func findOperation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil // plusieurs opérations : le nom est obligatoire
+			}
+			found = op
+		} else if op.Name != nil && op.Name.Value == name {
+			return op
+		}
+	}
+	return found
+}
+
+func respondGraphQLError(w http.ResponseWriter, status int, err error) {
+	respondWithJSON(w, status, map[string]any{"errors": gqlerrors.FormatErrors(err)})
+}
+
+// Erreur renvoyée au client avec un code dans "extensions"
+type graphqlError struct {
+	message    string
+	extensions map[string]any
+}
+
+func newGraphQLError(code, message string) graphqlError {
+	return graphqlError{message: message, extensions: map[string]any{"code": code}}
+}
+
+func (e graphqlError) Error() string {
+	return e.message
+}
+
+func (e graphqlError) Extensions() map[string]any {
+	return e.extensions
+}
+
+// Journalise l'erreur et n'en montre rien au client
+func graphqlInternalError(msg string, err error) error {
+	log.Println(msg, err)
+	return newGraphQLError("INTERNAL_SERVER_ERROR", "Internal server error")
+}
+
+// --- PROFONDEUR ET COMPLEXITÉ ---
+
+// Taille supposée des champs listes : lue dans l'argument arg
+// s'il est donné, size sinon. Elle multiplie le coût des sous-champs.
+var graphqlListSizes = map[string]struct {
+	arg  string
+	size int
+}{
+	"Query.movies": {"page_size", 20},
+	"Query.genres": {"", 30},
+	"Movie.genres": {"", 5},
+	"Genre.movies": {"first", 10},
+}
+
+// Refuse les requêtes trop profondes ou trop coûteuses. Chaque champ
+// coûte 1, multiplié par la taille des listes qui le contiennent.
+func checkGraphQLLimits(schema *graphql.Schema, doc *ast.Document, op *ast.OperationDefinition, variables map[string]any) error {
+	w := queryWalker{schema: schema, variables: variables, fragments: map[string]*ast.FragmentDefinition{}}
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			w.fragments[frag.Name.Value] = frag
+		}
+	}
+
+	root := schema.QueryType()
+	if op.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+
+	depth, cost := w.walk(root, op.SelectionSet, 1)
+	if depth > maxGraphQLDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, maxGraphQLDepth)
+	}
+	if cost > maxGraphQLComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", cost, maxGraphQLComplexity)
+	}
+	return nil
+}
+
+type queryWalker struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+// Renvoie la profondeur maximale et le coût d'une sélection sur le type parent.
+// Le document est déjà validé : pas de champ inconnu ni de cycle de fragments.
+func (w queryWalker) walk(parent *graphql.Object, set *ast.SelectionSet, depth int) (int, int) {
+	if set == nil || parent == nil {
+		return depth - 1, 0
+	}
+
+	maxDepth, cost := depth-1, 0
+	add := func(d, c int) {
+		maxDepth = max(maxDepth, d)
+		cost += c
+	}
+
+	for _, sel := range set.Selections {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			name := sel.Name.Value
+			// Introspection : bornée par la taille du schéma
+			if strings.HasPrefix(name, "__") {
+				continue
+			}
+
+			def, ok := parent.Fields()[name]
+			if !ok {
+				continue
+			}
+			child, _ := graphql.GetNamed(def.Type).(*graphql.Object)
+
+			d, c := w.walk(child, sel.SelectionSet, depth+1)
+			add(max(d, depth), 1+w.listSize(parent.Name()+"."+name, sel)*c)
+
+		case *ast.InlineFragment:
+			target := parent
+			if sel.TypeCondition != nil {
+				target, _ = w.schema.Type(sel.TypeCondition.Name.Value).(*graphql.Object)
+			}
+			add(w.walk(target, sel.SelectionSet, depth))
+
+		case *ast.FragmentSpread:
+			frag, ok := w.fragments[sel.Name.Value]
+			if !ok {
+				continue
+			}
+			target, _ := w.schema.Type(frag.TypeCondition.Name.Value).(*graphql.Object)
+			add(w.walk(target, frag.SelectionSet, depth))
+		}
+	}
+
+	return maxDepth, cost
+}
+
+func (w queryWalker) listSize(field string, node *ast.Field) int {
+	list, ok := graphqlListSizes[field]
+	if !ok {
+		return 1
+	}
+
+	size := list.size
+	for _, arg := range node.Arguments {
+		if list.arg == "" || arg.Name.Value != list.arg {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(v.Value); err == nil {
+				size = n
+			}
+		case *ast.Variable:
+			// Les variables JSON sont des float64
+			if n, ok := w.variables[v.Name.Value].(float64); ok {
+				size = int(n)
+			}
+		}
+	}
+
+	return max(size, 1)
+}
+
+// --- CHARGEMENT PAR LOTS ---
+
+// Regroupe les chargements demandés par les resolvers d'un même niveau
+// de la requête : Load enregistre la clé et renvoie une fonction que
+// l'exécuteur appelle plus tard. Le premier appel charge toutes les clés
+// en attente en une seule requête, les suivants lisent le résultat.
+type batchLoader[K comparable, V any] struct {
+	mu      sync.Mutex
+	fetch   func([]K) (map[K]V, error)
+	pending []K
+	queued  map[K]bool
+	values  map[K]V
+	errs    map[K]error
+}
+
+func newBatchLoader[K comparable, V any](fetch func([]K) (map[K]V, error)) *batchLoader[K, V] {
+	return &batchLoader[K, V]{
+		fetch:  fetch,
+		queued: make(map[K]bool),
+		values: make(map[K]V),
+		errs:   make(map[K]error),
+	}
+}
+
+func (l *batchLoader[K, V]) Load(key K) func() (V, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			keys := l.pending
+			l.pending = nil
+
+			values, err := l.fetch(keys)
+			for _, k := range keys {
+				if err != nil {
+					l.errs[k] = err
+				} else {
+					l.values[k] = values[k]
+				}
+			}
+		}
+
+		return l.values[key], l.errs[key]
+	}
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/graphql-go/graphql"
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+const graphqlContextKey = contextKey("graphql")
+
+// Nombre maximal de films renvoyés par genre (Genre.movies)
+const maxGenreMovies = 50
+
+// État d'une requête GraphQL partagé par ses resolvers : la requête HTTP,
+// la langue négociée et les loaders qui regroupent les lectures en base.
+type graphqlState struct {
+	r           *http.Request
+	lang        string
+	movies      *batchLoader[int, *store.Movie]
+	movieGenres *batchLoader[int, []store.Genre]
+	genreMovies *batchLoader[genreMoviesKey, []store.Movie]
+}
+
+type genreMoviesKey struct {
+	GenreID int
+	Limit   int
+}
+
+func (app *application) newGraphQLState(r *http.Request, lang string) *graphqlState {
+	s := &graphqlState{r: r, lang: lang}
+
+	s.movies = newBatchLoader(func(ids []int) (map[int]*store.Movie, error) {
+		movies, err := app.store.Movies.GetMoviesByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		if err := app.localizeGraphQLMovies(s.lang, movies); err != nil {
+			return nil, err
+		}
+
+		byID := make(map[int]*store.Movie, len(movies))
+		for i := range movies {
+			byID[movies[i].ID] = &movies[i]
+		}
+		return byID, nil
+	})
+
+	s.movieGenres = newBatchLoader(app.store.Genres.GetMovieGenres)
+
+	s.genreMovies = newBatchLoader(func(keys []genreMoviesKey) (map[genreMoviesKey][]store.Movie, error) {
+		// Une requête par limite demandée, en pratique une seule
+		byLimit := make(map[int][]int)
+		for _, k := range keys {
+			byLimit[k.Limit] = append(byLimit[k.Limit], k.GenreID)
+		}
+
+		result := make(map[genreMoviesKey][]store.Movie, len(keys))
+		for limit, genreIDs := range byLimit {
+			byGenre, err := app.store.Genres.GetGenreMovies(genreIDs, limit)
+			if err != nil {
+				return nil, err
+			}
+
+			// On traduit tous les films d'un coup, puis on les redistribue
+			var all []store.Movie
+			for _, id := range genreIDs {
+				all = append(all, byGenre[id]...)
+			}
+			if err := app.localizeGraphQLMovies(s.lang, all); err != nil {
+				return nil, err
+			}
+			for _, id := range genreIDs {
+				n := len(byGenre[id])
+				result[genreMoviesKey{id, limit}] = all[:n:n]
+				all = all[n:]
+			}
+		}
+		return result, nil
+	})
+
+	return s
+}
+
+func graphqlStateFrom(ctx context.Context) *graphqlState {
+	return ctx.Value(graphqlContextKey).(*graphqlState)
+}
+
+func (app *application) localizeGraphQLMovies(lang string, movies []store.Movie) error {
+	if lang == "" || len(movies) == 0 {
+		return nil
+	}
+	return app.store.Translations.LocalizeMovies(movies, lang)
+}
+
+// Les films arrivent par valeur (listes) ou par pointeur (loader)
+func sourceMovie(p graphql.ResolveParams) store.Movie {
+	if movie, ok := p.Source.(*store.Movie); ok {
+		return *movie
+	}
+	return p.Source.(store.Movie)
+}
+
+// Réserve une mutation aux utilisateurs ayant au moins le rôle demandé,
+// comme requireRole pour les routes REST
+func requireGraphQLRole(ctx context.Context, role string) error {
+	user := contextUser(ctx)
+	if user.IsAnonymous() {
+		return newGraphQLError("UNAUTHENTICATED", "Missing Authorization header")
+	}
+	if !user.HasRole(role) {
+		return newGraphQLError("FORBIDDEN", "Forbidden")
+	}
+	return nil
+}
+
+// Arguments de Query.movies : mêmes noms et mêmes règles que les
+// paramètres de GET /movies, relus par parseMovieFilters
+var movieFilterArgs = graphql.FieldConfigArgument{
+	"title":         {Type: graphql.String, Description: "Recherche dans le titre (et ses traductions)"},
+	"page":          {Type: graphql.Int},
+	"page_size":     {Type: graphql.Int, Description: "20 par défaut"},
+	"sort":          {Type: graphql.String, Description: "Colonne de tri, préfixée par - pour l'ordre décroissant"},
+	"updated_since": {Type: graphql.String, Description: "Date RFC 3339"},
+	"director":      {Type: graphql.String},
+	"actor":         {Type: graphql.String},
+	"language":      {Type: graphql.String, Description: "Langue originale (ISO 639-1)"},
+	"country":       {Type: graphql.String, Description: "Pays de production (ISO 3166-1)"},
+	"certification": {Type: graphql.String, Description: "Classification MPAA ou CNC"},
+	"min_runtime":   {Type: graphql.Int},
+	"max_runtime":   {Type: graphql.Int},
+	"imdb_id":       {Type: graphql.String},
+	"tmdb_id":       {Type: graphql.Int},
+	"watched":       {Type: graphql.Boolean, Description: "Demande un token utilisateur"},
+	"in_watchlist":  {Type: graphql.Boolean, Description: "Demande un token utilisateur"},
+}
+
+// Construit le schéma. Les champs sont nommés comme dans le JSON de l'API REST.
+func (app *application) graphqlSchema() (graphql.Schema, error) {
+	ratingSummaryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RatingSummary",
+		Fields: graphql.Fields{
+			"count":          {Type: graphql.NewNonNull(graphql.Int)},
+			"average":        {Type: graphql.Float},
+			"weighted_score": {Type: graphql.Float},
+		},
+	})
+
+	metadataType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Metadata",
+		Fields: graphql.Fields{
+			"current_page":  {Type: graphql.NewNonNull(graphql.Int)},
+			"page_size":     {Type: graphql.NewNonNull(graphql.Int)},
+			"first_page":    {Type: graphql.NewNonNull(graphql.Int)},
+			"last_page":     {Type: graphql.NewNonNull(graphql.Int)},
+			"total_records": {Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	// Movie et Genre se référencent : leurs champs sont lus à la construction du schéma
+	var movieType, genreType *graphql.Object
+
+	movieType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Movie",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":                {Type: graphql.NewNonNull(graphql.Int)},
+				"title":             {Type: graphql.NewNonNull(graphql.String)},
+				"release_year":      {Type: graphql.NewNonNull(graphql.Int)},
+				"rating":            {Type: graphql.Float, Description: "Note éditoriale"},
+				"review":            {Type: graphql.String, Description: "Critique éditoriale"},
+				"runtime":           {Type: graphql.Int, Description: "En minutes"},
+				"original_title":    {Type: graphql.String},
+				"original_language": {Type: graphql.String},
+				"countries":         {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+				"mpaa_rating":       {Type: graphql.String},
+				"cnc_rating":        {Type: graphql.String},
+				"synopsis":          {Type: graphql.String},
+				"tagline":           {Type: graphql.String},
+				"budget":            {Type: graphql.Float, Description: "En dollars US"},
+				"box_office":        {Type: graphql.Float, Description: "En dollars US"},
+				"imdb_id":           {Type: graphql.String},
+				"tmdb_id":           {Type: graphql.Int},
+				"user_ratings":      {Type: ratingSummaryType},
+				"created_at":        {Type: graphql.NewNonNull(graphql.DateTime)},
+				"updated_at":        {Type: graphql.NewNonNull(graphql.DateTime)},
+				"genres": {
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(genreType))),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						load := graphqlStateFrom(p.Context).movieGenres.Load(sourceMovie(p).ID)
+						return func() (any, error) {
+							genres, err := load()
+							if err != nil {
+								return nil, graphqlInternalError("Error fetching movie genres:", err)
+							}
+							if genres == nil {
+								genres = []store.Genre{}
+							}
+							return genres, nil
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	genreType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Genre",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": {Type: graphql.NewNonNull(graphql.Int)},
+				"name": {
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "Libellé dans la langue demandée (lang ou Accept-Language)",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(store.Genre).Label(graphqlStateFrom(p.Context).lang), nil
+					},
+				},
+				"movies": {
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(movieType))),
+					Description: "Les films du genre, les mieux notés d'abord",
+					Args: graphql.FieldConfigArgument{
+						"first": {Type: graphql.Int, DefaultValue: 10, Description: fmt.Sprintf("%d au plus", maxGenreMovies)},
+					},
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						first, _ := p.Args["first"].(int)
+						if first < 1 || first > maxGenreMovies {
+							return nil, newGraphQLError("BAD_USER_INPUT", fmt.Sprintf("first must be between 1 and %d", maxGenreMovies))
+						}
+
+						key := genreMoviesKey{GenreID: p.Source.(store.Genre).ID, Limit: first}
+						load := graphqlStateFrom(p.Context).genreMovies.Load(key)
+						return func() (any, error) {
+							movies, err := load()
+							if err != nil {
+								return nil, graphqlInternalError("Error fetching genre movies:", err)
+							}
+							if movies == nil {
+								movies = []store.Movie{}
+							}
+							return movies, nil
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	moviePageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "MoviePage",
+		Fields: graphql.Fields{
+			"metadata": {Type: graphql.NewNonNull(metadataType)},
+			"movies":   {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(movieType)))},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"movies": {
+				Type:    graphql.NewNonNull(moviePageType),
+				Args:    movieFilterArgs,
+				Resolve: app.resolveMovies,
+			},
+			"movie": {
+				Type: movieType,
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					load := graphqlStateFrom(p.Context).movies.Load(p.Args["id"].(int))
+					return func() (any, error) {
+						movie, err := load()
+						if err != nil {
+							return nil, graphqlInternalError("Error fetching movie:", err)
+						}
+						if movie == nil {
+							return nil, nil
+						}
+						return movie, nil
+					}, nil
+				},
+			},
+			"genres": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(genreType))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					genres, err := app.store.Genres.GetGenres()
+					if err != nil {
+						return nil, graphqlInternalError("Error fetching genres:", err)
+					}
+					return genres, nil
+				},
+			},
+		},
+	})
+
+	// Champs de MovieInput : mêmes noms que le JSON de POST /movies
+	movieInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "MovieInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":             {Type: graphql.NewNonNull(graphql.String)},
+			"release_year":      {Type: graphql.NewNonNull(graphql.Int)},
+			"rating":            {Type: graphql.Float},
+			"review":            {Type: graphql.String},
+			"genres":            {Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "Noms de genres existants"},
+			"runtime":           {Type: graphql.Int},
+			"original_title":    {Type: graphql.String},
+			"original_language": {Type: graphql.String},
+			"countries":         {Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"mpaa_rating":       {Type: graphql.String},
+			"cnc_rating":        {Type: graphql.String},
+			"synopsis":          {Type: graphql.String},
+			"tagline":           {Type: graphql.String},
+			"budget":            {Type: graphql.Float, Description: "En dollars US"},
+			"box_office":        {Type: graphql.Float, Description: "En dollars US"},
+			"imdb_id":           {Type: graphql.String},
+			"tmdb_id":           {Type: graphql.Int},
+		},
+	})
+
+	movieIDArg := graphql.FieldConfigArgument{
+		"id": {Type: graphql.NewNonNull(graphql.Int)},
+	}
+	movieInputArg := &graphql.ArgumentConfig{Type: graphql.NewNonNull(movieInputType)}
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"create_movie": {
+				Type:    graphql.NewNonNull(movieType),
+				Args:    graphql.FieldConfigArgument{"input": movieInputArg},
+				Resolve: app.resolveCreateMovie,
+			},
+			"update_movie": {
+				Type:    graphql.NewNonNull(movieType),
+				Args:    graphql.FieldConfigArgument{"id": movieIDArg["id"], "input": movieInputArg},
+				Resolve: app.resolveUpdateMovie,
+			},
+			"delete_movie": {
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Args:    movieIDArg,
+				Resolve: app.resolveDeleteMovie,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+}
+
+// --- RESOLVERS ---
+
+func (app *application) resolveMovies(p graphql.ResolveParams) (any, error) {
+	state := graphqlStateFrom(p.Context)
+
+	// Les arguments sont relus comme des paramètres d'URL
+	// pour appliquer exactement les règles de GET /movies
+	values := url.Values{}
+	for name, value := range p.Args {
+		values.Set(name, fmt.Sprint(value))
+	}
+
+	filters, err := parseMovieFilters(values)
+	if err != nil {
+		return nil, newGraphQLError("BAD_USER_INPUT", err.Error())
+	}
+	if err := app.setFilterUser(state.r, &filters); err != nil {
+		return nil, newGraphQLError("UNAUTHENTICATED", err.Error())
+	}
+
+	movies, metadata, err := app.store.Movies.GetMovies(values.Get("title"), filters)
+	if err != nil {
+		return nil, graphqlInternalError("Error fetching movies:", err)
+	}
+	if err := app.localizeGraphQLMovies(state.lang, movies); err != nil {
+		return nil, graphqlInternalError("Error translating movies:", err)
+	}
+
+	return map[string]any{"metadata": metadata, "movies": movies}, nil
+}
+
+func (app *application) resolveCreateMovie(p graphql.ResolveParams) (any, error) {
+	if err := requireGraphQLRole(p.Context, store.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	movie, err := graphqlMovieInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+
+	newMovie, err := app.store.Movies.AddMovie(movie)
+	if err != nil {
+		return nil, movieMutationError("Error adding movie:", err)
+	}
+
+	return newMovie, nil
+}
+
+func (app *application) resolveUpdateMovie(p graphql.ResolveParams) (any, error) {
+	if err := requireGraphQLRole(p.Context, store.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	movie, err := graphqlMovieInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+	movie.ID = p.Args["id"].(int)
+
+	if err := app.store.Movies.UpdateMovie(movie); err != nil {
+		return nil, movieMutationError("Error updating movie:", err)
+	}
+
+	// On relit le film pour renvoyer l'horodatage à jour
+	movie, err = app.store.Movies.GetMoviebyID(movie.ID)
+	if err != nil {
+		return nil, graphqlInternalError("Error fetching updated movie:", err)
+	}
+
+	return movie, nil
+}
+
+func (app *application) resolveDeleteMovie(p graphql.ResolveParams) (any, error) {
+	if err := requireGraphQLRole(p.Context, store.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	id := p.Args["id"].(int)
+	if err := app.store.Movies.DeleteMovie(id); err != nil {
+		return nil, movieMutationError("Error deleting movie:", err)
+	}
+
+	app.removePosterFiles(id)
+
+	return true, nil
+}
+
+// Convertit MovieInput en store.Movie en passant par le JSON de l'API REST,
+// puis applique Movie.Validate
+func graphqlMovieInput(input any) (store.Movie, error) {
+	var movie store.Movie
+
+	data, err := json.Marshal(input)
+	if err == nil {
+		err = json.Unmarshal(data, &movie)
+	}
+	if err != nil {
+		return store.Movie{}, newGraphQLError("BAD_USER_INPUT", "budget, box_office and tmdb_id must be whole numbers")
+	}
+
+	if err := movie.Validate(); err != nil {
+		return store.Movie{}, newGraphQLError("BAD_USER_INPUT", err.Error())
+	}
+
+	return movie, nil
+}
+
+// Traduit les erreurs des mutations de films comme les handlers REST
+func movieMutationError(msg string, err error) error {
+	var dupErr *store.DuplicateMovieError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return newGraphQLError("NOT_FOUND", "Movie not found")
+	case errors.Is(err, store.ErrGenreNotFound):
+		return newGraphQLError("BAD_USER_INPUT", err.Error())
+	case errors.As(err, &dupErr):
+		e := newGraphQLError("CONFLICT", store.ErrDuplicateMovie.Error())
+		if dupErr.ExistingID != 0 {
+			e.extensions["existing_id"] = dupErr.ExistingID
+		}
+		return e
+	case errors.Is(err, store.ErrDuplicateMovie):
+		return newGraphQLError("CONFLICT", err.Error())
+	default:
+		return graphqlInternalError(msg, err)
+	}
+}
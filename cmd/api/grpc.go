@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vfaust1/movie-api/internal/moviepb"
+	"github.com/vfaust1/movie-api/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Implémentation de moviepb.MovieService sur le même Storage que l'API REST
+type movieServer struct {
+	moviepb.UnimplementedMovieServiceServer
+	app *application
+}
+
+// Construit le serveur gRPC : MovieService, health checking et réflexion
+func (app *application) grpcServer() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(app.grpcAuthUnary),
+		grpc.ChainStreamInterceptor(app.grpcAuthStream),
+	)
+
+	moviepb.RegisterMovieServiceServer(srv, &movieServer{app: app})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(moviepb.MovieService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthServer)
+
+	reflection.Register(srv)
+
+	return srv
+}
+
+func (s *movieServer) List(ctx context.Context, req *moviepb.ListRequest) (*moviepb.ListResponse, error) {
+	values := movieFilterValues(req.GetFilter(), req.GetSort())
+	if req.GetPage() > 0 {
+		values.Set("page", strconv.Itoa(int(req.GetPage())))
+	}
+	if req.GetPageSize() > 0 {
+		values.Set("page_size", strconv.Itoa(int(req.GetPageSize())))
+	}
+
+	filters, err := parseMovieFilters(values)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	movies, meta, err := s.app.store.Movies.GetMovies(values.Get("title"), filters)
+	if err != nil {
+		return nil, grpcMovieError("Error fetching movies:", err)
+	}
+
+	resp := &moviepb.ListResponse{
+		Movies: make([]*moviepb.Movie, len(movies)),
+		Metadata: &moviepb.Metadata{
+			CurrentPage:  int32(meta.CurrentPage),
+			PageSize:     int32(meta.PageSize),
+			FirstPage:    int32(meta.FirstPage),
+			LastPage:     int32(meta.LastPage),
+			TotalRecords: int32(meta.TotalRecords),
+		},
+	}
+	for i, movie := range movies {
+		resp.Movies[i] = movieToProto(movie)
+	}
+
+	return resp, nil
+}
+
+func (s *movieServer) Get(ctx context.Context, req *moviepb.GetRequest) (*moviepb.Movie, error) {
+	movie, err := s.app.store.Movies.GetMoviebyID(int(req.GetId()))
+	if err != nil {
+		return nil, grpcMovieError("Error fetching movie:", err)
+	}
+	return movieToProto(movie), nil
+}
+
+func (s *movieServer) Create(ctx context.Context, req *moviepb.CreateRequest) (*moviepb.Movie, error) {
+	if err := requireGRPCRole(ctx, store.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	movie := movieFromInput(req.GetMovie())
+	if err := movie.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	newMovie, err := s.app.store.Movies.AddMovie(movie)
+	if err != nil {
+		return nil, grpcMovieError("Error adding movie:", err)
+	}
+
+	return movieToProto(newMovie), nil
+}
+
+func (s *movieServer) Update(ctx context.Context, req *moviepb.UpdateRequest) (*moviepb.Movie, error) {
+	if err := requireGRPCRole(ctx, store.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	movie := movieFromInput(req.GetMovie())
+	movie.ID = int(req.GetId())
+	if err := movie.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.app.store.Movies.UpdateMovie(movie); err != nil {
+		return nil, grpcMovieError("Error updating movie:", err)
+	}
+
+	// On relit le film pour renvoyer les genres et l'horodatage à jour
+	movie, err := s.app.store.Movies.GetMoviebyID(movie.ID)
+	if err != nil {
+		return nil, grpcMovieError("Error fetching updated movie:", err)
+	}
+
+	return movieToProto(movie), nil
+}
+
+func (s *movieServer) Delete(ctx context.Context, req *moviepb.DeleteRequest) (*emptypb.Empty, error) {
+	if err := requireGRPCRole(ctx, store.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	id := int(req.GetId())
+	if err := s.app.store.Movies.DeleteMovie(id); err != nil {
+		return nil, grpcMovieError("Error deleting movie:", err)
+	}
+
+	s.app.removePosterFiles(id)
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *movieServer) ListAll(req *moviepb.ListAllRequest, stream grpc.ServerStreamingServer[moviepb.Movie]) error {
+	values := movieFilterValues(req.GetFilter(), req.GetSort())
+
+	filters, err := parseMovieFilters(values)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Une erreur d'envoi (client parti) arrête la lecture
+	var sendErr error
+	err = s.app.store.Movies.ExportMovies(values.Get("title"), filters, func(movie store.Movie) error {
+		sendErr = stream.Send(movieToProto(movie))
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return grpcMovieError("Error exporting movies:", err)
+	}
+
+	return nil
+}
+
+// --- AUTHENTIFICATION ---
+
+// Identifie l'appelant à partir de la métadonnée authorization
+// ("Bearer <token>"), comme authMiddleware. Les lectures restent
+// publiques ; santé et réflexion ne demandent rien.
+func (app *application) grpcAuthenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if !strings.HasPrefix(fullMethod, "/"+moviepb.MovieService_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return context.WithValue(ctx, userContextKey, store.AnonymousUser), nil
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "Invalid Authorization header format")
+	}
+
+	user, err := app.authenticate(token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.Unauthenticated, "Invalid API key")
+		}
+		log.Println("Error authenticating user:", err)
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return context.WithValue(ctx, userContextKey, user), nil
+}
+
+func (app *application) grpcAuthUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := app.grpcAuthenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (app *application) grpcAuthStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := app.grpcAuthenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// ServerStream dont le contexte porte l'utilisateur
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// Réserve un appel aux utilisateurs ayant au moins le rôle demandé
+func requireGRPCRole(ctx context.Context, role string) error {
+	user := contextUser(ctx)
+	if user.IsAnonymous() {
+		return status.Error(codes.Unauthenticated, "Missing Authorization header")
+	}
+	if !user.HasRole(role) {
+		return status.Error(codes.PermissionDenied, "Forbidden")
+	}
+	return nil
+}
+
+// --- HELPERS ---
+
+// Traduit les erreurs des films en codes gRPC, comme les handlers REST
+func grpcMovieError(msg string, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "Movie not found")
+	case errors.Is(err, store.ErrGenreNotFound):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, store.ErrDuplicateMovie):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		log.Println(msg, err)
+		return status.Error(codes.Internal, "Internal server error")
+	}
+}
+
+// Traduit les filtres en paramètres de GET /movies,
+// relus par parseMovieFilters pour appliquer les mêmes règles
+func movieFilterValues(f *moviepb.MovieFilter, sort string) url.Values {
+	values := url.Values{}
+	set := func(name, value string) {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+
+	set("sort", sort)
+	set("title", f.GetTitle())
+	set("director", f.GetDirector())
+	set("actor", f.GetActor())
+	set("language", f.GetLanguage())
+	set("country", f.GetCountry())
+	set("certification", f.GetCertification())
+	set("imdb_id", f.GetImdbId())
+
+	if f.GetUpdatedSince() != nil {
+		set("updated_since", f.GetUpdatedSince().AsTime().Format(time.RFC3339Nano))
+	}
+	if f != nil && f.MinRuntime != nil {
+		set("min_runtime", strconv.Itoa(int(*f.MinRuntime)))
+	}
+	if f != nil && f.MaxRuntime != nil {
+		set("max_runtime", strconv.Itoa(int(*f.MaxRuntime)))
+	}
+	if f != nil && f.TmdbId != nil {
+		set("tmdb_id", strconv.Itoa(int(*f.TmdbId)))
+	}
+
+	return values
+}
+
+func movieToProto(m store.Movie) *moviepb.Movie {
+	pb := &moviepb.Movie{
+		Id:               int32(m.ID),
+		Title:            m.Title,
+		ReleaseYear:      int32(m.ReleaseYear),
+		Rating:           m.Rating,
+		Review:           m.Review,
+		Genres:           m.Genres,
+		Runtime:          int32Ptr(m.Runtime),
+		OriginalTitle:    m.OriginalTitle,
+		OriginalLanguage: m.OriginalLanguage,
+		Countries:        m.Countries,
+		MpaaRating:       m.MPAARating,
+		CncRating:        m.CNCRating,
+		Synopsis:         m.Synopsis,
+		Tagline:          m.Tagline,
+		Budget:           m.Budget,
+		BoxOffice:        m.BoxOffice,
+		ImdbId:           m.IMDbID,
+		TmdbId:           int32Ptr(m.TMDBID),
+		CreatedAt:        timestamppb.New(m.CreatedAt),
+		UpdatedAt:        timestamppb.New(m.UpdatedAt),
+	}
+
+	if m.UserRatings != nil {
+		pb.UserRatings = &moviepb.RatingSummary{
+			Count:         int32(m.UserRatings.Count),
+			Average:       m.UserRatings.Average,
+			WeightedScore: m.UserRatings.WeightedScore,
+		}
+	}
+
+	return pb
+}
+
+func movieFromInput(in *moviepb.MovieInput) store.Movie {
+	if in == nil {
+		in = &moviepb.MovieInput{}
+	}
+
+	return store.Movie{
+		Title:            in.GetTitle(),
+		ReleaseYear:      int(in.GetReleaseYear()),
+		Rating:           in.Rating,
+		Review:           in.Review,
+		Genres:           in.GetGenres(),
+		Runtime:          intPtr(in.Runtime),
+		OriginalTitle:    in.OriginalTitle,
+		OriginalLanguage: in.OriginalLanguage,
+		Countries:        in.GetCountries(),
+		MPAARating:       in.MpaaRating,
+		CNCRating:        in.CncRating,
+		Synopsis:         in.Synopsis,
+		Tagline:          in.Tagline,
+		Budget:           in.Budget,
+		BoxOffice:        in.BoxOffice,
+		IMDbID:           in.ImdbId,
+		TMDBID:           intPtr(in.TmdbId),
+	}
+}
+
+func int32Ptr(p *int) *int32 {
+	if p == nil {
+		return nil
+	}
+	v := int32(*p)
+	return &v
+}
+
+func intPtr(p *int32) *int {
+	if p == nil {
+		return nil
+	}
+	v := int(*p)
+	return &v
+}
@@ -3,72 +3,101 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/vfaust1/movie-api/internal/store"
 )
 
-type CreateMovieRequest struct {
-	Title       string   `json:"title" example:"The Matrix"`
-	ReleaseYear int      `json:"release_year" example:"1999"`
-	Rating      float64  `json:"rating" example:"8.7"`
-	Review      string   `json:"review" example:"Un chef d'oeuvre de SF"`
-	Genres      []string `json:"genres" example:"Action,Sci-Fi"`
-}
-
 type Movie struct {
-	ID          int      `json:"id" example:"1"`
-	Title       string   `json:"title" example:"The Matrix"`
-	ReleaseYear int      `json:"release_year" example:"1999"`
-	Rating      float64  `json:"rating" example:"8.7"`
-	Review      string   `json:"review" example:"Un chef d'oeuvre de SF"`
-	Genres      []string `json:"genres" example:"Action,Sci-Fi"`
+	ID               int           `json:"id" example:"1"`
+	Title            string        `json:"title" example:"The Matrix"`
+	ReleaseYear      int           `json:"release_year" example:"1999"`
+	Rating           float64       `json:"rating" example:"8.7"`
+	Review           string        `json:"review" example:"Un chef d'oeuvre de SF"`
+	Genres           []string      `json:"genres" example:"Action,Sci-Fi"`
+	Runtime          int           `json:"runtime" example:"136"`
+	OriginalTitle    string        `json:"original_title" example:"The Matrix"`
+	OriginalLanguage string        `json:"original_language" example:"en"`
+	Countries        []string      `json:"countries" example:"US,AU"`
+	MPAARating       string        `json:"mpaa_rating" example:"R" enums:"G,PG,PG-13,R,NC-17"`
+	CNCRating        string        `json:"cnc_rating" example:"-12" enums:"TP,-12,-16,-18"`
+	Synopsis         string        `json:"synopsis" example:"Un pirate informatique découvre que le monde n'est qu'une simulation."`
+	Tagline          string        `json:"tagline" example:"Welcome to the Real World."`
+	Budget           int64         `json:"budget" example:"63000000"`
+	BoxOffice        int64         `json:"box_office" example:"467222728"`
+	IMDbID           string        `json:"imdb_id" example:"tt0133093"`
+	TMDBID           int           `json:"tmdb_id" example:"603"`
+	Poster           *store.Poster `json:"poster,omitempty"`
+	CreatedAt        time.Time     `json:"created_at" example:"2024-01-15T10:00:00Z"`
+	UpdatedAt        time.Time     `json:"updated_at" example:"2024-01-16T08:30:00Z"`
 }
 
 // --- Les Handlers ---
 
 // GetAllMovies godoc
 // @Summary      Lister les films
-// @Description  Renvoie la liste complète des films
+// @Description  Renvoie la liste complète des films. Avec updated_since, ne renvoie que
+// @Description  les films modifiés depuis cette date ainsi que les films supprimés ("deleted").
+// @Description  Les films supprimés ne sont pas paginés : ils sont tous renvoyés sur la première
+// @Description  page, et absents des suivantes.
+// @Description  La recherche par titre porte aussi sur les titres traduits.
+// @Description  fields limite les colonnes lues et renvoyées, include ajoute les genres et le générique.
 // @Tags         movies
 // @Accept       json
-// @Produce      json
+// @Produce      json,application/xml,text/csv,application/msgpack
+// @Param        updated_since  query     string  false  "Date RFC 3339 (ex: 2024-01-15T10:00:00Z)"
+// @Param        director       query     string  false  "ID ou nom (partiel) du réalisateur"
+// @Param        actor          query     string  false  "ID ou nom (partiel) d'un acteur"
+// @Param        sort           query     string  false  "Tri (ex: -weighted_rating, -average_rating, -rating_count, runtime, -budget, -box_office)"
+// @Param        language       query     string  false  "Langue originale, code ISO 639-1 (ex: en)"
+// @Param        country        query     string  false  "Pays de production, code ISO 3166-1 alpha-2 (ex: FR)"
+// @Param        certification  query     string  false  "Classification MPAA ou CNC (ex: PG-13, -12)"
+// @Param        min_runtime    query     int     false  "Durée minimale en minutes"
+// @Param        max_runtime    query     int     false  "Durée maximale en minutes"
+// @Param        imdb_id        query     string  false  "Identifiant IMDb (ex: tt0133093)"
+// @Param        tmdb_id        query     int     false  "Identifiant TMDB"
+// @Param        watched        query     bool    false  "Films vus (true) ou non vus (false) par l'utilisateur connecté"
+// @Param        in_watchlist   query     bool    false  "Films présents ou non dans la liste \"à voir\" de l'utilisateur connecté"
+// @Param        lang           query     string  false  "Langue du titre, du synopsis et des genres (fr, en), sinon Accept-Language"
+// @Param        fields         query     string  false  "Champs à renvoyer, séparés par des virgules (ex: id,title,release_year)"
+// @Param        include        query     string  false  "Relations à inclure : genres, credits (ex: genres,credits)"
 // @Success      200  {array}   Movie
+// @Success      304  {string}  string "Non modifié depuis If-Modified-Since"
+// @Failure      400  {string}  string "Paramètre invalide"
 // @Router       /movies [get]
 // @Security     BearerAuth
 func (app *application) getAllMoviesHandler(w http.ResponseWriter, r *http.Request) {
 	queryValues := r.URL.Query()
 	title := queryValues.Get("title")
-	page := 1
 
-	if p := queryValues.Get("page"); p != "" {
-		if n, err := strconv.Atoi(p); err == nil && n > 0 {
-			page = n
-		}
+	filters, err := parseMovieFilters(queryValues)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	pageSize := 20
-
-	if ps := queryValues.Get("page_size"); ps != "" {
-		if n, err := strconv.Atoi(ps); err == nil && n > 0 {
-			pageSize = n
-		}
-
+	if err := app.setFilterUser(r, &filters); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
 	}
 
-	sort := "id"
-	if s := queryValues.Get("sort"); s != "" {
-		sort = s
+	fs, err := parseFieldset(queryValues)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	filters.Fields = fs.storeFields(r)
 
-	filters := store.Filters{
-		Page:         page,
-		PageSize:     pageSize,
-		Sort:         sort,
-		SortSafelist: []string{"id", "title", "release_year", "rating"},
+	lang, err := requestLanguage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	movies, metadata, err := app.store.Movies.GetMovies(title, filters)
@@ -79,22 +108,69 @@ func (app *application) getAllMoviesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if err := app.includeRelations(movies, fs.include); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println("Error fetching movie relations :", err)
+		return
+	}
+
+	if err := app.localizeMovies(w, lang, movies); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println("Error translating movies :", err)
+		return
+	}
+
+	projected, err := fs.project(r, movies)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println("Error selecting movie fields :", err)
+		return
+	}
+
 	response := map[string]any{
 		"metadata": metadata,
-		"movies":   movies,
+		"movies":   projected,
+	}
+
+	// Pour une synchronisation, on renvoie aussi les films supprimés,
+	// une seule fois : sur la première page
+	if filters.UpdatedSince != nil && filters.Page == 1 {
+		deleted, err := app.store.Movies.GetDeletedMovies(*filters.UpdatedSince)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Println("Error fetching deleted movies :", err)
+			return
+		}
+		response["deleted"] = deleted
+	}
+
+	notModified, err := app.catalogueNotModified(w, r, filters.UserID != 0)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println("Error fetching catalogue last modification :", err)
+		return
+	}
+	if notModified {
+		return
 	}
 
-	respondWithJSON(w, http.StatusOK, response)
+	// En CSV, les films seulement, sans les supprimés
+	respond(w, r, http.StatusOK, csvEnvelope{"movies", response})
 }
 
 // GetMovie godoc
 // @Summary      Récupérer un film par ID
-// @Description  Renvoie les détails d'un film spécifique
+// @Description  Renvoie les détails d'un film spécifique, générique compris
 // @Tags         movies
 // @Accept       json
-// @Produce      json
-// @Param        id   path      int  true  "ID du film"
+// @Produce      json,application/xml,application/msgpack
+// @Param        id       path      int     true   "ID du film"
+// @Param        lang     query     string  false  "Langue du titre, du synopsis et des genres (fr, en), sinon Accept-Language"
+// @Param        fields   query     string  false  "Champs à renvoyer, séparés par des virgules (ex: id,title), sans relations sauf include"
+// @Param        include  query     string  false  "Relations à inclure avec fields : genres, credits"
 // @Success      200  {object}  Movie
+// @Success      304  {string}  string "Non modifié depuis If-Modified-Since"
+// @Failure      400  {string}  string "Langue, champ ou relation non gérés"
 // @Failure      404  {string}  string "Film non trouvé"
 // @Router       /movies/{id} [get]
 // @Security     BearerAuth
@@ -107,7 +183,25 @@ func (app *application) getMovieByIDHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	movie, err := app.store.Movies.GetMoviebyID(id)
+	lang, err := requestLanguage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fs, err := parseFieldset(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Sans liste de champs, le film est renvoyé en entier, relations comprises
+	var movie store.Movie
+	if len(fs.fields) == 0 {
+		movie, err = app.store.Movies.GetMoviebyID(id)
+	} else {
+		movie, err = app.store.Movies.GetMovieFields(id, fs.storeFields(r))
+	}
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Movie not found", http.StatusNotFound)
@@ -118,26 +212,58 @@ func (app *application) getMovieByIDHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, movie)
+	movies := []store.Movie{movie}
+	if len(fs.fields) > 0 {
+		if err := app.includeRelations(movies, fs.include); err != nil {
+			log.Println("Error fetching movie relations:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := app.localizeMovies(w, lang, movies); err != nil {
+		log.Println("Error translating movie:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	projected, err := fs.project(r, movies)
+	if err != nil {
+		log.Println("Error selecting movie fields:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	notModified, err := app.catalogueNotModified(w, r, false)
+	if err != nil {
+		log.Println("Error fetching catalogue last modification:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if notModified {
+		return
+	}
+	respond(w, r, http.StatusOK, projected[0])
 }
 
 // CreateMovie godoc
 // @Summary      Créer un film
 // @Description  Ajoute un nouveau film à la base de données
 // @Tags         movies
-// @Accept       json
-// @Produce      json
-// @Param        input body CreateMovieRequest true "Infos du film"
-// @Success      201  {string}  string "Film créé"
+// @Accept       json,application/xml,application/msgpack
+// @Produce      json,application/xml,application/msgpack
+// @Param        input body store.Movie true "Infos du film"
+// @Success      201  {object}  store.Movie
 // @Failure      400  {string}  string "Erreur"
+// @Failure      409  {object}  map[string]any "Film déjà existant (existing_id)"
 // @Router       /movies [post]
 // @Security     BearerAuth
 func (app *application) createMovieHandler(w http.ResponseWriter, r *http.Request) {
 	var movie store.Movie
 
-	err := json.NewDecoder(r.Body).Decode(&movie)
+	err := decodeBody(r, &movie)
 	if err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		respondBodyError(w, err)
 		return
 	}
 
@@ -148,6 +274,14 @@ func (app *application) createMovieHandler(w http.ResponseWriter, r *http.Reques
 
 	newMovie, err := app.store.Movies.AddMovie(movie)
 
+	if errors.Is(err, store.ErrGenreNotFound) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, store.ErrDuplicateMovie) {
+		respondDuplicate(w, r, err)
+		return
+	}
 	if err != nil {
 		log.Println("Error adding movie:", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -156,15 +290,15 @@ func (app *application) createMovieHandler(w http.ResponseWriter, r *http.Reques
 
 	fmt.Printf("Movie added: %+v\n", newMovie)
 
-	respondWithJSON(w, http.StatusCreated, newMovie)
+	respond(w, r, http.StatusCreated, versionedMovie(r, newMovie))
 }
 
 // DeleteMovie godoc
 // @Summary      Supprimer un film
-// @Description  Efface définitivement un film de la base de données
+// @Description  Efface définitivement un film de la base de données, ainsi que son affiche
 // @Tags         movies
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/xml,application/msgpack
+// @Produce      json,application/xml,application/msgpack
 // @Param        id   path      int  true  "ID du Film"
 // @Success      200  {string}  string "Film supprimé avec succès"
 // @Failure      404  {string}  string "Film non trouvé"
@@ -190,6 +324,8 @@ func (app *application) deleteMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	app.removePosterFiles(id)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -200,10 +336,11 @@ func (app *application) deleteMovieHandler(w http.ResponseWriter, r *http.Reques
 // @Accept       json
 // @Produce      json
 // @Param        id     path    int                 true "ID du Film"
-// @Param        input  body    CreateMovieRequest  true "Nouvelles infos du film"
+// @Param        input  body    store.Movie  true "Nouvelles infos du film"
 // @Success      200    {object} Movie
 // @Failure      400    {string} string "Erreur de validation"
 // @Failure      404    {string} string "Film non trouvé"
+// @Failure      409    {object} map[string]any "Film déjà existant (existing_id)"
 // @Router       /movies/{id} [put]
 // @Security     BearerAuth
 func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Request) {
@@ -216,9 +353,9 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	var movie store.Movie
-	err = json.NewDecoder(r.Body).Decode(&movie)
+	err = decodeBody(r, &movie)
 	if err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		respondBodyError(w, err)
 		return
 	}
 
@@ -233,6 +370,8 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Movie not found", http.StatusNotFound)
+		} else if errors.Is(err, store.ErrDuplicateMovie) {
+			respondDuplicate(w, r, err)
 		} else {
 			log.Println("Error updating movie:", err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
@@ -240,11 +379,118 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, movie)
+	// On relit le film pour renvoyer les genres et l'horodatage à jour
+	movie, err = app.store.Movies.GetMoviebyID(id)
+	if err != nil {
+		log.Println("Error fetching updated movie:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, versionedMovie(r, movie))
 }
 
 // --- HELPERS ---
 
+// Lit la pagination, le tri et les filtres communs
+// aux routes qui listent des films
+func parseMovieFilters(queryValues url.Values) (store.Filters, error) {
+	page := 1
+
+	if p := queryValues.Get("page"); p != "" {
+		if n, err := strconv.Atoi(p); err == nil && n > 0 {
+			page = n
+		}
+	}
+
+	pageSize := 20
+
+	if ps := queryValues.Get("page_size"); ps != "" {
+		if n, err := strconv.Atoi(ps); err == nil && n > 0 {
+			pageSize = n
+		}
+
+	}
+
+	sort := "id"
+	if s := queryValues.Get("sort"); s != "" {
+		sort = s
+	}
+
+	filters := store.Filters{
+		Page:     page,
+		PageSize: pageSize,
+		Sort:     sort,
+		SortSafelist: []string{"id", "title", "release_year", "rating", "created_at", "updated_at", "average_rating", "rating_count", "weighted_rating",
+			"runtime", "budget", "box_office"},
+	}
+
+	if us := queryValues.Get("updated_since"); us != "" {
+		since, err := time.Parse(time.RFC3339, us)
+		if err != nil {
+			return store.Filters{}, errors.New("updated_since must be an RFC 3339 date")
+		}
+		filters.UpdatedSince = &since
+	}
+
+	filters.Director = queryValues.Get("director")
+	filters.Actor = queryValues.Get("actor")
+
+	filters.Language = queryValues.Get("language")
+	filters.Country = queryValues.Get("country")
+	filters.Certification = queryValues.Get("certification")
+	filters.IMDbID = queryValues.Get("imdb_id")
+
+	numeric := []struct {
+		name  string
+		value **int
+	}{
+		{"min_runtime", &filters.MinRuntime},
+		{"max_runtime", &filters.MaxRuntime},
+		{"tmdb_id", &filters.TMDBID},
+	}
+	for _, p := range numeric {
+		if v := queryValues.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return store.Filters{}, fmt.Errorf("%s must be a positive integer", p.name)
+			}
+			*p.value = &n
+		}
+	}
+
+	// Filtres personnels, liés à l'utilisateur par setFilterUser
+	personal := []struct {
+		name  string
+		value **bool
+	}{
+		{"watched", &filters.Watched},
+		{"in_watchlist", &filters.InWatchlist},
+	}
+	for _, p := range personal {
+		if v := queryValues.Get(p.name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return store.Filters{}, fmt.Errorf("%s must be true or false", p.name)
+			}
+			*p.value = &b
+		}
+	}
+
+	return filters, nil
+}
+
+// Ne garde que la pagination de la requête
+func parsePageFilters(queryValues url.Values) store.Filters {
+	filters, _ := parseMovieFilters(url.Values{
+		"page":      {queryValues.Get("page")},
+		"page_size": {queryValues.Get("page_size")},
+	})
+	return filters
+}
+
+// Réponse toujours en JSON, quel que soit Accept (GraphQL).
+// Les autres handlers passent par respond.
 func respondWithJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
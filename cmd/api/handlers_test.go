@@ -1,12 +1,38 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/vfaust1/movie-api/internal/moviepb"
 	"github.com/vfaust1/movie-api/internal/store"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 // --- LE MOCK  ---
@@ -22,10 +48,327 @@ func (m MockMovieStore) GetMovies(title string, filters store.Filters) ([]store.
 	return mockMovies, metadata, nil
 }
 
-func (m MockMovieStore) AddMovie(movie store.Movie) (store.Movie, error) { return store.Movie{}, nil }
-func (m MockMovieStore) GetMoviebyID(id int) (store.Movie, error)        { return store.Movie{}, nil }
-func (m MockMovieStore) UpdateMovie(movie store.Movie) error             { return nil }
-func (m MockMovieStore) DeleteMovie(id int) error                        { return nil }
+func (m MockMovieStore) ExportMovies(title string, filters store.Filters, fn func(store.Movie) error) error {
+	review := "Culte, \"rouge ou bleue\"\net suites"
+	mockMovies := []store.Movie{
+		{ID: 1, Title: "The Matrix", ReleaseYear: 1999, Review: &review, Genres: []string{"Action", "Sci-Fi"}},
+		{ID: 2, Title: "Fake Movie 2", ReleaseYear: 2021},
+	}
+	for _, movie := range mockMovies {
+		if err := fn(movie); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m MockMovieStore) AddMovie(movie store.Movie) (store.Movie, error) {
+	// "The Matrix" existe déjà dans le faux catalogue
+	if movie.Title == "The Matrix" {
+		return store.Movie{}, &store.DuplicateMovieError{ExistingID: 1}
+	}
+	return store.Movie{}, nil
+}
+func (m MockMovieStore) GetMoviebyID(id int) (store.Movie, error) { return store.Movie{}, nil }
+func (m MockMovieStore) GetMovieFields(id int, fields []string) (store.Movie, error) {
+	return store.Movie{ID: id, Title: "Fake Movie", ReleaseYear: 2020}, nil
+}
+func (m MockMovieStore) GetMoviesByIDs(ids []int) ([]store.Movie, error) {
+	movies := []store.Movie{}
+	for _, id := range ids {
+		if id == 1 || id == 2 {
+			movies = append(movies, store.Movie{ID: id, Title: fmt.Sprintf("Fake Movie %d", id), ReleaseYear: 2019 + id})
+		}
+	}
+	return movies, nil
+}
+func (m MockMovieStore) UpdateMovie(movie store.Movie) error { return nil }
+func (m MockMovieStore) DeleteMovie(id int) error            { return nil }
+func (m MockMovieStore) BulkApply(ops []store.BulkOperation, atomic bool) ([]store.BulkResult, error) {
+	results := make([]store.BulkResult, len(ops))
+	for i, op := range ops {
+		results[i] = store.BulkResult{ID: op.ID}
+		if op.Op == store.BulkCreate {
+			results[i].ID = 100 + i
+		}
+	}
+	return results, nil
+}
+func (m MockMovieStore) FindDuplicates(threshold float64, yearTolerance int, filters store.Filters) ([]store.DuplicatePair, store.Metadata, error) {
+	return []store.DuplicatePair{}, store.Metadata{}, nil
+}
+func (m MockMovieStore) MergeMovies(targetID, duplicateID int) (store.Movie, error) {
+	return store.Movie{ID: targetID}, nil
+}
+func (m MockMovieStore) SetCredits(movieID int, credits []store.Credit) ([]store.Credit, error) {
+	return credits, nil
+}
+func (m MockMovieStore) GetMovieCredits(movieIDs []int) (map[int][]store.Credit, error) {
+	return map[int][]store.Credit{1: {{PersonID: 3, Name: "Lana Wachowski", Role: store.CreditDirector}}}, nil
+}
+func (m MockMovieStore) GetDeletedMovies(since time.Time) ([]store.DeletedMovie, error) {
+	return []store.DeletedMovie{{ID: 3, DeletedAt: since.Add(time.Hour)}}, nil
+}
+func (m MockMovieStore) LastModified() (time.Time, error) {
+	return time.Time{}, nil
+}
+
+type MockRatingStore struct{}
+
+func (m MockRatingStore) SetRating(userID, movieID, score int) (store.UserRating, error) {
+	if score < 0 || score > 10 {
+		return store.UserRating{}, store.ErrInvalidScore
+	}
+	return store.UserRating{UserID: userID, MovieID: movieID, Score: score}, nil
+}
+func (m MockRatingStore) GetRating(userID, movieID int) (store.UserRating, error) {
+	return store.UserRating{UserID: userID, MovieID: movieID, Score: 7}, nil
+}
+func (m MockRatingStore) DeleteRating(userID, movieID int) error { return nil }
+
+type MockReviewStore struct{}
+
+// La critique 1 appartient à l'utilisateur 5
+func (m MockReviewStore) AddReview(review store.Review) (store.Review, error) {
+	review.ID = 1
+	return review, nil
+}
+func (m MockReviewStore) GetReviewByID(id int) (store.Review, error) {
+	if id != 1 {
+		return store.Review{}, sql.ErrNoRows
+	}
+	return store.Review{ID: 1, MovieID: 1, UserID: 5, Body: "Superbe", Status: store.ReviewApproved}, nil
+}
+func (m MockReviewStore) GetMovieReviews(movieID int, hideSpoilers bool, filters store.Filters) ([]store.Review, store.Metadata, error) {
+	return []store.Review{}, store.Metadata{}, nil
+}
+func (m MockReviewStore) GetReviewsByStatus(status string, filters store.Filters) ([]store.Review, store.Metadata, error) {
+	return []store.Review{}, store.Metadata{}, nil
+}
+func (m MockReviewStore) UpdateReview(review store.Review) error { return nil }
+func (m MockReviewStore) DeleteReview(id int) error              { return nil }
+func (m MockReviewStore) ModerateReview(id int, status string, note *string) (store.Review, error) {
+	return store.Review{ID: id, Status: status, ModerationNote: note}, nil
+}
+func (m MockReviewStore) VoteReview(reviewID, userID int, helpful bool) (store.Review, error) {
+	return store.Review{ID: reviewID}, nil
+}
+func (m MockReviewStore) DeleteReviewVote(reviewID, userID int) error { return nil }
+
+type MockListStore struct{}
+
+// La liste 1 est privée et appartient à l'utilisateur 5
+func (m MockListStore) AddList(list store.MovieList) (store.MovieList, error) {
+	list.ID = 1
+	return list, nil
+}
+func (m MockListStore) GetListByID(id int) (store.MovieList, error) {
+	if id != 1 {
+		return store.MovieList{}, sql.ErrNoRows
+	}
+	owner := 5
+	return store.MovieList{ID: 1, OwnerID: &owner, Name: "Ma liste", Kind: store.ListKindList}, nil
+}
+func (m MockListStore) GetLists(searchName string, viewerID int, ownOnly bool, filters store.Filters) ([]store.MovieList, store.Metadata, error) {
+	return []store.MovieList{}, store.Metadata{}, nil
+}
+func (m MockListStore) GetMovieLists(movieID, viewerID int) ([]store.MovieList, error) {
+	return []store.MovieList{}, nil
+}
+func (m MockListStore) UpdateList(list store.MovieList) error { return nil }
+func (m MockListStore) DeleteList(id int) error               { return nil }
+func (m MockListStore) AddListItem(listID, movieID int, position *int, note *string) error {
+	return nil
+}
+func (m MockListStore) MoveListItem(listID, movieID, position int) error { return nil }
+func (m MockListStore) RemoveListItem(listID, movieID int) error         { return nil }
+
+type MockStatsStore struct{}
+
+func (m MockStatsStore) GetStats(title string, filters store.Filters) (store.CatalogueStats, error) {
+	return store.CatalogueStats{TotalMovies: 2, MissingRating: 1}, nil
+}
+
+type MockGenreStore struct{}
+
+func (m MockGenreStore) GetGenres() ([]store.Genre, error) {
+	return []store.Genre{{ID: 1, Name: "Action"}, {ID: 2, Name: "Comédie"}, {ID: 4, Name: "Sci-Fi"}}, nil
+}
+
+// Les films 1 et 2 sont des films d'action, seul le 1 est une comédie
+func (m MockGenreStore) GetMovieGenres(movieIDs []int) (map[int][]store.Genre, error) {
+	action := store.Genre{ID: 1, Name: "Action"}
+	comedy := store.Genre{ID: 2, Name: "Comédie", Translations: map[string]string{"en": "Comedy"}}
+	genres := map[int][]store.Genre{}
+	for _, id := range movieIDs {
+		switch id {
+		case 1:
+			genres[id] = []store.Genre{action, comedy}
+		case 2:
+			genres[id] = []store.Genre{action}
+		}
+	}
+	return genres, nil
+}
+func (m MockGenreStore) GetGenreMovies(genreIDs []int, limit int) (map[int][]store.Movie, error) {
+	return map[int][]store.Movie{}, nil
+}
+
+type MockTranslationStore struct{}
+
+func (m MockTranslationStore) GetMovieTranslations(movieID int) ([]store.MovieTranslation, error) {
+	return []store.MovieTranslation{}, nil
+}
+func (m MockTranslationStore) SetMovieTranslation(t store.MovieTranslation) (store.MovieTranslation, error) {
+	return t, nil
+}
+func (m MockTranslationStore) DeleteMovieTranslation(movieID int, language string) error { return nil }
+func (m MockTranslationStore) SetGenreTranslation(genreID int, language, name string) error {
+	return nil
+}
+func (m MockTranslationStore) DeleteGenreTranslation(genreID int, language string) error { return nil }
+
+// Seul le film 1 est traduit, en anglais
+func (m MockTranslationStore) LocalizeMovies(movies []store.Movie, language string) error {
+	for i := range movies {
+		if movies[i].ID == 1 && language == "en" {
+			movies[i].Title = "Translated Movie 1"
+		}
+	}
+	return nil
+}
+
+type MockPosterStore struct{}
+
+func (m MockPosterStore) GetPoster(movieID int) (store.Poster, error) {
+	return store.Poster{}, sql.ErrNoRows
+}
+
+// Seul le film 1 existe
+func (m MockPosterStore) SetPoster(p store.Poster) (store.Poster, error) {
+	if p.MovieID != 1 {
+		return store.Poster{}, sql.ErrNoRows
+	}
+	p.UpdatedAt = time.Now()
+	return p, nil
+}
+func (m MockPosterStore) DeletePoster(movieID int) error { return nil }
+
+// Journal en mémoire : add y ajoute un événement pendant qu'un flux est ouvert
+type MockEventStore struct {
+	mu     sync.Mutex
+	events []store.MovieEvent
+}
+
+func (m *MockEventStore) add(eventType string, movieID int, payload string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := int64(len(m.events) + 1)
+	m.events = append(m.events, store.MovieEvent{ID: id, Type: eventType, MovieID: movieID, Payload: json.RawMessage(payload)})
+}
+
+func (m *MockEventStore) GetEventsSince(afterID int64, genre string, limit int) ([]store.MovieEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var events []store.MovieEvent
+	for _, e := range m.events {
+		var payload struct{ Genres []string }
+		json.Unmarshal(e.Payload, &payload)
+		if e.ID > afterID && (genre == "" || slices.Contains(payload.Genres, genre)) && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+func (m *MockEventStore) LastEventID() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.events)), nil
+}
+func (m *MockEventStore) DeleteEventsBefore(before time.Time) (int64, error) { return 0, nil }
+func (m *MockEventStore) Listen(ctx context.Context, notify func()) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// File d'envois en mémoire : chaque ClaimDeliveries vide la file
+// et les tentatives enregistrées sont gardées pour les vérifications
+type MockWebhookStore struct {
+	mu       sync.Mutex
+	queue    []store.WebhookDelivery
+	attempts []store.WebhookAttempt
+	retries  []*time.Time
+}
+
+func (m *MockWebhookStore) AddWebhook(w store.Webhook) (store.Webhook, error) {
+	w.ID, w.Active = 1, true
+	return w, nil
+}
+func (m *MockWebhookStore) GetWebhookByID(id int) (store.Webhook, error) {
+	if id != 1 {
+		return store.Webhook{}, sql.ErrNoRows
+	}
+	return store.Webhook{ID: 1, URL: "https://partner.example.com/hooks", Active: true}, nil
+}
+func (m *MockWebhookStore) GetWebhooks() ([]store.Webhook, error)                { return nil, nil }
+func (m *MockWebhookStore) UpdateWebhook(w store.Webhook) (store.Webhook, error) { return w, nil }
+func (m *MockWebhookStore) DeleteWebhook(id int) error                           { return nil }
+func (m *MockWebhookStore) GetDeliveries(webhookID int, filters store.Filters) ([]store.WebhookDelivery, store.Metadata, error) {
+	return nil, store.Metadata{}, nil
+}
+func (m *MockWebhookStore) ClaimDeliveries(limit int, lease time.Duration) ([]store.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	claimed := m.queue
+	m.queue = nil
+	return claimed, nil
+}
+func (m *MockWebhookStore) RecordAttempt(a store.WebhookAttempt, retryAt *time.Time, disableAfter int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts = append(m.attempts, a)
+	m.retries = append(m.retries, retryAt)
+	return false, nil
+}
+
+// Clés d'idempotence en mémoire, sans expiration
+type MockIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]store.IdempotentResponse
+}
+
+func (m *MockIdempotencyStore) ReserveKey(principal, key, requestHash string, ttl time.Duration) (store.IdempotentResponse, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.keys[principal+key]; ok {
+		return existing, false, nil
+	}
+	m.keys[principal+key] = store.IdempotentResponse{RequestHash: requestHash}
+	return m.keys[principal+key], true, nil
+}
+func (m *MockIdempotencyStore) GetKey(principal, key string) (store.IdempotentResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp, ok := m.keys[principal+key]
+	if !ok {
+		return store.IdempotentResponse{}, sql.ErrNoRows
+	}
+	return resp, nil
+}
+func (m *MockIdempotencyStore) CompleteKey(principal, key string, resp store.IdempotentResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp.RequestHash = m.keys[principal+key].RequestHash
+	m.keys[principal+key] = resp
+	return nil
+}
+func (m *MockIdempotencyStore) ReleaseKey(principal, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, principal+key)
+	return nil
+}
+func (m *MockIdempotencyStore) DeleteExpiredKeys() (int64, error) { return 0, nil }
 
 // --- LE TEST ---
 func TestGetAllMoviesHandler(t *testing.T) {
@@ -60,3 +403,1835 @@ func TestGetAllMoviesHandler(t *testing.T) {
 		t.Errorf("On attendait 2 films, on en a reçu %d", len(moviesList))
 	}
 }
+
+func TestGetAllMoviesHandler_UpdatedSince(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+		},
+	}
+
+	// Date invalide -> 400
+	req := httptest.NewRequest(http.MethodGet, "/movies?updated_since=hier", nil)
+	rr := httptest.NewRecorder()
+	app.getAllMoviesHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	// Date valide -> les films supprimés sont inclus
+	req = httptest.NewRequest(http.MethodGet, "/movies?updated_since=2024-01-15T10:00:00Z", nil)
+	rr = httptest.NewRecorder()
+	app.getAllMoviesHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var response map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Impossible de décoder le JSON de réponse")
+	}
+
+	deleted, ok := response["deleted"].([]any)
+	if !ok || len(deleted) != 1 {
+		t.Errorf("On attendait 1 film supprimé, on a reçu %v", response["deleted"])
+	}
+
+	// Les pages suivantes ne répètent pas les films supprimés
+	req = httptest.NewRequest(http.MethodGet, "/movies?updated_since=2024-01-15T10:00:00Z&page=2", nil)
+	rr = httptest.NewRecorder()
+	app.getAllMoviesHandler(rr, req)
+
+	response = nil
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Impossible de décoder le JSON de réponse")
+	}
+	if _, ok := response["deleted"]; ok {
+		t.Errorf("deleted ne devrait être renvoyé que sur la première page, reçu %v", response["deleted"])
+	}
+}
+
+func TestBulkMoviesHandler(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+		},
+	}
+
+	body := `{"op":"create","movie":{"title":"Inception","release_year":2010}}
+{"op":"create","movie":{"title":"A","release_year":2010}}
+{"op":"delete","id":4}`
+
+	// Mode atomique : un film invalide -> rien n'est appliqué
+	req := httptest.NewRequest(http.MethodPost, "/movies/bulk?atomic=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	app.bulkMoviesHandler(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+	}
+
+	// Mode best-effort : les opérations valides sont appliquées
+	req = httptest.NewRequest(http.MethodPost, "/movies/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rr = httptest.NewRecorder()
+	app.bulkMoviesHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Succeeded int              `json:"succeeded"`
+		Results   []bulkItemResult `json:"results"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Impossible de décoder le JSON de réponse")
+	}
+
+	wantStatus := []int{http.StatusCreated, http.StatusBadRequest, http.StatusNoContent}
+	for i, want := range wantStatus {
+		if response.Results[i].Status != want {
+			t.Errorf("opération %d : statut %d, attendu %d", i, response.Results[i].Status, want)
+		}
+	}
+	if response.Succeeded != 2 {
+		t.Errorf("On attendait 2 opérations réussies, on en a %d", response.Succeeded)
+	}
+}
+
+// Le lot se lit dans les mêmes formats que les autres corps de requête
+func TestBulkMoviesHandler_RequestFormats(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+		},
+	}
+
+	ops, err := msgpack.Marshal([]map[string]any{
+		{"op": "create", "movie": map[string]any{"title": "Inception", "release_year": 2010}},
+		{"op": "delete", "id": 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"JSON", "application/json", `[{"op":"create","movie":{"title":"Inception","release_year":2010}},{"op":"delete","id":4}]`, http.StatusOK},
+		{"XML", "application/xml", `<operations><operation><op>create</op><movie><title>Inception</title><release_year>2010</release_year></movie></operation><operation><op>delete</op><id>4</id></operation></operations>`, http.StatusOK},
+		{"msgpack", "application/msgpack", string(ops), http.StatusOK},
+		{"XML illisible", "application/xml", `<operations>`, http.StatusBadRequest},
+		{"format non géré", "text/csv", "op,id\ndelete,4\n", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/movies/bulk", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+			app.bulkMoviesHandler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Code = %d, attendu %d (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Succeeded int `json:"succeeded"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Succeeded != 2 {
+				t.Errorf("succeeded = %d, attendu 2", response.Succeeded)
+			}
+		})
+	}
+}
+
+func TestExportMoviesHandler_CSV(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/movies/export?format=csv", nil)
+	rr := httptest.NewRecorder()
+	app.exportMoviesHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if cd := rr.Header().Get("Content-Disposition"); !strings.Contains(cd, ".csv") {
+		t.Errorf("Content-Disposition inattendu : %q", cd)
+	}
+
+	// La critique contient une virgule, des guillemets et un saut de ligne
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV invalide : %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("On attendait 3 lignes (en-tête + 2 films), on en a %d", len(records))
+	}
+	if records[1][4] != "Culte, \"rouge ou bleue\"\net suites" {
+		t.Errorf("Critique mal échappée : %q", records[1][4])
+	}
+	if records[1][5] != "Action|Sci-Fi" {
+		t.Errorf("Genres inattendus : %q", records[1][5])
+	}
+}
+
+func TestImportMoviesHandler_DryRun(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+			Genres: MockGenreStore{},
+		},
+	}
+
+	// En-têtes français, séparateur ";" et virgule décimale
+	body := "Titre;Année;Note;Critique;Genres\n" +
+		"La Cité de la peur;1994;7,5;\"Culte; vraiment\";comédie\n" +
+		"X;1994;;;\n" +
+		"Matrix;1999;8,7;;Action|Western\n" +
+		"Inception;deux mille dix;;;\n"
+
+	req := httptest.NewRequest(http.MethodPost, "/movies/import?dry_run=true&delimiter=%3B", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	rr := httptest.NewRecorder()
+	app.importMoviesHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	var response struct {
+		ValidRows int               `json:"valid_rows"`
+		Errors    []importLineError `json:"errors"`
+		Created   []any             `json:"created"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Impossible de décoder le JSON de réponse")
+	}
+
+	if response.ValidRows != 1 {
+		t.Errorf("On attendait 1 ligne valide, on en a %d", response.ValidRows)
+	}
+
+	wantLines := []int{3, 4, 5}
+	if len(response.Errors) != len(wantLines) {
+		t.Fatalf("On attendait %d erreurs, on en a %v", len(wantLines), response.Errors)
+	}
+	for i, line := range wantLines {
+		if response.Errors[i].Line != line {
+			t.Errorf("erreur %d : ligne %d, attendu %d", i, response.Errors[i].Line, line)
+		}
+	}
+
+	if len(response.Created) != 0 {
+		t.Errorf("Un dry run ne doit rien créer, on a %v", response.Created)
+	}
+}
+
+func TestCreateMovieHandler_Duplicate(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+		},
+	}
+
+	body := `{"title": "The Matrix", "release_year": 1999}`
+	req := httptest.NewRequest(http.MethodPost, "/movies", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	app.createMovieHandler(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+	}
+
+	var response map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Impossible de décoder le JSON de réponse")
+	}
+	if response["existing_id"] != float64(1) {
+		t.Errorf("On attendait existing_id = 1, on a %v", response["existing_id"])
+	}
+	if loc := rr.Header().Get("Location"); loc != "/movies/1" {
+		t.Errorf("Location inattendu : %q", loc)
+	}
+}
+
+func TestSetMyRatingHandler(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies:  MockMovieStore{},
+			Ratings: MockRatingStore{},
+		},
+	}
+	handler := app.requireUser(app.setMyRatingHandler)
+
+	tests := []struct {
+		name       string
+		user       store.User
+		body       string
+		wantStatus int
+	}{
+		{"anonyme", store.AnonymousUser, `{"score": 8}`, http.StatusUnauthorized},
+		{"clé API", store.APIKeyUser, `{"score": 8}`, http.StatusForbidden},
+		{"note hors limites", store.User{ID: 5, Role: store.RoleUser}, `{"score": 11}`, http.StatusBadRequest},
+		{"note valide", store.User{ID: 5, Role: store.RoleUser}, `{"score": 8}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/movies/1/ratings/me", strings.NewReader(tt.body))
+			req.SetPathValue("id", "1")
+			req = app.contextSetUser(req, tt.user)
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateReviewHandler_Status(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Reviews: MockReviewStore{},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		user       store.User
+		wantStatus string
+	}{
+		{"utilisateur", store.User{ID: 5, Role: store.RoleUser}, store.ReviewPending},
+		{"éditeur", store.User{ID: 6, Role: store.RoleEditor}, store.ReviewApproved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"body": "  Une mise en scène vertigineuse.  ", "spoiler": true}`
+			req := httptest.NewRequest(http.MethodPost, "/movies/1/reviews", strings.NewReader(body))
+			req.SetPathValue("id", "1")
+			req = app.contextSetUser(req, tt.user)
+			rr := httptest.NewRecorder()
+			app.createReviewHandler(rr, req)
+
+			if rr.Code != http.StatusCreated {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+			}
+
+			var review store.Review
+			if err := json.Unmarshal(rr.Body.Bytes(), &review); err != nil {
+				t.Fatal("Impossible de décoder le JSON de réponse")
+			}
+			if review.Status != tt.wantStatus || !review.Spoiler || review.Body != "Une mise en scène vertigineuse." {
+				t.Errorf("Critique inattendue : %+v", review)
+			}
+		})
+	}
+}
+
+func TestUpdateReviewHandler_NotAuthor(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Reviews: MockReviewStore{},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/reviews/1", strings.NewReader(`{"body": "Pas à moi"}`))
+	req.SetPathValue("id", "1")
+	req = app.contextSetUser(req, store.User{ID: 7, Role: store.RoleEditor})
+	rr := httptest.NewRecorder()
+	app.updateReviewHandler(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+}
+
+func TestGetAllMoviesHandler_PersonalFilters(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		user       store.User
+		wantStatus int
+	}{
+		{"anonyme", "?watched=false", store.AnonymousUser, http.StatusUnauthorized},
+		{"clé API", "?in_watchlist=true", store.APIKeyUser, http.StatusUnauthorized},
+		{"valeur invalide", "?watched=peut-etre", store.User{ID: 5, Role: store.RoleUser}, http.StatusBadRequest},
+		{"utilisateur", "?watched=false&in_watchlist=true", store.User{ID: 5, Role: store.RoleUser}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/movies"+tt.query, nil)
+			req = app.contextSetUser(req, tt.user)
+			rr := httptest.NewRecorder()
+			app.getAllMoviesHandler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestListHandlers_Visibility(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Lists: MockListStore{},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		user       store.User
+		wantStatus int
+	}{
+		{"lecture anonyme d'une liste privée", http.MethodGet, store.AnonymousUser, http.StatusNotFound},
+		{"lecture par le propriétaire", http.MethodGet, store.User{ID: 5, Role: store.RoleUser}, http.StatusOK},
+		{"suppression par un autre utilisateur", http.MethodDelete, store.User{ID: 6, Role: store.RoleUser}, http.StatusNotFound},
+		{"suppression par un éditeur", http.MethodDelete, store.User{ID: 7, Role: store.RoleEditor}, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/lists/1", nil)
+			req.SetPathValue("id", "1")
+			req = app.contextSetUser(req, tt.user)
+			rr := httptest.NewRecorder()
+
+			if tt.method == http.MethodGet {
+				app.getListByIDHandler(rr, req)
+			} else {
+				app.deleteListHandler(rr, req)
+			}
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGetStatsHandler_ETag(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Stats: MockStatsStore{},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	rr := httptest.NewRecorder()
+	app.getStatsHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if cc := rr.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Errorf("Cache-Control inattendu : %q", cc)
+	}
+
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag manquant")
+	}
+
+	// Le même ETag renvoie 304 sans corps
+	req = httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	app.getStatsHandler(rr, req)
+
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotModified)
+	}
+	if rr.Body.Len() != 0 {
+		t.Errorf("Une réponse 304 ne doit pas avoir de corps")
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"en", "en"},
+		{"en-US,en;q=0.9,fr;q=0.8", "en"},
+		{"fr-CA, en;q=0.5", "fr"},
+		{"de, en;q=0.7, fr;q=0.9", "fr"},
+		{"de", "fr"},
+		{"en;q=0, fr;q=0.1", "fr"},
+		{"*", "fr"},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateLanguage(tt.header); got != tt.want {
+			t.Errorf("negotiateLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestGetAllMoviesHandler_Localized(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies:       MockMovieStore{},
+			Translations: MockTranslationStore{},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/movies", nil)
+	req.Header.Set("Accept-Language", "de, en-GB;q=0.8")
+	rr := httptest.NewRecorder()
+	app.getAllMoviesHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if lang := rr.Header().Get("Content-Language"); lang != "en" {
+		t.Errorf("Content-Language inattendu : %q", lang)
+	}
+
+	var response struct {
+		Movies []store.Movie `json:"movies"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Impossible de décoder le JSON de réponse")
+	}
+	if response.Movies[0].Title != "Translated Movie 1" || response.Movies[1].Title != "Fake Movie 2" {
+		t.Errorf("Titres inattendus : %q, %q", response.Movies[0].Title, response.Movies[1].Title)
+	}
+
+	// Le paramètre lang l'emporte et doit être une langue gérée
+	req = httptest.NewRequest(http.MethodGet, "/movies?lang=de", nil)
+	req.Header.Set("Accept-Language", "en")
+	rr = httptest.NewRecorder()
+	app.getAllMoviesHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestUploadPosterHandler(t *testing.T) {
+	blobs, err := store.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &application{
+		store: store.Storage{Posters: MockPosterStore{}},
+		blobs: blobs,
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 400, 600))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/movies/1/poster", bytes.NewReader(buf.Bytes()))
+	req.SetPathValue("id", "1")
+	rr := httptest.NewRecorder()
+	app.uploadPosterHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body)
+	}
+
+	var poster store.Poster
+	if err := json.Unmarshal(rr.Body.Bytes(), &poster); err != nil {
+		t.Fatal("Impossible de décoder le JSON de réponse")
+	}
+	if poster.Format != "png" || poster.Width != 400 || poster.Height != 600 {
+		t.Fatalf("Affiche inattendue : %+v", poster)
+	}
+
+	// Le mock ne renvoie pas les URL : on retrouve la vignette sur le disque
+	files, _ := filepath.Glob(filepath.Join(blobs.Dir, store.PosterPrefix(1), "*", "w185.jpg"))
+	if len(files) != 1 {
+		t.Fatalf("Vignette w185 introuvable : %v", files)
+	}
+	key, _ := filepath.Rel(blobs.Dir, files[0])
+
+	// La vignette est servie avec un cache long
+	req = httptest.NewRequest(http.MethodGet, store.ImagesURLPrefix+filepath.ToSlash(key), nil)
+	req.SetPathValue("path", filepath.ToSlash(key))
+	rr = httptest.NewRecorder()
+	app.serveImageHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Content-Type inattendu : %q", ct)
+	}
+	if cc := rr.Header().Get("Cache-Control"); cc != imageCacheControl {
+		t.Errorf("Cache-Control inattendu : %q", cc)
+	}
+
+	// Un film inconnu ne garde aucun fichier
+	req = httptest.NewRequest(http.MethodPut, "/movies/2/poster", bytes.NewReader(buf.Bytes()))
+	req.SetPathValue("id", "2")
+	rr = httptest.NewRecorder()
+	app.uploadPosterHandler(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+	if files, _ := filepath.Glob(filepath.Join(blobs.Dir, store.PosterPrefix(2), "*")); len(files) != 0 {
+		t.Errorf("Les fichiers du film 2 devaient être supprimés : %v", files)
+	}
+
+	// Un fichier qui n'est pas une image est refusé
+	req = httptest.NewRequest(http.MethodPut, "/movies/1/poster", strings.NewReader("pas une image"))
+	req.SetPathValue("id", "1")
+	rr = httptest.NewRecorder()
+	app.uploadPosterHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
+// Compte les appels pour vérifier que les genres sont chargés par lots
+type countingGenreStore struct {
+	MockGenreStore
+	calls *[][]int
+}
+
+func (m countingGenreStore) GetMovieGenres(movieIDs []int) (map[int][]store.Genre, error) {
+	*m.calls = append(*m.calls, movieIDs)
+	return m.MockGenreStore.GetMovieGenres(movieIDs)
+}
+
+type graphqlResponse struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func doGraphQL(t *testing.T, app *application, user store.User, query string, header http.Header) (*httptest.ResponseRecorder, graphqlResponse) {
+	t.Helper()
+
+	body, _ := json.Marshal(GraphQLRequest{Query: query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req = app.contextSetUser(req, user)
+
+	rr := httptest.NewRecorder()
+	app.graphqlHandler()(rr, req)
+
+	var response graphqlResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Impossible de décoder le JSON de réponse : %s", rr.Body)
+	}
+	return rr, response
+}
+
+func TestGraphQLHandler_MoviesWithGenres(t *testing.T) {
+	var calls [][]int
+	app := &application{
+		store: store.Storage{
+			Movies:       MockMovieStore{},
+			Genres:       countingGenreStore{calls: &calls},
+			Translations: MockTranslationStore{},
+		},
+	}
+
+	query := `{ movies(page_size: 2) { metadata { total_records } movies { id title genres { name } } } }`
+	rr, response := doGraphQL(t, app, store.AnonymousUser, query, http.Header{"Accept-Language": {"en"}})
+
+	if rr.Code != http.StatusOK || len(response.Errors) > 0 {
+		t.Fatalf("Réponse inattendue (%d) : %s", rr.Code, rr.Body)
+	}
+
+	// Une seule lecture des genres pour toute la page
+	if len(calls) != 1 || len(calls[0]) != 2 {
+		t.Errorf("Genres chargés en %d lots : %v", len(calls), calls)
+	}
+
+	movies := response.Data["movies"].(map[string]any)["movies"].([]any)
+	first := movies[0].(map[string]any)
+	if first["title"] != "Translated Movie 1" {
+		t.Errorf("Titre inattendu : %v", first["title"])
+	}
+	genres := first["genres"].([]any)
+	if len(genres) != 2 || genres[1].(map[string]any)["name"] != "Comedy" {
+		t.Errorf("Genres inattendus : %v", genres)
+	}
+
+	// Les filtres suivent les règles de GET /movies
+	_, response = doGraphQL(t, app, store.AnonymousUser, `{ movies(min_runtime: -5) { movies { id } } }`, nil)
+	if len(response.Errors) != 1 || response.Errors[0].Extensions["code"] != "BAD_USER_INPUT" {
+		t.Errorf("Erreurs inattendues : %+v", response.Errors)
+	}
+}
+
+func TestGraphQLHandler_Limits(t *testing.T) {
+	app := &application{store: store.Storage{Movies: MockMovieStore{}, Genres: MockGenreStore{}}}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"Too deep", `{ genres { movies { genres { movies { genres { movies { genres { movies { id } } } } } } } } }`},
+		{"Too complex", `{ movies(page_size: 1000) { movies { id title genres { name } } } }`},
+		{"Complex through fragment", `query { genres { ...G } } fragment G on Genre { movies(first: 50) { genres { movies(first: 50) { id } } } }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, response := doGraphQL(t, app, store.AnonymousUser, tt.query, nil)
+			if rr.Code != http.StatusBadRequest || len(response.Errors) == 0 {
+				t.Errorf("Réponse inattendue (%d) : %s", rr.Code, rr.Body)
+			}
+		})
+	}
+}
+
+func TestGraphQLHandler_Mutations(t *testing.T) {
+	app := &application{store: store.Storage{Movies: MockMovieStore{}, Genres: MockGenreStore{}}}
+	editor := store.User{ID: 1, Name: "editor", Role: store.RoleEditor}
+
+	tests := []struct {
+		name  string
+		user  store.User
+		query string
+		code  string
+	}{
+		{"Anonymous", store.AnonymousUser, `mutation { delete_movie(id: 1) }`, "UNAUTHENTICATED"},
+		{"Simple user", store.User{ID: 2, Role: store.RoleUser}, `mutation { delete_movie(id: 1) }`, "FORBIDDEN"},
+		{"Invalid movie", editor, `mutation { create_movie(input: {title: "Test", release_year: 1800}) { id } }`, "BAD_USER_INPUT"},
+		{"Duplicate", editor, `mutation { create_movie(input: {title: "The Matrix", release_year: 1999}) { id } }`, "CONFLICT"},
+		{"Deleted", editor, `mutation { delete_movie(id: 1) }`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, response := doGraphQL(t, app, tt.user, tt.query, nil)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+
+			if tt.code == "" {
+				if len(response.Errors) > 0 || response.Data["delete_movie"] != true {
+					t.Errorf("Réponse inattendue : %s", rr.Body)
+				}
+				return
+			}
+			if len(response.Errors) != 1 || response.Errors[0].Extensions["code"] != tt.code {
+				t.Errorf("Erreurs inattendues : %s", rr.Body)
+			}
+		})
+	}
+
+	// Une mutation ne passe pas par GET
+	req := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { delete_movie(id: 1) }`), nil)
+	rr := httptest.NewRecorder()
+	app.graphqlHandler()(rr, app.contextSetUser(req, editor))
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// Le film 42 n'existe pas
+type notFoundMovieStore struct {
+	MockMovieStore
+}
+
+func (m notFoundMovieStore) GetMoviebyID(id int) (store.Movie, error) {
+	if id == 42 {
+		return store.Movie{}, sql.ErrNoRows
+	}
+	return store.Movie{ID: id, Title: "Fake Movie", ReleaseYear: 2020}, nil
+}
+
+// Démarre le serveur gRPC en mémoire et renvoie un client connecté
+func newTestGRPCClient(t *testing.T, app *application) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := app.grpcServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCMovieService(t *testing.T) {
+	t.Setenv("API_KEY", "secret")
+	app := &application{store: store.Storage{Movies: notFoundMovieStore{}}}
+	conn := newTestGRPCClient(t, app)
+	client := moviepb.NewMovieServiceClient(conn)
+
+	ctx := context.Background()
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+
+	// Lecture publique
+	movie, err := client.Get(ctx, &moviepb.GetRequest{Id: 1})
+	if err != nil || movie.GetTitle() != "Fake Movie" {
+		t.Fatalf("Get() = %v, %v", movie, err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"Not found", func() error {
+			_, err := client.Get(ctx, &moviepb.GetRequest{Id: 42})
+			return err
+		}, codes.NotFound},
+		{"Anonymous write", func() error {
+			_, err := client.Delete(ctx, &moviepb.DeleteRequest{Id: 1})
+			return err
+		}, codes.Unauthenticated},
+		{"Wrong key", func() error {
+			bad := metadata.AppendToOutgoingContext(ctx, "authorization", "Basic secret")
+			_, err := client.Delete(bad, &moviepb.DeleteRequest{Id: 1})
+			return err
+		}, codes.Unauthenticated},
+		{"Invalid movie", func() error {
+			_, err := client.Create(authCtx, &moviepb.CreateRequest{Movie: &moviepb.MovieInput{Title: "Test", ReleaseYear: 1800}})
+			return err
+		}, codes.InvalidArgument},
+		{"Invalid filter", func() error {
+			minRuntime := int32(-1)
+			_, err := client.List(ctx, &moviepb.ListRequest{Filter: &moviepb.MovieFilter{MinRuntime: &minRuntime}})
+			return err
+		}, codes.InvalidArgument},
+		{"Duplicate", func() error {
+			_, err := client.Create(authCtx, &moviepb.CreateRequest{Movie: &moviepb.MovieInput{Title: "The Matrix", ReleaseYear: 1999}})
+			return err
+		}, codes.AlreadyExists},
+		{"Deleted", func() error {
+			_, err := client.Delete(authCtx, &moviepb.DeleteRequest{Id: 1})
+			return err
+		}, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call()); code != tt.code {
+				t.Errorf("code = %v, want %v", code, tt.code)
+			}
+		})
+	}
+
+	// ListAll envoie les films un par un
+	stream, err := client.ListAll(ctx, &moviepb.ListAllRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for {
+		movie, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		titles = append(titles, movie.GetTitle())
+	}
+	if len(titles) != 2 || titles[0] != "The Matrix" {
+		t.Errorf("Films reçus : %v", titles)
+	}
+
+	// Health checking, sans authentification
+	health, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: "movieapi.v1.MovieService"})
+	if err != nil || health.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Check() = %v, %v", health, err)
+	}
+}
+
+func TestMovieEventsHandler(t *testing.T) {
+	events := &MockEventStore{}
+	events.add(store.EventMovieCreated, 1, `{"id": 1, "title": "The Matrix", "genres": ["Action"]}`)
+	events.add(store.EventMovieCreated, 2, `{"id": 2, "title": "Amélie", "genres": ["Comédie"]}`)
+	events.add(store.EventMovieUpdated, 1, `{"id": 1, "title": "Matrix", "genres": ["Action"]}`)
+
+	app := &application{
+		store:  store.Storage{Events: events},
+		events: newEventBroker(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/movies/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	rr := httptest.NewRecorder()
+	app.movieEventsHandler(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Last-Event-ID invalide : statut %d, attendu %d", rr.Code, http.StatusBadRequest)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(app.movieEventsHandler))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Reprise après l'événement 0, filtrée sur Action : le film 2 est ignoré
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?genre=Action", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	reader := bufio.NewReader(res.Body)
+	readEvent := func() (id, eventType string) {
+		t.Helper()
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("lecture du flux : %v", err)
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				eventType = strings.TrimPrefix(line, "event: ")
+			case line == "" && id != "":
+				return id, eventType
+			}
+		}
+	}
+
+	want := [][2]string{{"1", store.EventMovieCreated}, {"3", store.EventMovieUpdated}}
+	for _, w := range want {
+		if id, eventType := readEvent(); id != w[0] || eventType != w[1] {
+			t.Errorf("événement %s %s, attendu %s %s", id, eventType, w[0], w[1])
+		}
+	}
+
+	// Un nouvel événement notifié arrive en direct
+	events.add(store.EventMovieDeleted, 1, `{"id": 1, "genres": ["Action"]}`)
+	app.events.broadcast()
+	if id, eventType := readEvent(); id != "4" || eventType != store.EventMovieDeleted {
+		t.Errorf("événement %s %s, attendu 4 %s", id, eventType, store.EventMovieDeleted)
+	}
+}
+
+func TestCreateWebhookHandler(t *testing.T) {
+	app := &application{
+		store: store.Storage{Webhooks: &MockWebhookStore{}},
+	}
+
+	body := `{"url": "ftp://partner.example.com"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	app.createWebhookHandler(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("URL invalide : statut %d, attendu %d", rr.Code, http.StatusBadRequest)
+	}
+
+	// Sans secret fourni, un secret est généré et renvoyé une seule fois
+	body = `{"url": "https://partner.example.com/hooks", "event_types": ["movie.deleted"]}`
+	req = httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	rr = httptest.NewRecorder()
+	app.createWebhookHandler(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+	}
+
+	var webhook store.Webhook
+	if err := json.NewDecoder(rr.Body).Decode(&webhook); err != nil {
+		t.Fatal(err)
+	}
+	if len(webhook.Secret) != 64 {
+		t.Errorf("Secret généré inattendu : %q", webhook.Secret)
+	}
+}
+
+func TestWebhookDispatcher(t *testing.T) {
+	const secret = "un-secret-partage-assez-long"
+
+	var mu sync.Mutex
+	var received []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(webhookSignatureHeader) != signWebhook(secret, body) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		received = append(received, r.Header.Get(webhookEventHeader))
+		mu.Unlock()
+		if r.URL.Path == "/down" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	event := store.MovieEvent{ID: 7, Type: store.EventMovieCreated, MovieID: 1, Payload: json.RawMessage(`{"id": 1}`)}
+	webhooks := &MockWebhookStore{queue: []store.WebhookDelivery{
+		{ID: 1, WebhookID: 1, URL: receiver.URL + "/ok", Secret: secret, Event: event},
+		{ID: 2, WebhookID: 2, URL: receiver.URL + "/down", Secret: secret, Event: event},
+		{ID: 3, WebhookID: 2, URL: receiver.URL + "/down", Secret: secret, Event: event, Attempts: webhookMaxAttempts - 1},
+		{ID: 4, WebhookID: 3, URL: receiver.URL + "/ok", Secret: "un-autre-secret-inconnu", Event: event},
+	}}
+
+	newWebhookDispatcher(webhooks).dispatch(context.Background())
+
+	if len(received) != 3 {
+		t.Errorf("Le récepteur a accepté %d envois signés, attendu 3", len(received))
+	}
+
+	results := map[int64]int{}
+	for i, a := range webhooks.attempts {
+		results[a.DeliveryID] = i
+	}
+	check := func(deliveryID int64, succeeded bool, status int, retry bool) {
+		t.Helper()
+		i, ok := results[deliveryID]
+		if !ok {
+			t.Fatalf("Aucune tentative enregistrée pour l'envoi %d", deliveryID)
+		}
+		a := webhooks.attempts[i]
+		if a.Succeeded != succeeded || a.StatusCode == nil || *a.StatusCode != status {
+			t.Errorf("envoi %d : succeeded=%v status=%v", deliveryID, a.Succeeded, a.StatusCode)
+		}
+		if (webhooks.retries[i] != nil) != retry {
+			t.Errorf("envoi %d : nouvelle tentative = %v, attendu %v", deliveryID, webhooks.retries[i], retry)
+		}
+	}
+
+	check(1, true, http.StatusNoContent, false)
+	check(2, false, http.StatusServiceUnavailable, true)
+	// Dernière tentative : l'envoi est abandonné
+	check(3, false, http.StatusServiceUnavailable, false)
+	check(4, false, http.StatusUnauthorized, true)
+
+	if attempt := webhooks.attempts[results[3]].Attempt; attempt != webhookMaxAttempts {
+		t.Errorf("Numéro de tentative %d, attendu %d", attempt, webhookMaxAttempts)
+	}
+
+	// Le délai double à chaque tentative, à l'aléa près, et reste plafonné
+	for attempt, want := range map[int]time.Duration{1: 30 * time.Second, 3: 2 * time.Minute, 30: webhookMaxDelay} {
+		if got := webhookBackoff(attempt); got < want*9/10 || got > want*11/10 {
+			t.Errorf("webhookBackoff(%d) = %v, attendu environ %v", attempt, got, want)
+		}
+	}
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	keys := &MockIdempotencyStore{keys: map[string]store.IdempotentResponse{}}
+	app := &application{
+		store:          store.Storage{Idempotency: keys},
+		idempotencyTTL: time.Hour,
+	}
+
+	calls := 0
+	handler := app.idempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/fail" {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Location", fmt.Sprintf("/movies/%d", calls))
+		respondWithJSON(w, http.StatusCreated, map[string]any{"id": calls, "input": string(body)})
+	}))
+
+	editor := store.User{ID: 5, Name: "alice", Role: store.RoleEditor}
+	post := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(idempotencyHeader, key)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, app.contextSetUser(req, editor))
+		return rr
+	}
+
+	first := post("/movies", "k1", `{"title": "Dune"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", first.Code, http.StatusCreated)
+	}
+
+	// Le rejeu renvoie la même réponse sans rappeler le handler
+	replay := post("/movies", "k1", `{"title": "Dune"}`)
+	if replay.Code != http.StatusCreated || replay.Body.String() != first.Body.String() || calls != 1 {
+		t.Errorf("rejeu : statut %d, corps %q, %d appels", replay.Code, replay.Body.String(), calls)
+	}
+	if replay.Header().Get("Location") != "/movies/1" || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("En-têtes du rejeu inattendus : %v", replay.Header())
+	}
+
+	if rr := post("/movies", "k1", `{"title": "Alien"}`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Clé réutilisée pour une autre requête : statut %d, attendu %d", rr.Code, http.StatusUnprocessableEntity)
+	}
+
+	// Même corps, mais lu ou renvoyé autrement : ce n'est pas la même requête
+	for _, header := range []struct{ name, value string }{
+		{"Content-Type", "application/xml"},
+		{"Accept", "application/msgpack"},
+		{apiVersionHeader, "2"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/movies", strings.NewReader(`{"title": "Dune"}`))
+		req.Header.Set(idempotencyHeader, "k1")
+		req.Header.Set(header.name, header.value)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, app.contextSetUser(req, editor))
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Clé réutilisée avec %s: %s : statut %d, attendu %d", header.name, header.value, rr.Code, http.StatusUnprocessableEntity)
+		}
+	}
+
+	// Une erreur serveur libère la clé
+	if rr := post("/fail", "k2", `{}`); rr.Code != http.StatusInternalServerError {
+		t.Fatalf("statut %d, attendu %d", rr.Code, http.StatusInternalServerError)
+	}
+	if _, err := keys.GetKey("user:5", "k2"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("La clé k2 devrait être libérée, GetKey() error = %v", err)
+	}
+
+	// Une requête identique en cours : le doublon attend sa réponse
+	in := httptest.NewRequest(http.MethodPost, "/movies", strings.NewReader(`{"title": "Heat"}`))
+	keys.keys["user:5k3"] = store.IdempotentResponse{RequestHash: requestHash(in, []byte(`{"title": "Heat"}`))}
+	go func() {
+		time.Sleep(3 * idempotencyPollInterval)
+		keys.CompleteKey("user:5", "k3", store.IdempotentResponse{Status: http.StatusCreated, Body: []byte(`{"id": 42}`)})
+	}()
+	if rr := post("/movies", "k3", `{"title": "Heat"}`); rr.Code != http.StatusCreated || rr.Body.String() != `{"id": 42}` {
+		t.Errorf("doublon concurrent : statut %d, corps %q", rr.Code, rr.Body.String())
+	}
+	if calls != 2 {
+		t.Errorf("Le handler a été appelé %d fois, attendu 2", calls)
+	}
+}
+
+func TestGetAllMoviesHandler_CacheHeaders(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+		},
+		cacheTTL: 30 * time.Second,
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"catalogue", "", "public, max-age=30"},
+		{"filtres personnels", "?watched=false", "private, no-cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/movies"+tt.query, nil)
+			req = app.contextSetUser(req, store.User{ID: 5, Role: store.RoleUser})
+			rr := httptest.NewRecorder()
+			app.getAllMoviesHandler(rr, req)
+
+			if got := rr.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, attendu %q", got, tt.want)
+			}
+			if vary := rr.Header().Values("Vary"); !slices.Equal(vary, []string{"Accept-Language", "Accept"}) {
+				t.Errorf("Vary = %q", vary)
+			}
+		})
+	}
+}
+
+// Catalogue dont le dernier changement est daté, pour Last-Modified
+type datedMovieStore struct {
+	MockMovieStore
+	lastModified time.Time
+}
+
+func (m datedMovieStore) LastModified() (time.Time, error) { return m.lastModified, nil }
+
+func TestMovieHandlers_IfModifiedSince(t *testing.T) {
+	changed := time.Date(2024, 1, 16, 8, 30, 0, 500, time.UTC)
+
+	tests := []struct {
+		name       string
+		url        string
+		since      time.Time
+		wantStatus int
+		wantHeader bool
+	}{
+		{"liste inchangée", "/movies", changed.Truncate(time.Second), http.StatusNotModified, true},
+		// Les films de la page n'ont pas changé, mais un autre film est
+		// entré dans la recherche (ou en est sorti, ou a été supprimé)
+		{"catalogue modifié depuis", "/movies?title=Fake", changed.Add(-time.Minute), http.StatusOK, true},
+		{"liste réduite", "/movies?fields=title", changed, http.StatusNotModified, true},
+		{"liste réduite modifiée", "/movies?fields=title", changed.Add(-time.Second), http.StatusOK, true},
+		{"liste personnelle", "/movies?watched=false", changed, http.StatusOK, false},
+		{"détail inchangé", "/movies/1", changed, http.StatusNotModified, true},
+		{"détail réduit", "/movies/1?fields=title", changed, http.StatusNotModified, true},
+		{"détail modifié depuis", "/movies/1", changed.Add(-time.Second), http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &application{
+				store: store.Storage{
+					Movies: datedMovieStore{lastModified: changed},
+				},
+				cacheTTL: 30 * time.Second,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req.SetPathValue("id", "1")
+			req.Header.Set("If-Modified-Since", tt.since.Format(http.TimeFormat))
+			req = app.contextSetUser(req, store.User{ID: 5, Role: store.RoleUser})
+			rr := httptest.NewRecorder()
+			if strings.HasPrefix(tt.url, "/movies/") {
+				app.getMovieByIDHandler(rr, req)
+			} else {
+				app.getAllMoviesHandler(rr, req)
+			}
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("statut = %d, attendu %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusNotModified && rr.Body.Len() > 0 {
+				t.Errorf("corps inattendu pour un 304 : %q", rr.Body.String())
+			}
+
+			want := ""
+			if tt.wantHeader {
+				want = changed.Format(http.TimeFormat)
+			}
+			if got := rr.Header().Get("Last-Modified"); got != want {
+				t.Errorf("Last-Modified = %q, attendu %q", got, want)
+			}
+			if vary := rr.Header().Values("Vary"); !slices.Contains(vary, "Accept") {
+				t.Errorf("Vary = %q", vary)
+			}
+		})
+	}
+}
+
+func TestMovieHandlers_Fieldsets(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+			Genres: MockGenreStore{},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		url        string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantKeys   []string
+	}{
+		{"liste réduite", "/movies?fields=title,release_year", app.getAllMoviesHandler, http.StatusOK, []string{"id", "title", "release_year"}},
+		{"ordre demandé", "/movies?fields=release_year,id,title", app.getAllMoviesHandler, http.StatusOK, []string{"release_year", "id", "title"}},
+		{"liste avec relations", "/movies?fields=title&include=credits,genres", app.getAllMoviesHandler, http.StatusOK, []string{"id", "title", "credits", "genres"}},
+		{"détail réduit", "/movies/1?fields=title&include=credits", app.getMovieByIDHandler, http.StatusOK, []string{"id", "title", "credits"}},
+		{"champ inconnu", "/movies?fields=title,password", app.getAllMoviesHandler, http.StatusBadRequest, nil},
+		{"relation inconnue", "/movies/1?include=reviews", app.getMovieByIDHandler, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req.SetPathValue("id", "1")
+			rr := httptest.NewRecorder()
+			tt.handler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Code = %d, attendu %d (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantKeys == nil {
+				return
+			}
+
+			// Les champs doivent suivre l'ordre de fields= puis d'include=
+			body, err := decodeOrdered(rr.Body.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			movies := []any{body}
+			if strings.HasPrefix(tt.url, "/movies?") {
+				movies = body.(*orderedObject).values["movies"].([]any)
+			}
+
+			for _, movie := range movies {
+				if keys := movie.(*orderedObject).keys; !slices.Equal(keys, tt.wantKeys) {
+					t.Errorf("Champs = %v, attendu %v", keys, tt.wantKeys)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAllMoviesHandler_ContentNegotiation(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{"défaut", "", http.StatusOK, "application/json", `"movies":[{"id":1`},
+		{"xml", "application/xml", http.StatusOK, "application/xml; charset=utf-8", "<movies><movie><id>1</id><title>Fake Movie 1</title>"},
+		{"csv", "text/csv", http.StatusOK, "text/csv; charset=utf-8", "id,title,release_year,rating"},
+		{"préférence", "text/csv;q=0.5, application/xml", http.StatusOK, "application/xml; charset=utf-8", "<response>"},
+		{"joker", "text/html, */*;q=0.1", http.StatusOK, "application/json", `"metadata"`},
+		{"non géré", "image/png", http.StatusNotAcceptable, "text/plain; charset=utf-8", "Not Acceptable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/movies", nil)
+			req.Header.Set("Accept", tt.accept)
+			rr := httptest.NewRecorder()
+			app.getAllMoviesHandler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Code = %d, attendu %d", rr.Code, tt.wantStatus)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != tt.wantContentType {
+				t.Errorf("Content-Type = %q, attendu %q", ct, tt.wantContentType)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("Corps inattendu : %s", rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("msgpack", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/movies", nil)
+		req.Header.Set("Accept", "application/msgpack")
+		rr := httptest.NewRecorder()
+		app.getAllMoviesHandler(rr, req)
+
+		var response struct {
+			Movies []struct {
+				ID    int    `msgpack:"id"`
+				Title string `msgpack:"title"`
+			} `msgpack:"movies"`
+		}
+		if err := msgpack.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Movies) != 2 || response.Movies[0].Title != "Fake Movie 1" {
+			t.Errorf("Films = %+v", response.Movies)
+		}
+	})
+
+	// La synchronisation renvoie aussi les films supprimés, mais le CSV
+	// ne garde que les films de la page
+	t.Run("csv et updated_since", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/movies?updated_since=2024-01-01T00:00:00Z", nil)
+		req.Header.Set("Accept", "text/csv")
+		rr := httptest.NewRecorder()
+		app.getAllMoviesHandler(rr, req)
+
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 3 || records[0][0] != "id" || records[0][1] != "title" || records[1][1] != "Fake Movie 1" {
+			t.Errorf("CSV = %q", records)
+		}
+	})
+}
+
+// Le JSON écrit une note de 8.0 comme 8 : les autres formats la rendent
+// tout de même en flottant, film entier comme réduit par fields=
+func TestRespond_WholeFloats(t *testing.T) {
+	rating := 8.0
+	movie := store.Movie{ID: 1, Title: "Inception", Rating: &rating}
+	projected, err := fieldset{fields: []string{"id", "rating"}}.project(httptest.NewRequest(http.MethodGet, "/movies", nil), []store.Movie{movie})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payloads := map[string]any{
+		"film":   movie,
+		"réduit": map[string]any{"movies": projected},
+	}
+	for name, payload := range payloads {
+		t.Run(name+" msgpack", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/movies", nil)
+			req.Header.Set("Accept", "application/msgpack")
+			rr := httptest.NewRecorder()
+			respond(rr, req, http.StatusOK, payload)
+
+			var body map[string]any
+			if err := msgpack.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			got := body["rating"]
+			if movies, ok := body["movies"].([]any); ok {
+				got = movies[0].(map[string]any)["rating"]
+			}
+			if got != 8.0 {
+				t.Errorf("rating = %#v, attendu 8.0 en flottant", got)
+			}
+		})
+
+		t.Run(name+" xml", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/movies", nil)
+			req.Header.Set("Accept", "application/xml")
+			rr := httptest.NewRecorder()
+			respond(rr, req, http.StatusOK, payload)
+
+			if !strings.Contains(rr.Body.String(), "<rating>8.0</rating>") || !strings.Contains(rr.Body.String(), "<id>1</id>") {
+				t.Errorf("Corps = %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateMovieHandler_RequestFormats(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Movies: MockMovieStore{},
+		},
+	}
+
+	matrix, err := msgpack.Marshal(map[string]any{"title": "The Matrix", "release_year": 1999})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		// "The Matrix" existe déjà : un 409 prouve que le corps a été lu
+		{"json", "application/json", `{"title": "The Matrix", "release_year": 1999}`, http.StatusConflict},
+		{"curl -d", "application/x-www-form-urlencoded", `{"title": "The Matrix", "release_year": 1999}`, http.StatusConflict},
+		{"xml", "application/xml", `<movie><title>The Matrix</title><release_year>1999</release_year><rating/><genres><genre>Action</genre></genres></movie>`, http.StatusConflict},
+		{"msgpack", "application/msgpack", string(matrix), http.StatusConflict},
+		{"xml invalide", "application/xml", `<movie><release_year>mil neuf cent</release_year></movie>`, http.StatusBadRequest},
+		{"csv", "text/csv", "title,release_year\nThe Matrix,1999", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/movies", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+			app.createMovieHandler(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Code = %d, attendu %d (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestNegotiationMiddleware(t *testing.T) {
+	app := &application{}
+	handler := app.negotiationMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		method     string
+		accept     string
+		wantStatus int
+	}{
+		{http.MethodPost, "application/xml", http.StatusNoContent},
+		{http.MethodPost, "application/graphql-response+json", http.StatusNoContent},
+		// Rien n'est écrit si la réponse ne pourra pas être envoyée
+		{http.MethodPost, "text/csv", http.StatusNotAcceptable},
+		{http.MethodDelete, "image/png", http.StatusNotAcceptable},
+		// Les lectures ont parfois leur propre format (flux SSE)
+		{http.MethodGet, "text/event-stream", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/movies", nil)
+		req.Header.Set("Accept", tt.accept)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != tt.wantStatus {
+			t.Errorf("%s Accept %q : code %d, attendu %d", tt.method, tt.accept, rr.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestRoutes_Versioning(t *testing.T) {
+	app := &application{
+		store: store.Storage{
+			Genres: MockGenreStore{},
+		},
+		v1Deprecation: deprecation{
+			since:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			sunset: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	handler := app.routes()
+
+	tests := []struct {
+		name        string
+		path        string
+		version     string
+		wantStatus  int
+		wantVersion string
+		wantLink    string
+	}{
+		{"v1", "/v1/genres", "", http.StatusOK, "1", `</v2/genres>; rel="successor-version"`},
+		{"alias de v1", "/genres", "", http.StatusOK, "1", `</v2/genres>; rel="successor-version"`},
+		{"v2", "/v2/genres", "", http.StatusOK, "2", ""},
+		{"v2 par en-tête", "/genres", "2", http.StatusOK, "2", ""},
+		// Le préfixe l'emporte sur l'en-tête
+		{"préfixe et en-tête", "/v1/genres", "v2", http.StatusOK, "1", `</v2/genres>; rel="successor-version"`},
+		{"version inconnue", "/genres", "3", http.StatusBadRequest, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.version != "" {
+				req.Header.Set(apiVersionHeader, tt.version)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Code = %d, attendu %d (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if got := rr.Header().Get(apiVersionHeader); got != tt.wantVersion {
+				t.Errorf("API-Version = %q, attendu %q", got, tt.wantVersion)
+			}
+			if got := rr.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %q, attendu %q", got, tt.wantLink)
+			}
+
+			deprecated := tt.wantLink != ""
+			if got := rr.Header().Get("Deprecation"); deprecated && got != "@1767225600" || !deprecated && got != "" {
+				t.Errorf("Deprecation = %q", got)
+			}
+			if got := rr.Header().Get("Sunset"); deprecated && got != "Thu, 31 Dec 2026 00:00:00 GMT" {
+				t.Errorf("Sunset = %q", got)
+			}
+		})
+	}
+
+	// Sans API_V1_DEPRECATION, la v1 reste courante
+	app.v1Deprecation = deprecation{}
+	rr := httptest.NewRecorder()
+	app.routes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/genres", nil))
+	for _, header := range []string{"Deprecation", "Sunset", "Link"} {
+		if got := rr.Header().Get(header); got != "" {
+			t.Errorf("%s = %q sans dépréciation configurée", header, got)
+		}
+	}
+}
+
+// Film noté par la rédaction et par les utilisateurs
+type ratedMovieStore struct {
+	MockMovieStore
+}
+
+func (m ratedMovieStore) GetMoviebyID(id int) (store.Movie, error) {
+	return m.GetMovieFields(id, store.MovieFields)
+}
+func (m ratedMovieStore) GetMovieFields(id int, fields []string) (store.Movie, error) {
+	rating, average := 8.5, 7.25
+	movie := store.Movie{ID: id, Title: "Inception", Rating: &rating}
+	// Les votes ne sont lus que s'ils sont demandés
+	if slices.Contains(fields, "user_ratings") {
+		movie.UserRatings = &store.RatingSummary{Average: &average, Count: 4}
+	}
+	return movie, nil
+}
+
+func TestRoutes_V2Movies(t *testing.T) {
+	t.Setenv("API_KEY", "secret")
+
+	var mismatches []error
+	app := &application{
+		store:            store.Storage{Movies: ratedMovieStore{}},
+		events:           newEventBroker(),
+		responseMismatch: func(r *http.Request, err error) { mismatches = append(mismatches, err) },
+	}
+	handler := app.routes()
+
+	inception := `{"title":"Inception","release_year":2010,"rating":8.5}`
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		version    string
+		body       string
+		wantStatus int
+		want       string
+	}{
+		{"v1", http.MethodGet, "/v1/movies/1", "", "", http.StatusOK, `"rating":8.5,`},
+		{"alias de v1", http.MethodGet, "/movies/1", "", "", http.StatusOK, `"rating":8.5,`},
+		{"v2", http.MethodGet, "/v2/movies/1", "", "", http.StatusOK, `"rating":{"editorial":8.5,"users":7.25}`},
+		{"v2 par en-tête", http.MethodGet, "/movies/1", "2", "", http.StatusOK, `"rating":{"editorial":8.5,"users":7.25}`},
+		// rating fait lire les votes, sans les renvoyer s'ils ne sont pas demandés
+		{"v2 réduit", http.MethodGet, "/v2/movies/1?fields=rating", "", "", http.StatusOK, `{"id":1,"rating":{"editorial":8.5,"users":7.25}}`},
+		// Les écritures renvoient le film sous la même forme que les lectures
+		{"création v1", http.MethodPost, "/v1/movies", "", inception, http.StatusCreated, `"rating":null,`},
+		{"création v2", http.MethodPost, "/v2/movies", "", inception, http.StatusCreated, `"rating":{"editorial":null,"users":null}`},
+		{"modification v2", http.MethodPut, "/v2/movies/1", "", inception, http.StatusOK, `"rating":{"editorial":8.5,"users":7.25}`},
+		{"modification v2 par en-tête", http.MethodPut, "/movies/1", "2", inception, http.StatusOK, `"rating":{"editorial":8.5,"users":7.25}`},
+		{"fusion v2", http.MethodPost, "/v2/movies/1/merge", "", `{"duplicate_id":2}`, http.StatusOK, `"rating":{"editorial":null,"users":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer secret")
+			if tt.version != "" {
+				req.Header.Set(apiVersionHeader, tt.version)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Code = %d, attendu %d (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.want) {
+				t.Errorf("Corps = %s, attendu %s", rr.Body.String(), tt.want)
+			}
+		})
+	}
+
+	// Chaque version décrit ses propres réponses
+	for _, err := range mismatches {
+		t.Errorf("réponse non conforme : %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/openapi.json", nil))
+	var doc openAPIDocument
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if ref := doc.Paths["/movies/{id}"]["get"].Responses["200"].Content["application/json"].Schema.Ref; ref != schemaRefPrefix+"movieV2" {
+		t.Errorf("v2 GET /movies/{id} 200 = %q", ref)
+	}
+	if doc.Servers[0]["url"] != "/v2" {
+		t.Errorf("servers = %v", doc.Servers)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	app := &application{}
+	routes := app.v1RouteTable()
+
+	// Servi sans authentification
+	rr := httptest.NewRecorder()
+	app.routes().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Code = %d (%s)", rr.Code, rr.Body.String())
+	}
+
+	var doc openAPIDocument
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+
+	operations := 0
+	for _, methods := range doc.Paths {
+		operations += len(methods)
+	}
+	if operations != len(routes) {
+		t.Errorf("%d opérations, attendu une par route (%d)", operations, len(routes))
+	}
+
+	// Le corps et la réponse sont ceux du handler : store.Movie, pas un type de documentation
+	create := doc.Paths["/movies"]["post"]
+	if ref := create.RequestBody.Content["application/json"].Schema.Ref; ref != schemaRefPrefix+"Movie" {
+		t.Errorf("POST /movies body = %q", ref)
+	}
+	if ref := create.Responses["201"].Content["application/json"].Schema.Ref; ref != schemaRefPrefix+"Movie" {
+		t.Errorf("POST /movies 201 = %q", ref)
+	}
+	if len(create.Security) == 0 {
+		t.Error("POST /movies devrait exiger un jeton")
+	}
+	if list := doc.Paths["/movies"]["get"]; len(list.Security) != 0 {
+		t.Error("GET /movies est public")
+	}
+
+	// Les champs pointeurs de store.Movie peuvent valoir null
+	rating := doc.Components.Schemas["Movie"].Properties["rating"]
+	if !slices.Equal(rating.types(), []string{"number", "null"}) {
+		t.Errorf("Movie.rating = %v", rating.Type)
+	}
+
+	if _, ok := doc.Paths["/images/{path}"]["get"]; !ok {
+		t.Error("GET /images/{path} manquant")
+	}
+	if _, ok := doc.Paths["/movies/{id}"]["delete"].Responses["204"]; !ok {
+		t.Error("DELETE /movies/{id} devrait documenter 204")
+	}
+}
+
+func TestOpenAPIValidation_Requests(t *testing.T) {
+	t.Setenv("API_KEY", "secret")
+	app := &application{
+		store:            store.Storage{Movies: MockMovieStore{}},
+		events:           newEventBroker(),
+		validateRequests: true,
+	}
+	handler := app.routes()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{"corps valide", http.MethodPost, "/movies", "application/json", `{"title":"Inception","release_year":2010,"rating":8.8}`, http.StatusCreated, ""},
+		{"année en chaîne", http.MethodPost, "/movies", "application/json", `{"title":"Inception","release_year":"2010"}`, http.StatusBadRequest, "body.release_year must be integer"},
+		{"champ inconnu", http.MethodPost, "/v1/movies", "application/json", `{"title":"Inception","release_year":2010,"year":2010}`, http.StatusBadRequest, "body.year is not a known field"},
+		{"élément de liste", http.MethodPost, "/movies", "application/json", `{"title":"Inception","release_year":2010,"genres":[1]}`, http.StatusBadRequest, "body.genres[0] must be string"},
+		{"JSON illisible", http.MethodPost, "/movies", "application/json", `{"title":`, http.StatusBadRequest, "Invalid JSON"},
+		{"type non géré", http.MethodPost, "/movies", "text/csv", "title\nInception", http.StatusUnsupportedMediaType, "Content-Type must be one of"},
+		{"XML laissé à decodeBody", http.MethodPost, "/movies", "application/xml", `<movie><title>Inception</title><release_year>2010</release_year></movie>`, http.StatusCreated, ""},
+		{"id non entier", http.MethodGet, "/movies/abc", "", "", http.StatusBadRequest, "Invalid path parameter id: must be integer"},
+		{"paramètre de requête", http.MethodGet, "/movies?page=deux", "", "", http.StatusBadRequest, "Invalid query parameter page: must be integer"},
+		{"valeur hors enum", http.MethodGet, "/movies/export?format=xlsx", "", "", http.StatusBadRequest, "must be one of csv, ndjson, json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer secret")
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Code = %d, attendu %d (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("Body = %q, attendu %q", rr.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestOpenAPIValidation_Responses(t *testing.T) {
+	t.Setenv("API_KEY", "secret")
+
+	var mismatches []error
+	app := &application{
+		store: store.Storage{
+			Movies:       MockMovieStore{},
+			Genres:       MockGenreStore{},
+			Translations: MockTranslationStore{},
+			Stats:        MockStatsStore{},
+		},
+		events:           newEventBroker(),
+		responseMismatch: func(r *http.Request, err error) { mismatches = append(mismatches, err) },
+	}
+	handler := app.routes()
+
+	requests := []struct {
+		method string
+		path   string
+		accept string
+		body   string
+	}{
+		{http.MethodGet, "/movies", "", ""},
+		{http.MethodGet, "/movies?fields=title&include=genres,credits", "", ""},
+		{http.MethodGet, "/movies/1", "", ""},
+		{http.MethodGet, "/movies/1?fields=title", "", ""},
+		{http.MethodGet, "/movies/1/translations", "", ""},
+		{http.MethodGet, "/genres", "", ""},
+		{http.MethodGet, "/stats", "", ""},
+		{http.MethodPost, "/movies", "", `{"title":"Inception","release_year":2010}`},
+		{http.MethodPut, "/movies/1", "", `{"title":"Inception","release_year":2010}`},
+		{http.MethodDelete, "/movies/1", "", ""},
+		// Seul le JSON est comparé au schéma
+		{http.MethodGet, "/movies", "text/csv", ""},
+	}
+
+	for _, rq := range requests {
+		req := httptest.NewRequest(rq.method, rq.path, strings.NewReader(rq.body))
+		req.Header.Set("Authorization", "Bearer secret")
+		if rq.accept != "" {
+			req.Header.Set("Accept", rq.accept)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code >= http.StatusBadRequest {
+			t.Errorf("%s %s : Code = %d (%s)", rq.method, rq.path, rr.Code, rr.Body.String())
+		}
+	}
+
+	for _, err := range mismatches {
+		t.Errorf("réponse non conforme : %v", err)
+	}
+
+	// Un champ que le document ne connaît pas est signalé
+	doc := newOpenAPIDocument("v1", app.v1RouteTable())
+	err := doc.checkResponse(doc.operation("GET /movies/{id}"), http.StatusOK, "application/json", []byte(`{"id":1,"director":"Nolan"}`))
+	if err == nil || !strings.Contains(err.Error(), "response.director is not a known field") {
+		t.Errorf("checkResponse() = %v", err)
+	}
+	if err := doc.checkResponse(doc.operation("GET /movies/{id}"), http.StatusCreated, "application/json", nil); err == nil {
+		t.Error("un statut non documenté devrait être signalé")
+	}
+}
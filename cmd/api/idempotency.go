@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+const (
+	idempotencyHeader       = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+	// Durée de conservation des réponses, modifiable par IDEMPOTENCY_TTL
+	defaultIdempotencyTTL = 24 * time.Hour
+	// Attente d'une requête identique encore en cours avant de répondre 409
+	idempotencyWait         = 5 * time.Second
+	idempotencyPollInterval = 100 * time.Millisecond
+)
+
+// Rend les POST rejouables sans risque : avec un en-tête Idempotency-Key,
+// la première réponse est enregistrée (par utilisateur) et renvoyée telle quelle
+// aux requêtes suivantes portant la même clé et le même contenu (corps, format
+// et version demandés).
+// Une erreur serveur libère la clé pour que le client puisse réessayer.
+func (app *application) idempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		user := app.contextGetUser(r)
+
+		// Les requêtes anonymes n'ont pas d'auteur auquel rattacher la clé
+		if r.Method != http.MethodPost || key == "" || user.IsAnonymous() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		// Le corps est lu une fois pour l'empreinte puis rendu au handler
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBulkBodyBytes))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			} else {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+			}
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		principal := idempotencyPrincipal(user)
+		hash := requestHash(r, body)
+
+		stored, reserved, err := app.store.Idempotency.ReserveKey(principal, key, hash, app.idempotencyTTL)
+		if err != nil {
+			log.Println("Error reserving idempotency key:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if !reserved {
+			if stored.RequestHash != hash {
+				http.Error(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+				return
+			}
+
+			if stored.Status == 0 {
+				stored, err = app.waitForIdempotentResponse(r.Context(), principal, key)
+				if err != nil && !errors.Is(err, sql.ErrNoRows) {
+					if r.Context().Err() == nil {
+						log.Println("Error fetching idempotency key:", err)
+						http.Error(w, "Internal server error", http.StatusInternalServerError)
+					}
+					return
+				}
+				// Toujours en cours, ou abandonnée sur une erreur : le client réessaiera
+				if stored.Status == 0 {
+					w.Header().Set("Retry-After", "1")
+					http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+					return
+				}
+			}
+
+			replayResponse(w, stored)
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			// Erreur serveur ou panique : la clé est libérée
+			if !completed {
+				if err := app.store.Idempotency.ReleaseKey(principal, key); err != nil {
+					log.Println("Error releasing idempotency key:", err)
+				}
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+		completed = true
+
+		resp := store.IdempotentResponse{Status: rec.status, Header: w.Header().Clone(), Body: rec.body.Bytes()}
+		if err := app.store.Idempotency.CompleteKey(principal, key, resp); err != nil {
+			log.Println("Error saving idempotent response:", err)
+		}
+	})
+}
+
+// Attend la fin de la requête qui a réservé la clé.
+// Renvoie un statut 0 si elle n'est pas terminée à temps.
+func (app *application) waitForIdempotentResponse(ctx context.Context, principal, key string) (store.IdempotentResponse, error) {
+	timeout := time.NewTimer(idempotencyWait)
+	defer timeout.Stop()
+	ticker := time.NewTicker(idempotencyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return store.IdempotentResponse{}, ctx.Err()
+		case <-timeout.C:
+			return store.IdempotentResponse{}, nil
+		case <-ticker.C:
+			resp, err := app.store.Idempotency.GetKey(principal, key)
+			if err != nil || resp.Status != 0 {
+				return resp, err
+			}
+		}
+	}
+}
+
+func replayResponse(w http.ResponseWriter, resp store.IdempotentResponse) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// Les clés sont propres à chaque utilisateur (ou à la clé API)
+func idempotencyPrincipal(user store.User) string {
+	if user.ID != 0 {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	return "service:" + user.Name
+}
+
+// En-têtes qui changent la lecture du corps ou la forme de la réponse :
+// le rejeu d'une réponse XML ne peut pas servir à un client qui attend du JSON
+var requestHashHeaders = []string{"Content-Type", "Accept", "Accept-Language", apiVersionHeader}
+
+// Empreinte de la requête : une clé réutilisée pour une autre requête est refusée
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	for _, name := range requestHashHeaders {
+		io.WriteString(h, name+": "+strings.Join(r.Header.Values(name), ", ")+"\n")
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Transmet la réponse au client tout en gardant une copie pour les rejeux
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// Permet à http.ResponseController d'atteindre la réponse d'origine
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Purge toutes les heures les clés expirées
+func (app *application) pruneIdempotencyKeys(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if _, err := app.store.Idempotency.DeleteExpiredKeys(); err != nil {
+			log.Println("Error pruning idempotency keys:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
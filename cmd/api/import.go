@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+const maxImportBytes = 10 << 20
+
+// En-têtes reconnus par défaut (en minuscules), anglais et français
+var defaultImportColumns = map[string]string{
+	"title":        "title",
+	"titre":        "title",
+	"release_year": "release_year",
+	"year":         "release_year",
+	"année":        "release_year",
+	"annee":        "release_year",
+	"rating":       "rating",
+	"note":         "rating",
+	"review":       "review",
+	"critique":     "review",
+	"genres":       "genres",
+	"genre":        "genres",
+}
+
+var importFields = []string{"title", "release_year", "rating", "review", "genres"}
+
+// Une ligne du fichier, avec son numéro de ligne pour le rapport
+type importRow struct {
+	Line  int
+	Movie store.Movie
+	Err   error
+}
+
+type importLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type importLineCreated struct {
+	Line int `json:"line"`
+	ID   int `json:"id"`
+}
+
+// ImportMovies godoc
+// @Summary      Importer des films depuis un CSV
+// @Description  Colonnes : title, release_year, rating, review, genres (séparés par "|").
+// @Description  Les en-têtes français (titre, année, note, critique) sont reconnus ; mapping permet
+// @Description  d'associer d'autres en-têtes, ex: {"Nom du film": "title"}.
+// @Description  Avec dry_run=true, rien n'est enregistré : seul le rapport ligne par ligne est renvoyé.
+// @Tags         movies
+// @Accept       text/csv
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file       formData  file    false  "Fichier CSV (multipart)"
+// @Param        dry_run    query     bool    false  "Valider sans enregistrer"
+// @Param        mapping    query     string  false  "Correspondance JSON en-tête -> champ"
+// @Param        delimiter  query     string  false  "Séparateur (défaut , ; encoder ; en %3B)"
+// @Success      200  {object}  map[string]any
+// @Failure      400  {string}  string "Fichier invalide"
+// @Router       /movies/import [post]
+// @Security     BearerAuth
+func (app *application) importMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if d := r.URL.Query().Get("dry_run"); d != "" {
+		b, err := strconv.ParseBool(d)
+		if err != nil {
+			http.Error(w, "dry_run must be true or false", http.StatusBadRequest)
+			return
+		}
+		dryRun = b
+	}
+
+	columns, err := importColumnMapping(r.URL.Query().Get("mapping"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	delimiter := ','
+	if d := r.URL.Query().Get("delimiter"); d != "" {
+		if utf8.RuneCountInString(d) != 1 {
+			http.Error(w, "delimiter must be a single character", http.StatusBadRequest)
+			return
+		}
+		delimiter, _ = utf8.DecodeRuneInString(d)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	body, err := importBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+
+	rows, err := parseImportCSV(body, columns, delimiter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	genres, err := app.store.Genres.GetGenres()
+	if err != nil {
+		log.Println("Error fetching genres:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	resolveImportGenres(rows, genres)
+
+	lineErrors := []importLineError{}
+	var ops []store.BulkOperation
+	var opRows []int
+
+	for i := range rows {
+		if rows[i].Err == nil {
+			rows[i].Err = rows[i].Movie.Validate()
+		}
+		if rows[i].Err != nil {
+			lineErrors = append(lineErrors, importLineError{Line: rows[i].Line, Error: rows[i].Err.Error()})
+			continue
+		}
+		ops = append(ops, store.BulkOperation{Op: store.BulkCreate, Movie: rows[i].Movie})
+		opRows = append(opRows, i)
+	}
+
+	response := map[string]any{
+		"dry_run":      dryRun,
+		"total_rows":   len(rows),
+		"valid_rows":   len(ops),
+		"invalid_rows": len(rows) - len(ops),
+	}
+
+	if dryRun || len(ops) == 0 {
+		response["errors"] = lineErrors
+		response["created"] = []importLineCreated{}
+		respond(w, r, http.StatusOK, response)
+		return
+	}
+
+	// Les lignes valides sont insérées par paquets via le mode best-effort du bulk
+	results, err := app.store.Movies.BulkApply(ops, false)
+	if err != nil {
+		log.Println("Error importing movies:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	created := []importLineCreated{}
+	for n, res := range results {
+		line := rows[opRows[n]].Line
+		if res.Err != nil {
+			_, msg := bulkErrorStatus(res.Err)
+			lineErrors = append(lineErrors, importLineError{Line: line, Error: msg})
+			continue
+		}
+		created = append(created, importLineCreated{Line: line, ID: res.ID})
+	}
+
+	response["errors"] = lineErrors
+	response["created"] = created
+	respond(w, r, http.StatusOK, response)
+}
+
+// Renvoie le fichier envoyé, en multipart (champ "file") ou directement dans le corps
+func importBody(r *http.Request) (io.ReadCloser, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, errors.New("missing CSV file in form field 'file'")
+	}
+	return file, nil
+}
+
+// Ajoute la correspondance personnalisée (JSON) aux en-têtes par défaut
+func importColumnMapping(raw string) (map[string]string, error) {
+	columns := make(map[string]string, len(defaultImportColumns))
+	for header, field := range defaultImportColumns {
+		columns[header] = field
+	}
+
+	if raw == "" {
+		return columns, nil
+	}
+
+	var custom map[string]string
+	if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+		return nil, errors.New("mapping must be a JSON object, e.g. {\"Titre\": \"title\"}")
+	}
+
+	for header, field := range custom {
+		known := false
+		for _, f := range importFields {
+			if f == field {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("mapping: unknown field '%s'", field)
+		}
+		columns[normalizeHeader(header)] = field
+	}
+
+	return columns, nil
+}
+
+func normalizeHeader(header string) string {
+	header = strings.TrimPrefix(header, "\ufeff") // BOM ajouté par Excel
+	return strings.ToLower(strings.TrimSpace(header))
+}
+
+// Lit le CSV ligne par ligne. Une ligne mal formée est reportée
+// dans son importRow sans interrompre la lecture des suivantes.
+func parseImportCSV(body io.Reader, columns map[string]string, delimiter rune) ([]importRow, error) {
+	reader := csv.NewReader(body)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty CSV file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+
+	// Position de chaque champ dans une ligne
+	positions := make(map[string]int)
+	for i, h := range header {
+		if field, ok := columns[normalizeHeader(h)]; ok {
+			positions[field] = i
+		}
+	}
+
+	for _, required := range []string{"title", "release_year"} {
+		if _, ok := positions[required]; !ok {
+			return nil, fmt.Errorf("missing column for '%s'", required)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, importRow{Line: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, parseImportRecord(line, record, positions))
+	}
+
+	return rows, nil
+}
+
+func parseImportRecord(line int, record []string, positions map[string]int) importRow {
+	row := importRow{Line: line}
+
+	value := func(field string) string {
+		i, ok := positions[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row.Movie.Title = value("title")
+
+	year, err := strconv.Atoi(value("release_year"))
+	if err != nil {
+		row.Err = errors.New("release_year must be an integer")
+		return row
+	}
+	row.Movie.ReleaseYear = year
+
+	if v := value("rating"); v != "" {
+		// Accepte la virgule décimale des tableurs français
+		rating, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+		if err != nil {
+			row.Err = errors.New("rating must be a number")
+			return row
+		}
+		row.Movie.Rating = &rating
+	}
+
+	if v := value("review"); v != "" {
+		row.Movie.Review = &v
+	}
+
+	if v := value("genres"); v != "" {
+		for _, g := range strings.Split(v, "|") {
+			if g = strings.TrimSpace(g); g != "" {
+				row.Movie.Genres = append(row.Movie.Genres, g)
+			}
+		}
+	}
+
+	return row
+}
+
+// Remplace chaque genre par son nom exact en base (sans tenir compte
+// de la casse) et signale les genres inconnus
+func resolveImportGenres(rows []importRow, genres []store.Genre) {
+	known := make(map[string]string, len(genres))
+	for _, g := range genres {
+		known[strings.ToLower(g.Name)] = g.Name
+	}
+
+	for i := range rows {
+		if rows[i].Err != nil {
+			continue
+		}
+		for j, name := range rows[i].Movie.Genres {
+			canonical, ok := known[strings.ToLower(name)]
+			if !ok {
+				rows[i].Err = fmt.Errorf("%w: '%s'", store.ErrGenreNotFound, name)
+				break
+			}
+			rows[i].Movie.Genres[j] = canonical
+		}
+	}
+}
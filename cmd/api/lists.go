@@ -0,0 +1,458 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+type CreateListRequest struct {
+	Name        string `json:"name" example:"Best Sci-Fi of the 90s"`
+	Description string `json:"description" example:"Les incontournables de la décennie"`
+	Kind        string `json:"kind" example:"list" enums:"list,collection"`
+	IsPublic    *bool  `json:"is_public" example:"true"`
+}
+
+type AddListItemRequest struct {
+	MovieID  int     `json:"movie_id" example:"1"`
+	Position *int    `json:"position" example:"1"`
+	Note     *string `json:"note" example:"Le premier et le meilleur"`
+}
+
+// GetAllLists godoc
+// @Summary      Lister les listes de films
+// @Description  Renvoie les listes publiques et celles de l'utilisateur connecté, les plus récemment modifiées en premier
+// @Tags         lists
+// @Produce      json
+// @Param        name       query  string  false  "Recherche par nom"
+// @Param        mine       query  bool    false  "Seulement mes listes"
+// @Param        page       query  int     false  "Page"
+// @Param        page_size  query  int     false  "Taille de page"
+// @Success      200  {object}  map[string]any "metadata et lists ([]store.MovieList)"
+// @Router       /lists [get]
+func (app *application) getAllListsHandler(w http.ResponseWriter, r *http.Request) {
+	queryValues := r.URL.Query()
+
+	filters := parsePageFilters(queryValues)
+	mine, _ := strconv.ParseBool(queryValues.Get("mine"))
+
+	user := app.contextGetUser(r)
+	if mine && user.ID == 0 {
+		http.Error(w, "mine requires a user token", http.StatusUnauthorized)
+		return
+	}
+
+	lists, metadata, err := app.store.Lists.GetLists(queryValues.Get("name"), user.ID, mine, filters)
+	if err != nil {
+		log.Println("Error fetching lists:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"metadata": metadata,
+		"lists":    lists,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// GetList godoc
+// @Summary      Récupérer une liste avec ses films
+// @Tags         lists
+// @Produce      json
+// @Param        id   path      int  true  "ID de la liste"
+// @Success      200  {object}  store.MovieList
+// @Failure      404  {string}  string "Liste non trouvée"
+// @Router       /lists/{id} [get]
+func (app *application) getListByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	list, ok := app.fetchList(w, r, id, false)
+	if !ok {
+		return
+	}
+
+	respond(w, r, http.StatusOK, list)
+}
+
+// CreateList godoc
+// @Summary      Créer une liste
+// @Description  kind vaut "list" (sélection) ou "collection" (saga). Les listes sont publiques par défaut.
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        input  body      CreateListRequest  true  "Infos de la liste"
+// @Success      201    {object}  store.MovieList
+// @Failure      400    {string}  string "Erreur de validation"
+// @Router       /lists [post]
+// @Security     BearerAuth
+func (app *application) createListHandler(w http.ResponseWriter, r *http.Request) {
+	var input CreateListRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	list := input.toList()
+
+	// Les listes créées avec la clé API n'ont pas de propriétaire
+	if user := app.contextGetUser(r); user.ID != 0 {
+		list.OwnerID = &user.ID
+	}
+
+	if err := list.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	newList, err := app.store.Lists.AddList(list)
+	if err != nil {
+		log.Println("Error adding list:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, newList)
+}
+
+// UpdateList godoc
+// @Summary      Modifier une liste
+// @Description  Réservé au propriétaire de la liste et aux éditeurs
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                true  "ID de la liste"
+// @Param        input  body      CreateListRequest  true  "Nouvelles infos"
+// @Success      200    {object}  store.MovieList
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      403    {string}  string "Liste d'un autre utilisateur"
+// @Failure      404    {string}  string "Liste non trouvée"
+// @Router       /lists/{id} [put]
+// @Security     BearerAuth
+func (app *application) updateListHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input CreateListRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	existing, ok := app.fetchList(w, r, id, true)
+	if !ok {
+		return
+	}
+
+	list := input.toList()
+	list.ID = id
+	if input.IsPublic == nil {
+		list.IsPublic = existing.IsPublic
+	}
+
+	if err := list.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.Lists.UpdateList(list); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+		} else {
+			log.Println("Error updating list:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	app.respondWithList(w, r, id, http.StatusOK)
+}
+
+// DeleteList godoc
+// @Summary      Supprimer une liste
+// @Description  Réservé au propriétaire de la liste et aux éditeurs. Les films ne sont pas supprimés.
+// @Tags         lists
+// @Param        id   path      int  true  "ID de la liste"
+// @Success      204  {string}  string "Liste supprimée"
+// @Failure      403  {string}  string "Liste d'un autre utilisateur"
+// @Failure      404  {string}  string "Liste non trouvée"
+// @Router       /lists/{id} [delete]
+// @Security     BearerAuth
+func (app *application) deleteListHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := app.fetchList(w, r, id, true); !ok {
+		return
+	}
+
+	if err := app.store.Lists.DeleteList(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+		} else {
+			log.Println("Error deleting list:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddListItem godoc
+// @Summary      Ajouter un film à une liste
+// @Description  Sans position, le film est ajouté à la fin de la liste
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                 true  "ID de la liste"
+// @Param        input  body      AddListItemRequest  true  "Film, position et note facultatives"
+// @Success      201    {object}  store.MovieList
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      404    {string}  string "Liste ou film non trouvé"
+// @Failure      409    {string}  string "Film déjà dans la liste"
+// @Router       /lists/{id}/items [post]
+// @Security     BearerAuth
+func (app *application) addListItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input AddListItemRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	if input.MovieID <= 0 {
+		http.Error(w, "movie_id is required", http.StatusBadRequest)
+		return
+	}
+	if input.Position != nil && *input.Position < 1 {
+		http.Error(w, "position must be at least 1", http.StatusBadRequest)
+		return
+	}
+	if input.Note != nil && len(*input.Note) > 500 {
+		http.Error(w, "note must not exceed 500 characters", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := app.fetchList(w, r, id, true); !ok {
+		return
+	}
+
+	if err := app.store.Lists.AddListItem(id, input.MovieID, input.Position, input.Note); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrAlreadyInList):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Println("Error adding list item:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	app.respondWithList(w, r, id, http.StatusCreated)
+}
+
+// MoveListItem godoc
+// @Summary      Déplacer un film dans une liste
+// @Tags         lists
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                   true  "ID de la liste"
+// @Param        movie_id  path      int                   true  "ID du film"
+// @Param        input     body      MoveItemRequest  true  "Nouvelle position (1 = en tête)"
+// @Success      200       {object}  store.MovieList
+// @Failure      400       {string}  string "Position invalide"
+// @Failure      404       {string}  string "Film absent de la liste"
+// @Router       /lists/{id}/items/{movie_id} [put]
+// @Security     BearerAuth
+func (app *application) moveListItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	movieID, err := strconv.Atoi(r.PathValue("movie_id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input MoveItemRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	if input.Position < 1 {
+		http.Error(w, "position must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := app.fetchList(w, r, id, true); !ok {
+		return
+	}
+
+	if err := app.store.Lists.MoveListItem(id, movieID, input.Position); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not in list", http.StatusNotFound)
+		} else {
+			log.Println("Error moving list item:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	app.respondWithList(w, r, id, http.StatusOK)
+}
+
+// RemoveListItem godoc
+// @Summary      Retirer un film d'une liste
+// @Tags         lists
+// @Param        id        path      int  true  "ID de la liste"
+// @Param        movie_id  path      int  true  "ID du film"
+// @Success      204       {string}  string "Film retiré"
+// @Failure      404       {string}  string "Film absent de la liste"
+// @Router       /lists/{id}/items/{movie_id} [delete]
+// @Security     BearerAuth
+func (app *application) removeListItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	movieID, err := strconv.Atoi(r.PathValue("movie_id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := app.fetchList(w, r, id, true); !ok {
+		return
+	}
+
+	if err := app.store.Lists.RemoveListItem(id, movieID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not in list", http.StatusNotFound)
+		} else {
+			log.Println("Error removing list item:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMovieLists godoc
+// @Summary      Listes contenant un film
+// @Description  Renvoie les listes publiques (et celles de l'utilisateur connecté) qui contiennent le film
+// @Tags         lists
+// @Produce      json
+// @Param        id   path      int  true  "ID du film"
+// @Success      200  {object}  map[string]any "lists ([]store.MovieList)"
+// @Router       /movies/{id}/lists [get]
+func (app *application) getMovieListsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	lists, err := app.store.Lists.GetMovieLists(id, user.ID)
+	if err != nil {
+		log.Println("Error fetching movie lists:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, map[string]any{"lists": lists})
+}
+
+// --- HELPERS ---
+
+func (input CreateListRequest) toList() store.MovieList {
+	list := store.MovieList{
+		Name:     input.Name,
+		Kind:     input.Kind,
+		IsPublic: input.IsPublic == nil || *input.IsPublic,
+	}
+	if input.Description != "" {
+		list.Description = &input.Description
+	}
+	return list
+}
+
+// Vrai si l'utilisateur peut modifier la liste : son propriétaire ou un éditeur
+func canEditList(user store.User, list store.MovieList) bool {
+	if user.HasRole(store.RoleEditor) {
+		return true
+	}
+	return user.ID != 0 && list.OwnerID != nil && *list.OwnerID == user.ID
+}
+
+// Charge une liste et vérifie que l'utilisateur peut la voir (ou la modifier
+// avec forEdit). Une liste privée est introuvable pour les autres utilisateurs.
+func (app *application) fetchList(w http.ResponseWriter, r *http.Request, id int, forEdit bool) (store.MovieList, bool) {
+	list, err := app.store.Lists.GetListByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "List not found", http.StatusNotFound)
+		} else {
+			log.Println("Error fetching list:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return store.MovieList{}, false
+	}
+
+	user := app.contextGetUser(r)
+	canEdit := canEditList(user, list)
+
+	if !list.IsPublic && !canEdit {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return store.MovieList{}, false
+	}
+
+	if forEdit && !canEdit {
+		http.Error(w, "You can only modify your own lists", http.StatusForbidden)
+		return store.MovieList{}, false
+	}
+
+	return list, true
+}
+
+func (app *application) respondWithList(w http.ResponseWriter, r *http.Request, id, status int) {
+	list, err := app.store.Lists.GetListByID(id)
+	if err != nil {
+		log.Println("Error fetching list:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, status, list)
+}
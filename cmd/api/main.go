@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -10,7 +15,16 @@ import (
 )
 
 type application struct {
-	store store.Storage
+	store          store.Storage
+	blobs          store.BlobStore
+	events         *eventBroker
+	idempotencyTTL time.Duration
+	cacheTTL       time.Duration
+	v1Deprecation  deprecation
+	// Requêtes vérifiées contre /openapi.json avant d'atteindre les handlers
+	validateRequests bool
+	// Appelée pour chaque réponse qui ne suit pas /openapi.json (tests)
+	responseMismatch func(r *http.Request, err error)
 }
 
 // @title           Movie API
@@ -39,10 +53,89 @@ func main() {
 
 	defer db.Close()
 
+	// Dossier des affiches envoyées
+	uploadDir := os.Getenv("UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "uploads"
+	}
+
+	blobs, err := store.NewLocalBlobStore(uploadDir)
+	if err != nil {
+		log.Fatal("Failed to initialize upload directory: ", err)
+	}
+
+	// Durée pendant laquelle une requête POST avec Idempotency-Key peut être rejouée
+	idempotencyTTL := defaultIdempotencyTTL
+	if ttl := os.Getenv("IDEMPOTENCY_TTL"); ttl != "" {
+		idempotencyTTL, err = time.ParseDuration(ttl)
+		if err != nil || idempotencyTTL <= 0 {
+			log.Fatal("IDEMPOTENCY_TTL must be a positive duration (ex: 24h)")
+		}
+	}
+
+	// Cache des lectures de films (GET /movies, GET /movies/{id})
+	cacheTTL := defaultMovieCacheTTL
+	if ttl := os.Getenv("MOVIE_CACHE_TTL"); ttl != "" {
+		cacheTTL, err = time.ParseDuration(ttl)
+		if err != nil || cacheTTL < 0 {
+			log.Fatal("MOVIE_CACHE_TTL must be a duration (ex: 30s, 0 to disable)")
+		}
+	}
+
+	cacheSize := defaultMovieCacheSize
+	if size := os.Getenv("MOVIE_CACHE_SIZE"); size != "" {
+		cacheSize, err = strconv.Atoi(size)
+		if err != nil || cacheSize <= 0 {
+			log.Fatal("MOVIE_CACHE_SIZE must be a positive integer")
+		}
+	}
+
+	// Dépréciation de la v1, annoncée dans les en-têtes de ses réponses
+	var v1Deprecation deprecation
+	if v1Deprecation.since, err = parseVersionDate(os.Getenv("API_V1_DEPRECATION")); err != nil {
+		log.Fatal("API_V1_DEPRECATION must be a date (ex: 2026-12-31)")
+	}
+	if v1Deprecation.sunset, err = parseVersionDate(os.Getenv("API_V1_SUNSET")); err != nil {
+		log.Fatal("API_V1_SUNSET must be a date (ex: 2027-06-30)")
+	}
+	if v1Deprecation.since.IsZero() && !v1Deprecation.sunset.IsZero() {
+		log.Fatal("API_V1_SUNSET requires API_V1_DEPRECATION")
+	}
+
+	// Vérification des requêtes contre le document OpenAPI
+	validateRequests := false
+	if v := os.Getenv("OPENAPI_VALIDATE"); v != "" {
+		validateRequests, err = strconv.ParseBool(v)
+		if err != nil {
+			log.Fatal("OPENAPI_VALIDATE must be true or false")
+		}
+	}
+
 	app := &application{
-		store: store.NewStorage(db),
+		store:            store.NewStorage(db),
+		blobs:            blobs,
+		events:           newEventBroker(),
+		idempotencyTTL:   idempotencyTTL,
+		cacheTTL:         cacheTTL,
+		v1Deprecation:    v1Deprecation,
+		validateRequests: validateRequests,
 	}
 
+	if cacheTTL > 0 {
+		movies := store.NewCachedMovieRepository(app.store.Movies, store.NewLRUCache(cacheSize), cacheTTL)
+		app.store.Movies = movies
+		go app.invalidateOnEvents(context.Background(), movies)
+		// Compteurs hits / misses, lus sur GET /debug/vars
+		expvar.Publish("movie_cache", expvar.Func(func() any { return movies.Stats() }))
+	}
+
+	// Flux GET /movies/events : écoute des changements faits par toutes les instances
+	go app.listenForEvents(context.Background())
+
+	// Envoi aux webhooks des partenaires des événements mis en file
+	go newWebhookDispatcher(app.store.Webhooks).run(context.Background(), app.events)
+	go app.pruneIdempotencyKeys(context.Background())
+
 	srv := &http.Server{
 		Addr:         ":8080",
 		Handler:      app.routes(),
@@ -51,6 +144,24 @@ func main() {
 		IdleTimeout:  1 * time.Minute,
 	}
 
+	// API gRPC pour les services internes, sur son propre port
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatal("Failed to listen for gRPC: ", err)
+	}
+
+	go func() {
+		log.Println("🎬 gRPC server started on port " + grpcPort)
+		if err := app.grpcServer().Serve(lis); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
 	log.Println("🎬 Server started on http://localhost:8080")
 	if err := srv.ListenAndServe(); err != nil {
 		log.Fatal(err)
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"strings"
 	"time"
+
+	"github.com/vfaust1/movie-api/internal/store"
 )
 
 // Affiche chaque requête reçue et sa durée
@@ -17,6 +22,9 @@ func (app *application) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// Identifie l'auteur de la requête à partir du header Authorization :
+// la clé API_KEY donne le compte de service, sinon le token est cherché
+// parmi les utilisateurs. Les GET du catalogue restent publics.
 func (app *application) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -25,14 +33,14 @@ func (app *application) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/movies") {
-			next.ServeHTTP(w, r)
-			return
-		}
-
 		authHeader := r.Header.Get("Authorization")
 
 		if authHeader == "" {
+			if isPublicRead(r) {
+				next.ServeHTTP(w, app.contextSetUser(r, store.AnonymousUser))
+				return
+			}
+
 			http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
 			return
 		}
@@ -43,16 +51,97 @@ func (app *application) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		token := parts[1]
+		user, err := app.authenticate(parts[1])
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+			} else {
+				log.Println("Error authenticating user:", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, app.contextSetUser(r, user))
+	})
+}
+
+// Renvoie l'utilisateur du token : le compte de service pour la clé
+// API_KEY, sinon l'utilisateur enregistré (sql.ErrNoRows si inconnu)
+func (app *application) authenticate(token string) (store.User, error) {
+	expectedKey := os.Getenv("API_KEY")
+
+	if expectedKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expectedKey)) == 1 {
+		return store.APIKeyUser, nil
+	}
+
+	return app.store.Users.GetUserByTokenHash(store.HashToken(token))
+}
+
+// Préfixes des ressources lisibles sans authentification
+var publicReadPrefixes = []string{"/people", "/lists", "/stats", "/genres", "/images", "/openapi.json"}
+
+// Vrai pour les lectures du catalogue, ouvertes à tous
+func isPublicRead(r *http.Request) bool {
+	// Les mutations GraphQL vérifient elles-mêmes le rôle
+	if r.URL.Path == "/graphql" {
+		return true
+	}
+
+	if r.Method != http.MethodGet {
+		return false
+	}
 
-		expectedKey := os.Getenv("API_KEY")
+	path := unversionedPath(r.URL.Path)
 
-		if token != expectedKey {
-			http.Error(w, "Invalid API key", http.StatusUnauthorized)
+	if strings.Contains(path, "/movies") {
+		return true
+	}
+
+	for _, prefix := range publicReadPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Réserve une route aux utilisateurs ayant au moins le rôle demandé
+func (app *application) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := app.contextGetUser(r)
+
+		if user.IsAnonymous() {
+			http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
 			return
+		}
 
+		if !user.HasRole(role) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
 		}
 
 		next.ServeHTTP(w, r)
-	})
+	}
+}
+
+// Réserve une route aux utilisateurs enregistrés : le compte
+// de service API_KEY n'a pas de données personnelles
+func (app *application) requireUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := app.contextGetUser(r)
+
+		if user.IsAnonymous() {
+			http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+			return
+		}
+
+		if user.ID == 0 {
+			http.Error(w, "This route requires a user token, not the API key", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
 }
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Document OpenAPI 3.1 servi sur /openapi.json. Il est généré depuis la
+// table des routes et les types Go réellement décodés et renvoyés, il ne
+// peut donc pas dériver du code comme les annotations de docs/swagger.
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       map[string]string                       `json:"info"`
+	Servers    []map[string]string                     `json:"servers"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*jsonSchema       `json:"schemas"`
+	SecuritySchemes map[string]map[string]string `json:"securitySchemes"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *jsonSchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *jsonSchema `json:"schema"`
+}
+
+// Schéma JSON (draft 2020-12, celui d'OpenAPI 3.1), limité à ce que
+// produisent les types Go
+type jsonSchema struct {
+	Ref    string      `json:"$ref,omitempty"`
+	Type   any         `json:"type,omitempty"` // "string" ou ["string", "null"]
+	Format string      `json:"format,omitempty"`
+	Enum   []string    `json:"enum,omitempty"`
+	Items  *jsonSchema `json:"items,omitempty"`
+	// Champs d'un objet ; false refuse les autres
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	AnyOf                []*jsonSchema          `json:"anyOf,omitempty"`
+}
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// Paramètre de requête (?page=2) et le type Go dans lequel il est lu
+type queryParam struct {
+	name string
+	typ  reflect.Type
+	enum []string
+}
+
+func queryParams[T any](names ...string) []queryParam {
+	params := make([]queryParam, len(names))
+	for i, name := range names {
+		params[i] = queryParam{name: name, typ: reflect.TypeFor[T]()}
+	}
+	return params
+}
+
+// Formats structurés, lus par decodeBody et écrits par respond
+var structuredMediaTypes = []string{"application/json", "application/xml", "application/msgpack"}
+
+var pathParamPattern = regexp.MustCompile(`\{([a-z_]+)(\.\.\.)?\}`)
+
+// Décrit les routes d'une version de l'API dans un document OpenAPI
+func newOpenAPIDocument(version string, routes []route) *openAPIDocument {
+	number := strings.TrimPrefix(version, "v")
+	doc := &openAPIDocument{
+		OpenAPI: "3.1.0",
+		Info:    map[string]string{"title": "Movie API", "version": number + ".0", "description": "API de gestion de films en Go."},
+		Servers: []map[string]string{
+			{"url": "/" + version},
+			{"url": "/", "description": "Routes sans préfixe avec l'en-tête API-Version: " + number + " (v1 sans en-tête)"},
+		},
+		Paths: make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			Schemas:         make(map[string]*jsonSchema),
+			SecuritySchemes: map[string]map[string]string{"BearerAuth": {"type": "http", "scheme": "bearer"}},
+		},
+	}
+
+	g := &schemaGenerator{schemas: doc.Components.Schemas, names: make(map[reflect.Type]string)}
+
+	for _, rt := range routes {
+		method, routePath, _ := strings.Cut(rt.pattern, " ")
+		p := openAPIPath(routePath)
+
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[p][strings.ToLower(method)] = g.operation(rt, method, routePath)
+	}
+
+	return doc
+}
+
+// Opération décrivant la route de motif pattern ("GET /movies/{id}")
+func (d *openAPIDocument) operation(pattern string) *openAPIOperation {
+	method, routePath, _ := strings.Cut(pattern, " ")
+	return d.Paths[openAPIPath(routePath)][strings.ToLower(method)]
+}
+
+// OpenAPI ne connaît pas les jokers de ServeMux : {path...} devient {path}
+func openAPIPath(routePath string) string {
+	return pathParamPattern.ReplaceAllString(routePath, "{$1}")
+}
+
+type schemaGenerator struct {
+	schemas map[string]*jsonSchema
+	names   map[reflect.Type]string
+}
+
+func (g *schemaGenerator) operation(rt route, method, routePath string) *openAPIOperation {
+	op := &openAPIOperation{
+		OperationID: handlerName(rt.handler),
+		Summary:     rt.summary,
+		Tags:        []string{strings.Split(strings.TrimPrefix(routePath, "/"), "/")[0]},
+		Responses:   make(map[string]openAPIResponse),
+	}
+
+	// Les identifiants sont des entiers, les autres segments des chaînes
+	for _, m := range pathParamPattern.FindAllStringSubmatch(routePath, -1) {
+		schema := &jsonSchema{Type: "string"}
+		if m[1] == "id" || strings.HasSuffix(m[1], "_id") {
+			schema = &jsonSchema{Type: "integer"}
+		}
+		op.Parameters = append(op.Parameters, openAPIParameter{Name: m[1], In: "path", Required: true, Schema: schema})
+	}
+
+	for _, q := range rt.query {
+		schema := g.typeSchema(q.typ)
+		schema.Enum = q.enum
+		op.Parameters = append(op.Parameters, openAPIParameter{Name: q.name, In: "query", Schema: schema})
+	}
+
+	// Même règle que authMiddleware pour les routes ouvertes à tous
+	if !isPublicRead(&http.Request{Method: method, URL: &url.URL{Path: routePath}}) {
+		op.Security = []map[string][]string{{"BearerAuth": {}}}
+	}
+	switch {
+	case rt.personal:
+		op.Description = "Jeton utilisateur requis (la clé API est refusée)."
+	case rt.role != "":
+		op.Description = "Rôle requis : " + rt.role + "."
+	}
+
+	if rt.request != nil || len(rt.consumes) > 0 {
+		mediaTypes := rt.consumes
+		if len(mediaTypes) == 0 {
+			mediaTypes = structuredMediaTypes
+		}
+		op.RequestBody = &openAPIRequestBody{Required: true, Content: g.content(rt.request, mediaTypes)}
+	}
+
+	for _, status := range slices.Sorted(maps.Keys(rt.responses)) {
+		value := rt.responses[status]
+		response := openAPIResponse{Description: http.StatusText(status)}
+		if value != nil {
+			mediaTypes := rt.produces
+			if len(mediaTypes) == 0 {
+				mediaTypes = structuredMediaTypes
+				if hasList(value) {
+					mediaTypes = append(slices.Clone(mediaTypes), "text/csv")
+				}
+			}
+			response.Content = g.content(value, mediaTypes)
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+	op.Responses["default"] = openAPIResponse{
+		Description: "Erreur",
+		Content:     map[string]openAPIMediaType{"text/plain": {Schema: &jsonSchema{Type: "string"}}},
+	}
+
+	return op
+}
+
+// Contenu par type MIME : le schéma de la valeur pour les formats
+// structurés, une chaîne ou un fichier pour les autres
+func (g *schemaGenerator) content(value any, mediaTypes []string) map[string]openAPIMediaType {
+	var structured *jsonSchema
+	if value != nil {
+		structured = g.valueSchema(value)
+	}
+
+	content := make(map[string]openAPIMediaType, len(mediaTypes))
+	for _, mediaType := range mediaTypes {
+		var schema *jsonSchema
+		switch {
+		case structured != nil && slices.Contains(structuredMediaTypes, mediaType):
+			schema = structured
+		case mediaType == "multipart/form-data":
+			schema = &jsonSchema{Type: "object", AdditionalProperties: &jsonSchema{Type: "string", Format: "binary"}}
+		case strings.HasPrefix(mediaType, "image/"):
+			schema = &jsonSchema{Type: "string", Format: "binary"}
+		default:
+			schema = &jsonSchema{Type: "string"}
+		}
+		content[mediaType] = openAPIMediaType{Schema: schema}
+	}
+	return content
+}
+
+// Vrai pour une liste, ou un objet qui en contient une (réponse CSV possible)
+func hasList(value any) bool {
+	if obj, ok := value.(map[string]any); ok {
+		for _, v := range obj {
+			if hasList(v) {
+				return true
+			}
+		}
+		return false
+	}
+	return reflect.TypeOf(value).Kind() == reflect.Slice
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// Schéma d'une valeur d'exemple : les map[string]any des enveloppes
+// ({"metadata": ..., "movies": ...}) sont décrites clé par clé
+func (g *schemaGenerator) valueSchema(value any) *jsonSchema {
+	if obj, ok := value.(map[string]any); ok {
+		schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema, len(obj))}
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			schema.Properties[key] = g.valueSchema(obj[key])
+		}
+		return schema
+	}
+	return g.typeSchema(reflect.TypeOf(value))
+}
+
+// Schéma d'un type Go tel que encoding/json le lit et l'écrit : un pointeur,
+// une slice ou une map peuvent valoir null, une struct nommée devient un
+// schéma partagé de components
+func (g *schemaGenerator) typeSchema(t reflect.Type) *jsonSchema {
+	switch t {
+	case timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &jsonSchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.typeSchema(t.Elem()))
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &jsonSchema{Type: "string", Format: "byte"}
+		}
+		return nullable(&jsonSchema{Type: "array", Items: g.typeSchema(t.Elem())})
+	case reflect.Array:
+		return &jsonSchema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return nullable(&jsonSchema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())})
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.ref(t)
+	}
+
+	// interface{} : n'importe quelle valeur
+	return &jsonSchema{}
+}
+
+// Référence vers le schéma partagé d'une struct nommée, créé au premier usage
+func (g *schemaGenerator) ref(t reflect.Type) *jsonSchema {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		// Deux packages peuvent déclarer le même nom
+		if _, taken := g.schemas[name]; taken {
+			name = path.Base(t.PkgPath()) + "." + name
+		}
+		g.names[t] = name
+
+		// Enregistré avant ses champs, pour les types récursifs
+		schema := &jsonSchema{}
+		g.schemas[name] = schema
+		*schema = *g.structSchema(t)
+	}
+	return &jsonSchema{Ref: schemaRefPrefix + name}
+}
+
+// Les champs ne sont pas requis : les lectures peuvent n'en renvoyer qu'une
+// partie (?fields=) et les handlers valident eux-mêmes les champs obligatoires
+func (g *schemaGenerator) structSchema(t reflect.Type) *jsonSchema {
+	schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema), AdditionalProperties: false}
+	fields := jsonFields(t)
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		schema.Properties[name] = g.typeSchema(fields[name])
+	}
+	return schema
+}
+
+func nullable(schema *jsonSchema) *jsonSchema {
+	switch typ := schema.Type.(type) {
+	case string:
+		schema.Type = []string{typ, "null"}
+		return schema
+	case nil:
+		if schema.Ref != "" {
+			return &jsonSchema{AnyOf: []*jsonSchema{schema, {Type: "null"}}}
+		}
+	}
+	return schema
+}
+
+// Nom de la méthode du handler (getAllMoviesHandler → getAllMovies)
+func handlerName(h http.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	name = name[strings.LastIndex(name, ".")+1:]
+	name = strings.TrimSuffix(name, "-fm")
+	return strings.TrimSuffix(name, "Handler")
+}
+
+// GET /openapi.json : le document des routes REST de la version, calculé une fois
+func (app *application) openAPIHandler(doc *openAPIDocument) http.HandlerFunc {
+	spec, err := json.MarshalIndent(doc, "", "  ")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			log.Println("Error generating OpenAPI document:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}
+}
+
+// Type MIME d'un corps de requête tel que decodeBody le lit : les alias
+// de JSON, XML et MessagePack sont ramenés à leur type principal
+func requestMediaType(contentType string) string {
+	if contentType == "" {
+		return "application/json"
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+
+	if f, ok := mediaTypeFormats[mediaType]; ok && f != formatCSV {
+		mediaType, _, _ = mime.ParseMediaType(formatContentTypes[f])
+	}
+	if strings.HasSuffix(mediaType, "+json") || mediaType == "application/x-www-form-urlencoded" || mediaType == "text/plain" {
+		return "application/json"
+	}
+	return mediaType
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+type CreatePersonRequest struct {
+	Name      string `json:"name" example:"Lana Wachowski"`
+	BirthYear int    `json:"birth_year" example:"1965"`
+	Biography string `json:"biography" example:"Réalisatrice et scénariste américaine"`
+}
+
+// GetAllPeople godoc
+// @Summary      Lister les personnes
+// @Description  Renvoie la liste paginée des réalisateurs, acteurs et membres de l'équipe
+// @Tags         people
+// @Produce      json
+// @Param        name       query  string  false  "Recherche par nom"
+// @Param        page       query  int     false  "Page"
+// @Param        page_size  query  int     false  "Taille de page"
+// @Success      200  {object}  map[string]any "metadata et people ([]store.Person)"
+// @Router       /people [get]
+func (app *application) getAllPeopleHandler(w http.ResponseWriter, r *http.Request) {
+	queryValues := r.URL.Query()
+
+	filters, err := parseMovieFilters(queryValues)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	people, metadata, err := app.store.People.GetPeople(queryValues.Get("name"), filters)
+	if err != nil {
+		log.Println("Error fetching people:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"metadata": metadata,
+		"people":   people,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// GetPerson godoc
+// @Summary      Récupérer une personne par ID
+// @Tags         people
+// @Produce      json
+// @Param        id   path      int  true  "ID de la personne"
+// @Success      200  {object}  store.Person
+// @Failure      404  {string}  string "Personne non trouvée"
+// @Router       /people/{id} [get]
+func (app *application) getPersonByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	person, err := app.store.People.GetPersonByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Person not found", http.StatusNotFound)
+		} else {
+			log.Println("Error fetching person:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, person)
+}
+
+// CreatePerson godoc
+// @Summary      Créer une personne
+// @Tags         people
+// @Accept       json
+// @Produce      json
+// @Param        input  body      CreatePersonRequest  true  "Infos de la personne"
+// @Success      201    {object}  store.Person
+// @Failure      400    {string}  string "Erreur de validation"
+// @Router       /people [post]
+// @Security     BearerAuth
+func (app *application) createPersonHandler(w http.ResponseWriter, r *http.Request) {
+	var person store.Person
+
+	if err := decodeBody(r, &person); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	if err := person.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	newPerson, err := app.store.People.AddPerson(person)
+	if err != nil {
+		log.Println("Error adding person:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, newPerson)
+}
+
+// UpdatePerson godoc
+// @Summary      Modifier une personne
+// @Tags         people
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                  true  "ID de la personne"
+// @Param        input  body      CreatePersonRequest  true  "Nouvelles infos"
+// @Success      200    {object}  store.Person
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      404    {string}  string "Personne non trouvée"
+// @Router       /people/{id} [put]
+// @Security     BearerAuth
+func (app *application) updatePersonHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var person store.Person
+	if err := decodeBody(r, &person); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	person.ID = id
+
+	if err := person.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.People.UpdatePerson(person); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Person not found", http.StatusNotFound)
+		} else {
+			log.Println("Error updating person:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	person, err = app.store.People.GetPersonByID(id)
+	if err != nil {
+		log.Println("Error fetching updated person:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, person)
+}
+
+// DeletePerson godoc
+// @Summary      Supprimer une personne
+// @Description  Supprime la personne et sa participation à tous les films
+// @Tags         people
+// @Param        id   path      int  true  "ID de la personne"
+// @Success      204  {string}  string "Personne supprimée"
+// @Failure      404  {string}  string "Personne non trouvée"
+// @Router       /people/{id} [delete]
+// @Security     BearerAuth
+func (app *application) deletePersonHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.People.DeletePerson(id); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Person not found", http.StatusNotFound)
+		} else {
+			log.Println("Error deleting person:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetFilmography godoc
+// @Summary      Filmographie d'une personne
+// @Description  Renvoie les films d'une personne avec son rôle, du plus récent au plus ancien
+// @Tags         people
+// @Produce      json
+// @Param        id   path      int  true  "ID de la personne"
+// @Success      200  {object}  map[string]any "filmography ([]store.FilmographyEntry)"
+// @Failure      404  {string}  string "Personne non trouvée"
+// @Router       /people/{id}/filmography [get]
+func (app *application) getFilmographyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := app.store.People.GetFilmography(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Person not found", http.StatusNotFound)
+		} else {
+			log.Println("Error fetching filmography:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, map[string]any{"filmography": entries})
+}
+
+// SetMovieCredits godoc
+// @Summary      Remplacer le générique d'un film
+// @Description  Rôles : director, writer, actor (avec character et billing_order), composer
+// @Tags         movies
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int             true  "ID du film"
+// @Param        input  body      []store.Credit  true  "Générique complet"
+// @Success      200    {object}  map[string]any "credits ([]store.Credit)"
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      404    {string}  string "Film non trouvé"
+// @Router       /movies/{id}/credits [put]
+// @Security     BearerAuth
+func (app *application) setMovieCreditsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var credits []store.Credit
+	if err := decodeBody(r, &credits); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	for i := range credits {
+		if err := credits[i].Validate(); err != nil {
+			http.Error(w, fmt.Sprintf("credit %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	saved, err := app.store.Movies.SetCredits(id, credits)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrPersonNotFound):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Println("Error setting credits:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, map[string]any{"credits": saved})
+}
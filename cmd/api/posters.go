@@ -0,0 +1,230 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+// Les fichiers ne changent jamais de contenu (version dans l'URL)
+const imageCacheControl = "public, max-age=31536000, immutable"
+
+// UploadPoster godoc
+// @Summary      Envoyer l'affiche d'un film
+// @Description  Accepte une image JPEG, PNG ou WebP (10 Mo max, 200x300 pixels min), envoyée
+// @Description  directement dans le corps ou dans le champ "poster" d'un formulaire multipart.
+// @Description  Des vignettes JPEG (w185, w342, w500) sont générées. Remplace l'affiche précédente.
+// @Tags         posters
+// @Accept       image/jpeg,image/png,image/webp,multipart/form-data
+// @Produce      json
+// @Param        id      path      int   true   "ID du film"
+// @Param        poster  formData  file  false  "Image (formulaire multipart)"
+// @Success      200     {object}  store.Poster
+// @Failure      400     {string}  string "Image invalide"
+// @Failure      404     {string}  string "Film non trouvé"
+// @Failure      413     {string}  string "Image trop lourde"
+// @Router       /movies/{id}/poster [put]
+// @Security     BearerAuth
+func (app *application) uploadPosterHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	data, err := readPosterUpload(w, r)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "poster must not exceed 10 MB", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	processed, err := store.ProcessPoster(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	processed.Poster.MovieID = id
+
+	previous, err := app.store.Posters.GetPoster(id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Println("Error fetching poster:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := app.storePosterFiles(processed); err != nil {
+		log.Println("Error storing poster files:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	poster, err := app.store.Posters.SetPoster(processed.Poster)
+	if err != nil {
+		// Les nouveaux fichiers ne sont rattachés à rien : on les retire
+		if previous.Version != processed.Poster.Version {
+			if delErr := app.blobs.DeletePrefix(processed.Poster.Prefix()); delErr != nil {
+				log.Println("Error removing poster files:", delErr)
+			}
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		} else {
+			log.Println("Error saving poster:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// L'ancienne version n'est plus référencée
+	if previous.Version != "" && previous.Version != poster.Version {
+		if err := app.blobs.DeletePrefix(previous.Prefix()); err != nil {
+			log.Println("Error removing old poster files:", err)
+		}
+	}
+
+	respond(w, r, http.StatusOK, poster)
+}
+
+// DeletePoster godoc
+// @Summary      Supprimer l'affiche d'un film
+// @Tags         posters
+// @Param        id   path  int  true  "ID du film"
+// @Success      204
+// @Failure      404  {string}  string "Pas d'affiche"
+// @Router       /movies/{id}/poster [delete]
+// @Security     BearerAuth
+func (app *application) deletePosterHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.Posters.DeletePoster(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Poster not found", http.StatusNotFound)
+		} else {
+			log.Println("Error deleting poster:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	app.removePosterFiles(id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeImage godoc
+// @Summary      Télécharger une image
+// @Description  Sert les affiches et vignettes dont les URL sont données par GET /movies/{id}.
+// @Description  Les réponses peuvent être gardées en cache un an.
+// @Tags         posters
+// @Produce      image/jpeg,image/png,image/webp
+// @Param        path  path  string  true  "Chemin de l'image (ex: posters/1/3f2a9c/w342.jpg)"
+// @Success      200
+// @Failure      404   {string}  string "Image non trouvée"
+// @Router       /images/{path} [get]
+func (app *application) serveImageHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("path")
+
+	blob, err := app.blobs.Get(key)
+	if err != nil {
+		if errors.Is(err, store.ErrBlobNotFound) || errors.Is(err, store.ErrInvalidKey) {
+			http.Error(w, "Image not found", http.StatusNotFound)
+		} else {
+			log.Println("Error reading image:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer blob.Content.Close()
+
+	w.Header().Set("Cache-Control", imageCacheControl)
+	if blob.ContentType != "" {
+		w.Header().Set("Content-Type", blob.ContentType)
+	}
+
+	// Gère If-Modified-Since et les requêtes Range
+	http.ServeContent(w, r, path.Base(key), blob.ModTime, blob.Content)
+}
+
+// --- HELPERS ---
+
+// Lit l'image envoyée, brute ou dans le champ "poster" d'un formulaire multipart
+func readPosterUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, store.MaxPosterBytes+1<<20)
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return readLimited(r.Body)
+	}
+
+	file, _, err := r.FormFile("poster")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, err
+		}
+		return nil, errors.New(`missing "poster" file in form`)
+	}
+	defer file.Close()
+
+	return readLimited(file)
+}
+
+// Lit au plus MaxPosterBytes octets
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, store.MaxPosterBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > store.MaxPosterBytes {
+		return nil, &http.MaxBytesError{Limit: store.MaxPosterBytes}
+	}
+	if len(data) == 0 {
+		return nil, errors.New("poster is empty")
+	}
+	return data, nil
+}
+
+// Écrit l'original et les vignettes ; en cas d'erreur, rien n'est gardé
+func (app *application) storePosterFiles(p store.ProcessedPoster) error {
+	err := app.blobs.Put(p.Poster.OriginalKey(), p.Original)
+	for size, data := range p.Thumbnails {
+		if err != nil {
+			break
+		}
+		err = app.blobs.Put(p.Poster.ThumbnailKey(size), data)
+	}
+
+	if err != nil {
+		if err := app.blobs.DeletePrefix(p.Poster.Prefix()); err != nil {
+			log.Println("Error removing poster files:", err)
+		}
+	}
+
+	return err
+}
+
+// Supprime tous les fichiers d'affiche d'un film. Une erreur est seulement
+// journalisée : le film ou l'affiche sont déjà supprimés en base.
+func (app *application) removePosterFiles(movieID int) {
+	if app.blobs == nil {
+		return
+	}
+	if err := app.blobs.DeletePrefix(store.PosterPrefix(movieID)); err != nil {
+		log.Println("Error removing poster files:", err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+type SetRatingRequest struct {
+	Score int `json:"score" example:"8"`
+}
+
+// GetMyRating godoc
+// @Summary      Ma note d'un film
+// @Tags         ratings
+// @Produce      json
+// @Param        id   path      int  true  "ID du film"
+// @Success      200  {object}  store.UserRating
+// @Failure      404  {string}  string "Film non noté"
+// @Router       /movies/{id}/ratings/me [get]
+// @Security     BearerAuth
+func (app *application) getMyRatingHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	rating, err := app.store.Ratings.GetRating(user.ID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Rating not found", http.StatusNotFound)
+		} else {
+			log.Println("Error fetching rating:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, rating)
+}
+
+// SetMyRating godoc
+// @Summary      Noter un film
+// @Description  Enregistre ou remplace la note (0 à 10) de l'utilisateur connecté. Renvoie la note et les notes agrégées du film.
+// @Tags         ratings
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int               true  "ID du film"
+// @Param        input  body      SetRatingRequest  true  "Note de 0 à 10"
+// @Success      200    {object}  map[string]any "rating (store.UserRating) et user_ratings (store.RatingSummary)"
+// @Failure      400    {string}  string "Note invalide"
+// @Failure      404    {string}  string "Film non trouvé"
+// @Router       /movies/{id}/ratings/me [put]
+// @Security     BearerAuth
+func (app *application) setMyRatingHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input SetRatingRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	rating, err := app.store.Ratings.SetRating(user.ID, id, input.Score)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidScore):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		default:
+			log.Println("Error setting rating:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	movie, err := app.store.Movies.GetMoviebyID(id)
+	if err != nil {
+		log.Println("Error fetching rated movie:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"rating":       rating,
+		"user_ratings": movie.UserRatings,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// DeleteMyRating godoc
+// @Summary      Retirer ma note
+// @Tags         ratings
+// @Param        id   path      int  true  "ID du film"
+// @Success      204  {string}  string "Note supprimée"
+// @Failure      404  {string}  string "Film non noté"
+// @Router       /movies/{id}/ratings/me [delete]
+// @Security     BearerAuth
+func (app *application) deleteMyRatingHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	if err := app.store.Ratings.DeleteRating(user.ID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Rating not found", http.StatusNotFound)
+		} else {
+			log.Println("Error deleting rating:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultRecommendationLimit = 10
+	maxRecommendationLimit     = 50
+)
+
+// GetSimilarMovies godoc
+// @Summary      Films similaires
+// @Description  Classe les films selon les genres et les personnes (même rôle) en commun,
+// @Description  la proximité de l'année de sortie et la note. reason explique le rapprochement.
+// @Tags         recommendations
+// @Produce      json
+// @Param        id     path   int  true   "ID du film"
+// @Param        limit  query  int  false  "Nombre de films (10 par défaut, 50 au plus)"
+// @Success      200  {object}  map[string]any "similar ([]store.Recommendation)"
+// @Failure      404  {string}  string "Film non trouvé"
+// @Router       /movies/{id}/similar [get]
+func (app *application) getSimilarMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	similar, err := app.store.Recommendations.GetSimilarMovies(id, limit)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		} else {
+			log.Println("Error fetching similar movies:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, map[string]any{"similar": similar})
+}
+
+// GetRecommendations godoc
+// @Summary      Mes recommandations
+// @Description  Films proches de ceux que l'utilisateur a bien notés (7 ou plus) ou vus,
+// @Description  hors films déjà vus ou notés. because liste les films à l'origine de la recommandation.
+// @Tags         recommendations
+// @Produce      json
+// @Param        limit  query  int  false  "Nombre de films (10 par défaut, 50 au plus)"
+// @Success      200  {object}  map[string]any "recommendations ([]store.Recommendation)"
+// @Router       /users/me/recommendations [get]
+// @Security     BearerAuth
+func (app *application) getRecommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	recommendations, err := app.store.Recommendations.GetUserRecommendations(user.ID, limit)
+	if err != nil {
+		log.Println("Error fetching recommendations:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, map[string]any{"recommendations": recommendations})
+}
+
+// --- HELPERS ---
+
+func parseLimit(value string) (int, error) {
+	if value == "" {
+		return defaultRecommendationLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxRecommendationLimit {
+		return 0, errors.New("limit must be an integer between 1 and 50")
+	}
+
+	return limit, nil
+}
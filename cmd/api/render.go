@@ -0,0 +1,598 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Formats de réponse gérés, choisis selon l'en-tête Accept
+const (
+	formatJSON    = "json"
+	formatXML     = "xml"
+	formatCSV     = "csv"
+	formatMsgpack = "msgpack"
+)
+
+var formatContentTypes = map[string]string{
+	formatJSON:    "application/json",
+	formatXML:     "application/xml; charset=utf-8",
+	formatCSV:     "text/csv; charset=utf-8",
+	formatMsgpack: "application/msgpack",
+}
+
+// Types MIME reconnus dans Accept (et Content-Type pour les corps de requête)
+var mediaTypeFormats = map[string]string{
+	"application/json":        formatJSON,
+	"application/xml":         formatXML,
+	"text/xml":                formatXML,
+	"text/csv":                formatCSV,
+	"application/msgpack":     formatMsgpack,
+	"application/x-msgpack":   formatMsgpack,
+	"application/vnd.msgpack": formatMsgpack,
+}
+
+var errNotAcceptable = errors.New("Not Acceptable: supported types are application/json, application/xml, text/csv (lists only) and application/msgpack")
+
+// Formats acceptés par le client, du préféré au moins bon.
+// Sans en-tête Accept, ou avec */*, c'est du JSON.
+func acceptedFormats(accept string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return []string{formatJSON}
+	}
+
+	type mediaRange struct {
+		format string
+		q      float64
+	}
+	var ranges []mediaRange
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		format, ok := mediaTypeFormats[mediaType]
+		switch {
+		case ok:
+		case mediaType == "*/*", mediaType == "application/*", strings.HasSuffix(mediaType, "+json"):
+			format = formatJSON
+		default:
+			continue
+		}
+		ranges = append(ranges, mediaRange{format, q})
+	}
+
+	slices.SortStableFunc(ranges, func(a, b mediaRange) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	var formats []string
+	for _, r := range ranges {
+		if !slices.Contains(formats, r.format) {
+			formats = append(formats, r.format)
+		}
+	}
+	return formats
+}
+
+// Écrit la réponse dans le format demandé par Accept (JSON par défaut),
+// ou 406 si le client n'en accepte aucun
+func respond(w http.ResponseWriter, r *http.Request, status int, payload any) {
+	w.Header().Add("Vary", "Accept")
+
+	contentType, body, err := encodeResponse(r, payload)
+	if errors.Is(err, errNotAcceptable) {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// Réponse qui contient plusieurs listes : seule la liste key est écrite
+// en CSV, les autres formats reçoivent la réponse entière
+type csvEnvelope struct {
+	key     string
+	payload map[string]any
+}
+
+func (e csvEnvelope) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.payload)
+}
+
+// Encode payload dans le premier format accepté par le client qui lui convient
+// (le CSV ne convient qu'aux listes). Les autres formats sont produits à partir
+// du JSON : ils portent exactement les mêmes champs, dans le même ordre, et les
+// nombres gardent le type déclaré en Go (une note de 8.0 reste un flottant).
+func encodeResponse(r *http.Request, payload any) (string, []byte, error) {
+	var csvKey string
+	if e, ok := payload.(csvEnvelope); ok {
+		csvKey = e.key
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var tree any
+	for _, format := range acceptedFormats(r.Header.Get("Accept")) {
+		if format == formatJSON {
+			return formatContentTypes[format], append(data, '\n'), nil
+		}
+
+		if tree == nil {
+			if tree, err = decodeOrdered(data); err != nil {
+				return "", nil, err
+			}
+			tree = restoreFloats(tree, reflect.ValueOf(payload))
+		}
+
+		var body []byte
+		switch format {
+		case formatXML:
+			body, err = encodeXML(tree)
+		case formatMsgpack:
+			body, err = msgpack.Marshal(tree)
+		case formatCSV:
+			rows, ok := csvRows(tree, csvKey)
+			if !ok {
+				continue
+			}
+			body, err = encodeCSV(rows)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return formatContentTypes[format], body, nil
+	}
+
+	return "", nil, errNotAcceptable
+}
+
+// Refuse d'emblée (406) une écriture si le client n'accepte aucun format
+// de réponse, avant qu'elle ne modifie quoi que ce soit. Les lectures
+// continuent : certaines ont leur propre format (images, exports, SSE).
+func (app *application) negotiationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			formats := acceptedFormats(r.Header.Get("Accept"))
+			// Une écriture ne renvoie jamais de liste à mettre en CSV
+			formats = slices.DeleteFunc(formats, func(f string) bool { return f == formatCSV })
+			if len(formats) == 0 {
+				w.Header().Add("Vary", "Accept")
+				http.Error(w, errNotAcceptable.Error(), http.StatusNotAcceptable)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// --- Représentation intermédiaire : le JSON, ordre des champs conservé ---
+
+// Objet JSON dont les clés restent dans l'ordre de la réponse
+type orderedObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// Décode le JSON en *orderedObject, []any, string, int64, float64, bool ou nil
+// (un nombre sans partie décimale donne un int64, voir restoreFloats)
+func decodeOrdered(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeOrderedValue(dec)
+}
+
+func decodeOrderedValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			obj := &orderedObject{values: make(map[string]any)}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := keyTok.(string)
+				value, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				if _, seen := obj.values[key]; !seen {
+					obj.keys = append(obj.keys, key)
+				}
+				obj.values[key] = value
+			}
+			_, err := dec.Token()
+			return obj, err
+		}
+
+		list := []any{}
+		for dec.More() {
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n, nil
+		}
+		return t.Float64()
+	}
+
+	return tok, nil
+}
+
+// Le JSON écrit 8.0 comme 8 : les nombres entiers de l'arbre que payload
+// déclare flottants redeviennent des float64
+func restoreFloats(tree any, payload reflect.Value) any {
+	for payload.Kind() == reflect.Pointer || payload.Kind() == reflect.Interface {
+		if payload.IsNil() {
+			return tree
+		}
+		payload = payload.Elem()
+	}
+	if !payload.IsValid() || !payload.CanInterface() {
+		return tree
+	}
+
+	switch p := payload.Interface().(type) {
+	case orderedObject:
+		if obj, ok := tree.(*orderedObject); ok {
+			for key, value := range obj.values {
+				obj.values[key] = restoreFloats(value, reflect.ValueOf(p.values[key]))
+			}
+		}
+		return tree
+	case csvEnvelope:
+		return restoreFloats(tree, reflect.ValueOf(p.payload))
+	case json.Marshaler:
+		// JSON déjà écrit (json.RawMessage) ou texte (time.Time) : rien à typer
+		return tree
+	}
+
+	switch payload.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if n, ok := tree.(int64); ok {
+			return float64(n)
+		}
+	case reflect.Struct:
+		if obj, ok := tree.(*orderedObject); ok {
+			fields := jsonFieldValues(payload)
+			for key, value := range obj.values {
+				if field, ok := fields[key]; ok {
+					obj.values[key] = restoreFloats(value, field)
+				}
+			}
+		}
+	case reflect.Map:
+		if obj, ok := tree.(*orderedObject); ok && payload.Type().Key().Kind() == reflect.String {
+			for key, value := range obj.values {
+				obj.values[key] = restoreFloats(value, payload.MapIndex(reflect.ValueOf(key).Convert(payload.Type().Key())))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if list, ok := tree.([]any); ok && len(list) == payload.Len() {
+			for i := range list {
+				list[i] = restoreFloats(list[i], payload.Index(i))
+			}
+		}
+	}
+	return tree
+}
+
+// Valeurs des champs d'une struct par nom JSON, comme jsonFields
+func jsonFieldValues(v reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		tag := f.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				for k, value := range jsonFieldValues(fv) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = value
+					}
+				}
+				continue
+			}
+		}
+
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = v.Field(i)
+	}
+
+	return fields
+}
+
+func (o *orderedObject) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if err := enc.EncodeMapLen(len(o.keys)); err != nil {
+		return err
+	}
+	for _, key := range o.keys {
+		if err := enc.EncodeString(key); err != nil {
+			return err
+		}
+		if err := enc.Encode(o.values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Texte d'une valeur simple, en XML comme en CSV. Un flottant garde sa
+// partie décimale (8.0), pour ne pas être lu comme un entier.
+func scalarText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		text := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(text, ".") {
+			text += ".0"
+		}
+		return text
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// --- XML ---
+
+const xmlRootElement = "response"
+
+// Noms de champs utilisables tels quels comme nom d'élément XML
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// <response> contient la réponse. Les éléments d'une liste prennent le nom
+// de la liste au singulier (<movies><movie>...), ou <item> ; une clé qui
+// n'est pas un nom XML valide devient <entry key="...">.
+func encodeXML(tree any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+	if err := writeXMLElement(enc, xmlRootElement, tree); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func writeXMLElement(enc *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !xmlNamePattern.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "xml") {
+		start = xml.StartElement{
+			Name: xml.Name{Local: "entry"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+		}
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case *orderedObject:
+		for _, key := range v.keys {
+			if err := writeXMLElement(enc, key, v.values[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		item := xmlItemName(name)
+		for _, elem := range v {
+			if err := writeXMLElement(enc, item, elem); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := enc.EncodeToken(xml.CharData(scalarText(v))); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// Nom des éléments d'une liste : movies → movie, countries → country
+func xmlItemName(list string) string {
+	switch {
+	case !xmlNamePattern.MatchString(list), list == xmlRootElement:
+		return "item"
+	case strings.HasSuffix(list, "movies"):
+		return strings.TrimSuffix(list, "s")
+	case strings.HasSuffix(list, "ies"):
+		return strings.TrimSuffix(list, "ies") + "y"
+	case strings.HasSuffix(list, "s") && len(list) > 1:
+		return strings.TrimSuffix(list, "s")
+	}
+	return "item"
+}
+
+// --- CSV ---
+
+// Lignes à mettre en CSV : la réponse si c'est une liste, sinon sa liste key
+// ou, sans key, sa première liste (les films d'une page, sans les métadonnées)
+func csvRows(tree any, key string) ([]any, bool) {
+	switch v := tree.(type) {
+	case []any:
+		return v, true
+	case *orderedObject:
+		if key != "" {
+			list, ok := v.values[key].([]any)
+			return list, ok
+		}
+		for _, key := range v.keys {
+			if list, ok := v.values[key].([]any); ok {
+				return list, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Une ligne par élément. Les objets imbriqués sont aplatis (user_ratings.count),
+// les listes de valeurs simples séparées par "|" comme dans l'export,
+// les autres listes écrites en JSON.
+func encodeCSV(rows []any) ([]byte, error) {
+	var columns []string
+	records := make([]map[string]string, len(rows))
+
+	for i, row := range rows {
+		records[i] = make(map[string]string)
+		flattenCSV("", row, records[i], &columns)
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if len(columns) > 0 {
+		cw.Write(columns)
+	}
+
+	for _, record := range records {
+		line := make([]string, len(columns))
+		for i, col := range columns {
+			line[i] = record[col]
+		}
+		cw.Write(line)
+	}
+
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
+
+func flattenCSV(prefix string, value any, record map[string]string, columns *[]string) {
+	column := prefix
+	if column == "" {
+		column = "value"
+	}
+
+	switch v := value.(type) {
+	case *orderedObject:
+		for _, key := range v.keys {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flattenCSV(name, v.values[key], record, columns)
+		}
+		return
+	case []any:
+		record[column] = csvList(v)
+	default:
+		record[column] = scalarText(v)
+	}
+
+	if !slices.Contains(*columns, column) {
+		*columns = append(*columns, column)
+	}
+}
+
+func csvList(list []any) string {
+	texts := make([]string, len(list))
+	for i, elem := range list {
+		switch elem.(type) {
+		case *orderedObject, []any:
+			data, _ := json.Marshal(list)
+			return string(data)
+		}
+		texts[i] = scalarText(elem)
+	}
+	return strings.Join(texts, "|")
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+type CreateReviewRequest struct {
+	Body    string `json:"body" example:"Une mise en scène vertigineuse."`
+	Spoiler bool   `json:"spoiler" example:"false"`
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status" example:"approved" enums:"approved,rejected,pending"`
+	Note   string `json:"note" example:"Merci pour votre critique"`
+}
+
+type VoteReviewRequest struct {
+	Helpful bool `json:"helpful" example:"true"`
+}
+
+// GetMovieReviews godoc
+// @Summary      Critiques d'un film
+// @Description  Renvoie les critiques approuvées, les plus récentes en premier
+// @Tags         reviews
+// @Produce      json
+// @Param        id             path   int     true   "ID du film"
+// @Param        hide_spoilers  query  bool    false  "Écarter les critiques qui dévoilent l'intrigue"
+// @Param        sort           query  string  false  "Tri : created_at ou helpful_count (ex: -helpful_count)"
+// @Param        page           query  int     false  "Page"
+// @Param        page_size      query  int     false  "Taille de page"
+// @Success      200  {object}  map[string]any "metadata et reviews ([]store.Review)"
+// @Router       /movies/{id}/reviews [get]
+func (app *application) getMovieReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	queryValues := r.URL.Query()
+	filters := parseReviewFilters(queryValues, "-created_at")
+
+	hideSpoilers, _ := strconv.ParseBool(queryValues.Get("hide_spoilers"))
+
+	reviews, metadata, err := app.store.Reviews.GetMovieReviews(id, hideSpoilers, filters)
+	if err != nil {
+		log.Println("Error fetching reviews:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"metadata": metadata,
+		"reviews":  reviews,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// CreateReview godoc
+// @Summary      Critiquer un film
+// @Description  La critique est en attente de modération, sauf si son auteur est éditeur. Une seule critique par utilisateur et par film.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                  true  "ID du film"
+// @Param        input  body      CreateReviewRequest  true  "Texte et drapeau spoiler"
+// @Success      201    {object}  store.Review
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      404    {string}  string "Film non trouvé"
+// @Failure      409    {string}  string "Film déjà critiqué"
+// @Router       /movies/{id}/reviews [post]
+// @Security     BearerAuth
+func (app *application) createReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input CreateReviewRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	review := store.Review{
+		MovieID: id,
+		UserID:  user.ID,
+		Body:    input.Body,
+		Spoiler: input.Spoiler,
+		Status:  initialReviewStatus(user),
+	}
+
+	if err := review.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	newReview, err := app.store.Reviews.AddReview(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrDuplicateReview):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Println("Error adding review:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusCreated, newReview)
+}
+
+// UpdateReview godoc
+// @Summary      Modifier sa critique
+// @Description  Réservé à l'auteur. Une critique modifiée repasse en modération, sauf si son auteur est éditeur.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                  true  "ID de la critique"
+// @Param        input  body      CreateReviewRequest  true  "Nouveau texte et drapeau spoiler"
+// @Success      200    {object}  store.Review
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      403    {string}  string "Critique d'un autre utilisateur"
+// @Failure      404    {string}  string "Critique non trouvée"
+// @Router       /reviews/{id} [put]
+// @Security     BearerAuth
+func (app *application) updateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input CreateReviewRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	review, ok := app.fetchReview(w, id)
+	if !ok {
+		return
+	}
+
+	user := app.contextGetUser(r)
+	if review.UserID != user.ID {
+		http.Error(w, "You can only edit your own reviews", http.StatusForbidden)
+		return
+	}
+
+	review.Body = input.Body
+	review.Spoiler = input.Spoiler
+	review.Status = initialReviewStatus(user)
+
+	if err := review.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.Reviews.UpdateReview(review); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Review not found", http.StatusNotFound)
+		} else {
+			log.Println("Error updating review:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	review, ok = app.fetchReview(w, id)
+	if !ok {
+		return
+	}
+
+	respond(w, r, http.StatusOK, review)
+}
+
+// DeleteReview godoc
+// @Summary      Supprimer une critique
+// @Description  Réservé à l'auteur et aux éditeurs
+// @Tags         reviews
+// @Param        id   path      int  true  "ID de la critique"
+// @Success      204  {string}  string "Critique supprimée"
+// @Failure      403  {string}  string "Critique d'un autre utilisateur"
+// @Failure      404  {string}  string "Critique non trouvée"
+// @Router       /reviews/{id} [delete]
+// @Security     BearerAuth
+func (app *application) deleteReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	review, ok := app.fetchReview(w, id)
+	if !ok {
+		return
+	}
+
+	user := app.contextGetUser(r)
+	if review.UserID != user.ID && !user.HasRole(store.RoleEditor) {
+		http.Error(w, "You can only delete your own reviews", http.StatusForbidden)
+		return
+	}
+
+	if err := app.store.Reviews.DeleteReview(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Review not found", http.StatusNotFound)
+		} else {
+			log.Println("Error deleting review:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VoteReview godoc
+// @Summary      Voter pour une critique
+// @Description  Indique si une critique approuvée est utile ou non. Un nouveau vote remplace le précédent.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                true  "ID de la critique"
+// @Param        input  body      VoteReviewRequest  true  "Utile ou non"
+// @Success      200    {object}  store.Review
+// @Failure      403    {string}  string "Vote sur sa propre critique"
+// @Failure      404    {string}  string "Critique non trouvée"
+// @Router       /reviews/{id}/vote [put]
+// @Security     BearerAuth
+func (app *application) voteReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input VoteReviewRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	review, err := app.store.Reviews.VoteReview(id, user.ID, input.Helpful)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Review not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrOwnReviewVote):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			log.Println("Error voting on review:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, review)
+}
+
+// DeleteReviewVote godoc
+// @Summary      Retirer son vote
+// @Tags         reviews
+// @Param        id   path      int  true  "ID de la critique"
+// @Success      204  {string}  string "Vote retiré"
+// @Failure      404  {string}  string "Aucun vote"
+// @Router       /reviews/{id}/vote [delete]
+// @Security     BearerAuth
+func (app *application) deleteReviewVoteHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	if err := app.store.Reviews.DeleteReviewVote(id, user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Vote not found", http.StatusNotFound)
+		} else {
+			log.Println("Error deleting review vote:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetModerationQueue godoc
+// @Summary      File de modération
+// @Description  Réservé aux éditeurs. Renvoie les critiques ayant le statut demandé (pending par défaut), les plus anciennes en premier.
+// @Tags         reviews
+// @Produce      json
+// @Param        status     query  string  false  "pending, approved ou rejected"
+// @Param        page       query  int     false  "Page"
+// @Param        page_size  query  int     false  "Taille de page"
+// @Success      200  {object}  map[string]any "metadata et reviews ([]store.Review)"
+// @Failure      400  {string}  string "Statut invalide"
+// @Router       /reviews [get]
+// @Security     BearerAuth
+func (app *application) getModerationQueueHandler(w http.ResponseWriter, r *http.Request) {
+	queryValues := r.URL.Query()
+
+	status := queryValues.Get("status")
+	switch status {
+	case "":
+		status = store.ReviewPending
+	case store.ReviewPending, store.ReviewApproved, store.ReviewRejected:
+	default:
+		http.Error(w, store.ErrInvalidStatus.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filters := parseReviewFilters(queryValues, "created_at")
+
+	reviews, metadata, err := app.store.Reviews.GetReviewsByStatus(status, filters)
+	if err != nil {
+		log.Println("Error fetching moderation queue:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"metadata": metadata,
+		"reviews":  reviews,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// ModerateReview godoc
+// @Summary      Modérer une critique
+// @Description  Réservé aux éditeurs. La note éventuelle est visible par l'auteur.
+// @Tags         reviews
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                    true  "ID de la critique"
+// @Param        input  body      ModerateReviewRequest  true  "Nouveau statut"
+// @Success      200    {object}  store.Review
+// @Failure      400    {string}  string "Statut invalide"
+// @Failure      404    {string}  string "Critique non trouvée"
+// @Router       /reviews/{id}/moderation [put]
+// @Security     BearerAuth
+func (app *application) moderateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input ModerateReviewRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	var note *string
+	if input.Note != "" {
+		note = &input.Note
+	}
+
+	review, err := app.store.Reviews.ModerateReview(id, input.Status, note)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidStatus):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Review not found", http.StatusNotFound)
+		default:
+			log.Println("Error moderating review:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, review)
+}
+
+// --- HELPERS ---
+
+// Les critiques des éditeurs sont publiées directement,
+// celles des autres utilisateurs attendent la modération
+func initialReviewStatus(user store.User) string {
+	if user.HasRole(store.RoleEditor) {
+		return store.ReviewApproved
+	}
+	return store.ReviewPending
+}
+
+// Pagination et tri des critiques (created_at ou helpful_count)
+func parseReviewFilters(queryValues url.Values, defaultSort string) store.Filters {
+	filters := parsePageFilters(queryValues)
+
+	filters.Sort = defaultSort
+	if s := queryValues.Get("sort"); s != "" {
+		filters.Sort = s
+	}
+	filters.SortSafelist = []string{"created_at", "helpful_count"}
+
+	return filters
+}
+
+// Charge une critique et répond 404 ou 500 en cas d'échec
+func (app *application) fetchReview(w http.ResponseWriter, id int) (store.Review, bool) {
+	review, err := app.store.Reviews.GetReviewByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Review not found", http.StatusNotFound)
+		} else {
+			log.Println("Error fetching review:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return store.Review{}, false
+	}
+
+	return review, true
+}
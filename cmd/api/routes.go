@@ -1,22 +1,304 @@
 package main
 
 import (
+	"expvar"
+	"maps"
 	"net/http"
+	"reflect"
+	"slices"
+	"time"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 	_ "github.com/vfaust1/movie-api/docs"
+	"github.com/vfaust1/movie-api/internal/store"
 )
 
 func (app *application) routes() http.Handler {
 	router := http.NewServeMux()
 
-	router.HandleFunc("GET /movies", app.getAllMoviesHandler)
-	router.HandleFunc("GET /movies/{id}", app.getMovieByIDHandler)
-	router.HandleFunc("POST /movies", app.createMovieHandler)
-	router.HandleFunc("PUT /movies/{id}", app.updateMovieHandler)
-	router.HandleFunc("DELETE /movies/{id}", app.deleteMovieHandler)
+	app.versionedRoutes(router)
+
+	// GraphQL fait évoluer son schéma sans version
+	graphqlHandler := app.graphqlHandler()
+	router.HandleFunc("GET /graphql", graphqlHandler)
+	router.HandleFunc("POST /graphql", graphqlHandler)
+
+	router.HandleFunc("GET /debug/vars", app.requireRole(store.RoleAdmin, expvar.Handler().ServeHTTP))
 
 	router.Handle("/swagger/", httpSwagger.WrapHandler)
 
-	return app.loggingMiddleware(app.authMiddleware(router))
+	return app.loggingMiddleware(app.authMiddleware(app.negotiationMiddleware(app.idempotencyMiddleware(router))))
+}
+
+// Route REST : son handler, qui peut l'appeler, et ce qu'elle lit et
+// renvoie. C'est de cette table que /openapi.json est généré.
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+	summary string
+	// Rôle minimum exigé, vide si la route n'en demande pas
+	role string
+	// Données personnelles : un jeton utilisateur est exigé (pas la clé API)
+	personal bool
+	query    []queryParam
+	// Valeur du type décodé depuis le corps, nil sans corps
+	request any
+	// Types de contenu du corps quand il n'est pas décodé par decodeBody
+	consumes []string
+	// Valeur renvoyée par statut, nil pour une réponse sans corps
+	responses map[int]any
+	// Types de contenu de la réponse quand elle n'est pas négociée
+	produces []string
+}
+
+// Paramètres de requête communs
+var (
+	pageQuery  = queryParams[int]("page", "page_size")
+	movieQuery = slices.Concat(queryParams[string]("title", "sort", "director", "actor", "language", "country", "certification", "imdb_id"), pageQuery,
+		queryParams[time.Time]("updated_since"), queryParams[int]("min_runtime", "max_runtime", "tmdb_id"), queryParams[bool]("watched", "in_watchlist"))
+	localizedQuery  = queryParams[string]("lang")
+	movieFieldQuery = queryParams[string]("lang", "fields", "include")
+)
+
+// Réponses communes
+var (
+	duplicateResponse = map[string]any{"error": "", "existing_id": 0}
+	bulkResponse      = map[string]any{"atomic": false, "succeeded": 0, "failed": 0, "results": []bulkItemResult{}}
+)
+
+// Routes d'une version et son document OpenAPI (GET /openapi.json),
+// contre lequel les requêtes et les réponses sont vérifiées
+func (app *application) versionRoutes(version string, routes []route) http.Handler {
+	router := http.NewServeMux()
+
+	spec := newOpenAPIDocument(version, routes)
+	router.HandleFunc("GET /openapi.json", app.openAPIHandler(spec))
+
+	for _, rt := range routes {
+		handler := rt.handler
+		if app.validateRequests || app.responseMismatch != nil {
+			handler = app.validateOpenAPI(spec, rt.pattern, handler)
+		}
+
+		switch {
+		case rt.personal:
+			handler = app.requireUser(handler)
+		case rt.role != "":
+			handler = app.requireRole(rt.role, handler)
+		}
+
+		router.HandleFunc(rt.pattern, handler)
+	}
+
+	return router
+}
+
+// Routes de la v1, servies sous /v1 et sans préfixe
+func (app *application) v1RouteTable() []route {
+	return []route{
+		{pattern: "GET /movies", handler: app.getAllMoviesHandler, summary: "Lister les films",
+			query:     slices.Concat(movieQuery, movieFieldQuery),
+			responses: map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "movies": []store.Movie{}, "deleted": []store.DeletedMovie{}}}},
+		{pattern: "GET /movies/export", handler: app.exportMoviesHandler, summary: "Exporter le catalogue",
+			query:     slices.Concat([]queryParam{{name: "format", typ: reflect.TypeFor[string](), enum: []string{"csv", "ndjson", "json"}}}, movieQuery),
+			responses: map[int]any{http.StatusOK: []store.Movie{}}, produces: []string{"application/json", "text/csv", "application/x-ndjson"}},
+		{pattern: "GET /movies/duplicates", handler: app.getDuplicatesHandler, summary: "Lister les doublons probables",
+			query:     slices.Concat(queryParams[float64]("threshold"), queryParams[int]("year_tolerance"), movieQuery),
+			responses: map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "duplicates": []store.DuplicatePair{}}}},
+		{pattern: "GET /movies/events", handler: app.movieEventsHandler, summary: "Suivre les changements du catalogue",
+			query:     slices.Concat(queryParams[string]("genre"), queryParams[int]("last_event_id")),
+			responses: map[int]any{http.StatusOK: ""}, produces: []string{"text/event-stream"}},
+		{pattern: "GET /movies/{id}", handler: app.getMovieByIDHandler, summary: "Lire un film",
+			query:     movieFieldQuery,
+			responses: map[int]any{http.StatusOK: store.Movie{}}},
+		{pattern: "POST /movies", handler: app.createMovieHandler, summary: "Créer un film", role: store.RoleEditor,
+			request:   store.Movie{},
+			responses: map[int]any{http.StatusCreated: store.Movie{}, http.StatusConflict: duplicateResponse}},
+		{pattern: "POST /movies/bulk", handler: app.bulkMoviesHandler, summary: "Créer, modifier et supprimer des films par lot", role: store.RoleEditor,
+			query:   queryParams[bool]("atomic"),
+			request: []store.BulkOperation{}, consumes: []string{"application/json", "application/x-ndjson"},
+			responses: map[int]any{http.StatusOK: bulkResponse, http.StatusUnprocessableEntity: bulkResponse}},
+		{pattern: "POST /movies/import", handler: app.importMoviesHandler, summary: "Importer des films depuis un CSV", role: store.RoleEditor,
+			query:    slices.Concat(queryParams[bool]("dry_run"), queryParams[string]("mapping", "delimiter")),
+			consumes: []string{"text/csv", "multipart/form-data"},
+			responses: map[int]any{http.StatusOK: map[string]any{"dry_run": false, "total_rows": 0, "valid_rows": 0, "invalid_rows": 0,
+				"errors": []importLineError{}, "created": []importLineCreated{}}}},
+		{pattern: "PUT /movies/{id}", handler: app.updateMovieHandler, summary: "Modifier un film", role: store.RoleEditor,
+			request:   store.Movie{},
+			responses: map[int]any{http.StatusOK: store.Movie{}, http.StatusConflict: duplicateResponse}},
+		{pattern: "DELETE /movies/{id}", handler: app.deleteMovieHandler, summary: "Supprimer un film", role: store.RoleEditor,
+			responses: map[int]any{http.StatusNoContent: nil}},
+		{pattern: "POST /movies/{id}/merge", handler: app.mergeMovieHandler, summary: "Fusionner un doublon dans un film", role: store.RoleEditor,
+			request:   MergeMovieRequest{},
+			responses: map[int]any{http.StatusOK: store.Movie{}}},
+		{pattern: "GET /movies/{id}/ratings/me", handler: app.getMyRatingHandler, summary: "Lire ma note", personal: true,
+			responses: map[int]any{http.StatusOK: store.UserRating{}}},
+		{pattern: "PUT /movies/{id}/ratings/me", handler: app.setMyRatingHandler, summary: "Noter un film", personal: true,
+			request:   SetRatingRequest{},
+			responses: map[int]any{http.StatusOK: map[string]any{"rating": store.UserRating{}, "user_ratings": &store.RatingSummary{}}}},
+		{pattern: "DELETE /movies/{id}/ratings/me", handler: app.deleteMyRatingHandler, summary: "Retirer ma note", personal: true,
+			responses: map[int]any{http.StatusNoContent: nil}},
+		{pattern: "GET /movies/{id}/reviews", handler: app.getMovieReviewsHandler, summary: "Lister les critiques d'un film",
+			query:     slices.Concat(queryParams[bool]("hide_spoilers"), queryParams[string]("sort"), pageQuery),
+			responses: map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "reviews": []store.Review{}}}},
+		{pattern: "POST /movies/{id}/reviews", handler: app.createReviewHandler, summary: "Écrire une critique", personal: true,
+			request:   CreateReviewRequest{},
+			responses: map[int]any{http.StatusCreated: store.Review{}}},
+		{pattern: "GET /movies/{id}/lists", handler: app.getMovieListsHandler, summary: "Listes contenant un film",
+			responses: map[int]any{http.StatusOK: map[string]any{"lists": []store.MovieList{}}}},
+		{pattern: "GET /movies/{id}/similar", handler: app.getSimilarMoviesHandler, summary: "Films similaires",
+			query:     queryParams[int]("limit"),
+			responses: map[int]any{http.StatusOK: map[string]any{"similar": []store.Recommendation{}}}},
+		{pattern: "PUT /movies/{id}/credits", handler: app.setMovieCreditsHandler, summary: "Remplacer le générique d'un film", role: store.RoleEditor,
+			request:   []store.Credit{},
+			responses: map[int]any{http.StatusOK: map[string]any{"credits": []store.Credit{}}}},
+		{pattern: "PUT /movies/{id}/poster", handler: app.uploadPosterHandler, summary: "Envoyer l'affiche d'un film", role: store.RoleEditor,
+			consumes:  []string{"image/jpeg", "image/png", "image/webp", "multipart/form-data"},
+			responses: map[int]any{http.StatusOK: store.Poster{}}},
+		{pattern: "DELETE /movies/{id}/poster", handler: app.deletePosterHandler, summary: "Supprimer l'affiche d'un film", role: store.RoleEditor,
+			responses: map[int]any{http.StatusNoContent: nil}},
+		{pattern: "GET /movies/{id}/translations", handler: app.getMovieTranslationsHandler, summary: "Lister les traductions d'un film",
+			responses: map[int]any{http.StatusOK: map[string]any{"translations": []store.MovieTranslation{}}}},
+		{pattern: "PUT /movies/{id}/translations/{lang}", handler: app.setMovieTranslationHandler, summary: "Traduire un film", role: store.RoleEditor,
+			request:   SetTranslationRequest{},
+			responses: map[int]any{http.StatusOK: store.MovieTranslation{}}},
+		{pattern: "DELETE /movies/{id}/translations/{lang}", handler: app.deleteMovieTranslationHandler, summary: "Supprimer une traduction", role: store.RoleEditor,
+			responses: map[int]any{http.StatusNoContent: nil}},
+
+		{pattern: "GET /genres", handler: app.getGenresHandler, summary: "Lister les genres",
+			query:     localizedQuery,
+			responses: map[int]any{http.StatusOK: []store.Genre{}}},
+		{pattern: "PUT /genres/{id}/translations/{lang}", handler: app.setGenreTranslationHandler, summary: "Traduire un genre", role: store.RoleEditor,
+			request:   SetGenreTranslationRequest{},
+			responses: map[int]any{http.StatusNoContent: nil}},
+		{pattern: "DELETE /genres/{id}/translations/{lang}", handler: app.deleteGenreTranslationHandler, summary: "Supprimer la traduction d'un genre", role: store.RoleEditor,
+			responses: map[int]any{http.StatusNoContent: nil}},
+
+		{pattern: "GET /images/{path...}", handler: app.serveImageHandler, summary: "Lire une affiche",
+			responses: map[int]any{http.StatusOK: ""}, produces: []string{"image/jpeg", "image/png", "image/webp"}},
+
+		{pattern: "GET /people", handler: app.getAllPeopleHandler, summary: "Lister les personnes",
+			query:     slices.Concat(queryParams[string]("name"), pageQuery),
+			responses: map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "people": []store.Person{}}}},
+		{pattern: "GET /people/{id}", handler: app.getPersonByIDHandler, summary: "Lire une personne",
+			responses: map[int]any{http.StatusOK: store.Person{}}},
+		{pattern: "GET /people/{id}/filmography", handler: app.getFilmographyHandler, summary: "Filmographie d'une personne",
+			responses: map[int]any{http.StatusOK: map[string]any{"filmography": []store.FilmographyEntry{}}}},
+		{pattern: "POST /people", handler: app.createPersonHandler, summary: "Créer une personne", role: store.RoleEditor,
+			request:   store.Person{},
+			responses: map[int]any{http.StatusCreated: store.Person{}}},
+		{pattern: "PUT /people/{id}", handler: app.updatePersonHandler, summary: "Modifier une personne", role: store.RoleEditor,
+			request:   store.Person{},
+			responses: map[int]any{http.StatusOK: store.Person{}}},
+		{pattern: "DELETE /people/{id}", handler: app.deletePersonHandler, summary: "Supprimer une personne", role: store.RoleEditor,
+			responses: map[int]any{http.StatusNoContent: nil}},
+
+		{pattern: "GET /stats", handler: app.getStatsHandler, summary: "Statistiques du catalogue",
+			query:     movieQuery,
+			responses: map[int]any{http.StatusOK: store.CatalogueStats{}}},
+
+		{pattern: "GET /lists", handler: app.getAllListsHandler, summary: "Lister les listes",
+			query:     slices.Concat(queryParams[string]("name"), queryParams[bool]("mine"), pageQuery),
+			responses: map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "lists": []store.MovieList{}}}},
+		{pattern: "GET /lists/{id}", handler: app.getListByIDHandler, summary: "Lire une liste",
+			responses: map[int]any{http.StatusOK: store.MovieList{}}},
+		{pattern: "POST /lists", handler: app.createListHandler, summary: "Créer une liste", role: store.RoleUser,
+			request:   CreateListRequest{},
+			responses: map[int]any{http.StatusCreated: store.MovieList{}}},
+		{pattern: "PUT /lists/{id}", handler: app.updateListHandler, summary: "Modifier une liste", role: store.RoleUser,
+			request:   CreateListRequest{},
+			responses: map[int]any{http.StatusOK: store.MovieList{}}},
+		{pattern: "DELETE /lists/{id}", handler: app.deleteListHandler, summary: "Supprimer une liste", role: store.RoleUser,
+			responses: map[int]any{http.StatusNoContent: nil}},
+		{pattern: "POST /lists/{id}/items", handler: app.addListItemHandler, summary: "Ajouter un film à une liste", role: store.RoleUser,
+			request:   AddListItemRequest{},
+			responses: map[int]any{http.StatusCreated: store.MovieList{}}},
+		{pattern: "PUT /lists/{id}/items/{movie_id}", handler: app.moveListItemHandler, summary: "Déplacer un film dans une liste", role: store.RoleUser,
+			request:   MoveItemRequest{},
+			responses: map[int]any{http.StatusOK: store.MovieList{}}},
+		{pattern: "DELETE /lists/{id}/items/{movie_id}", handler: app.removeListItemHandler, summary: "Retirer un film d'une liste", role: store.RoleUser,
+			responses: map[int]any{http.StatusNoContent: nil}},
+
+		{pattern: "GET /reviews", handler: app.getModerationQueueHandler, summary: "File de modération des critiques", role: store.RoleEditor,
+			query:     slices.Concat(queryParams[string]("status"), pageQuery),
+			responses: map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "reviews": []store.Review{}}}},
+		{pattern: "PUT /reviews/{id}", handler: app.updateReviewHandler, summary: "Modifier ma critique", personal: true,
+			request:   CreateReviewRequest{},
+			responses: map[int]any{http.StatusOK: store.Review{}}},
+		{pattern: "DELETE /reviews/{id}", handler: app.deleteReviewHandler, summary: "Supprimer une critique", role: store.RoleUser,
+			responses: map[int]any{http.StatusNoContent: nil}},
+		{pattern: "PUT /reviews/{id}/moderation", handler: app.moderateReviewHandler, summary: "Modérer une critique", role: store.RoleEditor,
+			request:   ModerateReviewRequest{},
+			responses: map[int]any{http.StatusOK: store.Review{}}},
+		{pattern: "PUT /reviews/{id}/vote", handler: app.voteReviewHandler, summary: "Voter pour une critique", personal: true,
+			request:   VoteReviewRequest{},
+			responses: map[int]any{http.StatusOK: store.Review{}}},
+		{pattern: "DELETE /reviews/{id}/vote", handler: app.deleteReviewVoteHandler, summary: "Retirer mon vote", personal: true,
+			responses: map[int]any{http.StatusNoContent: nil}},
+
+		{pattern: "POST /users", handler: app.createUserHandler, summary: "Créer un utilisateur", role: store.RoleAdmin,
+			request:   store.User{},
+			responses: map[int]any{http.StatusCreated: CreateUserResponse{}}},
+		{pattern: "GET /users/me", handler: app.getCurrentUserHandler, summary: "Utilisateur courant",
+			responses: map[int]any{http.StatusOK: store.User{}}},
+		{pattern: "GET /users/me/recommendations", handler: app.getRecommendationsHandler, summary: "Films recommandés", personal: true,
+			query:     queryParams[int]("limit"),
+			responses: map[int]any{http.StatusOK: map[string]any{"recommendations": []store.Recommendation{}}}},
+		{pattern: "GET /users/me/watchlist", handler: app.getWatchlistHandler, summary: "Ma liste à voir", personal: true,
+			query:     slices.Concat(queryParams[string]("sort"), pageQuery),
+			responses: map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "watchlist": []store.WatchlistItem{}}}},
+		{pattern: "POST /users/me/watchlist", handler: app.addToWatchlistHandler, summary: "Ajouter un film à voir", personal: true,
+			request:   AddWatchlistRequest{},
+			responses: map[int]any{http.StatusCreated: store.WatchlistItem{}}},
+		{pattern: "PUT /users/me/watchlist/{movie_id}", handler: app.moveWatchlistItemHandler, summary: "Déplacer un film à voir", personal: true,
+			request:   MoveItemRequest{},
+			responses: map[int]any{http.StatusOK: store.WatchlistItem{}}},
+		{pattern: "DELETE /users/me/watchlist/{movie_id}", handler: app.removeFromWatchlistHandler, summary: "Retirer un film à voir", personal: true,
+			responses: map[int]any{http.StatusNoContent: nil}},
+		{pattern: "GET /users/me/watched", handler: app.getWatchedHandler, summary: "Mes films vus", personal: true,
+			query:     slices.Concat(queryParams[string]("sort"), pageQuery),
+			responses: map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "watched": []store.WatchedMovie{}}}},
+		{pattern: "PUT /users/me/watched/{movie_id}", handler: app.markWatchedHandler, summary: "Marquer un film comme vu", personal: true,
+			request:   MarkWatchedRequest{},
+			responses: map[int]any{http.StatusOK: store.WatchedMovie{}}},
+		{pattern: "DELETE /users/me/watched/{movie_id}", handler: app.unmarkWatchedHandler, summary: "Retirer un film vu", personal: true,
+			responses: map[int]any{http.StatusNoContent: nil}},
+
+		{pattern: "GET /webhooks", handler: app.getWebhooksHandler, summary: "Lister les webhooks", role: store.RoleAdmin,
+			responses: map[int]any{http.StatusOK: []store.Webhook{}}},
+		{pattern: "GET /webhooks/{id}", handler: app.getWebhookHandler, summary: "Lire un webhook", role: store.RoleAdmin,
+			responses: map[int]any{http.StatusOK: store.Webhook{}}},
+		{pattern: "POST /webhooks", handler: app.createWebhookHandler, summary: "Créer un webhook", role: store.RoleAdmin,
+			request:   CreateWebhookRequest{},
+			responses: map[int]any{http.StatusCreated: store.Webhook{}}},
+		{pattern: "PUT /webhooks/{id}", handler: app.updateWebhookHandler, summary: "Modifier un webhook", role: store.RoleAdmin,
+			request:   UpdateWebhookRequest{},
+			responses: map[int]any{http.StatusOK: store.Webhook{}}},
+		{pattern: "DELETE /webhooks/{id}", handler: app.deleteWebhookHandler, summary: "Supprimer un webhook", role: store.RoleAdmin,
+			responses: map[int]any{http.StatusNoContent: nil}},
+		{pattern: "GET /webhooks/{id}/deliveries", handler: app.getWebhookDeliveriesHandler, summary: "Historique des envois d'un webhook", role: store.RoleAdmin,
+			query:     pageQuery,
+			responses: map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "deliveries": []store.WebhookDelivery{}}}},
+	}
+}
+
+// Routes de la v2 : celles de la v1, sauf les lectures et écritures
+// de films qui renvoient rating en objet (movieV2)
+func (app *application) v2RouteTable() []route {
+	routes := app.v1RouteTable()
+	for i, rt := range routes {
+		switch rt.pattern {
+		case "GET /movies":
+			routes[i].responses = map[int]any{http.StatusOK: map[string]any{"metadata": store.Metadata{}, "movies": []movieV2{}, "deleted": []store.DeletedMovie{}}}
+		case "GET /movies/{id}", "POST /movies", "PUT /movies/{id}", "POST /movies/{id}/merge":
+			responses := maps.Clone(rt.responses)
+			for status, value := range responses {
+				if _, ok := value.(store.Movie); ok {
+					responses[status] = movieV2{}
+				}
+			}
+			routes[i].responses = responses
+		}
+	}
+	return routes
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Durée pendant laquelle clients et proxies peuvent réutiliser les statistiques
+const statsMaxAge = 60
+
+// GetStats godoc
+// @Summary      Statistiques du catalogue
+// @Description  Nombre de films par genre, décennie et année, note moyenne par genre et décennie,
+// @Description  répartition des notes, films les mieux notés par genre et films sans note ni critique.
+// @Description  Accepte les mêmes filtres que GET /movies. Réponse cacheable 60 secondes (ETag).
+// @Tags         stats
+// @Produce      json
+// @Param        title          query  string  false  "Recherche par titre"
+// @Param        updated_since  query  string  false  "Date RFC 3339"
+// @Param        director       query  string  false  "ID ou nom (partiel) du réalisateur"
+// @Param        actor          query  string  false  "ID ou nom (partiel) d'un acteur"
+// @Param        language       query  string  false  "Langue originale (ISO 639-1)"
+// @Param        country        query  string  false  "Pays de production (ISO 3166-1 alpha-2)"
+// @Param        certification  query  string  false  "Classification MPAA ou CNC"
+// @Param        min_runtime    query  int     false  "Durée minimale en minutes"
+// @Param        max_runtime    query  int     false  "Durée maximale en minutes"
+// @Param        watched        query  bool    false  "Films vus ou non par l'utilisateur connecté"
+// @Param        in_watchlist   query  bool    false  "Films présents ou non dans la liste \"à voir\""
+// @Success      200  {object}  store.CatalogueStats
+// @Success      304  {string}  string "Non modifié"
+// @Failure      400  {string}  string "Paramètre invalide"
+// @Router       /stats [get]
+func (app *application) getStatsHandler(w http.ResponseWriter, r *http.Request) {
+	queryValues := r.URL.Query()
+
+	filters, err := parseMovieFilters(queryValues)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := app.setFilterUser(r, &filters); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	stats, err := app.store.Stats.GetStats(queryValues.Get("title"), filters)
+	if err != nil {
+		log.Println("Error computing stats:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Les statistiques personnelles ne doivent pas être gardées par un proxy partagé
+	respondCacheable(w, r, stats, statsMaxAge, filters.UserID != 0)
+}
+
+// Comme respond, avec Cache-Control et un ETag calculé sur le corps :
+// un client qui renvoie le même ETag (If-None-Match) reçoit 304 sans corps.
+func respondCacheable(w http.ResponseWriter, r *http.Request, payload any, maxAge int, private bool) {
+	w.Header().Add("Vary", "Accept")
+
+	contentType, body, err := encodeResponse(r, payload)
+	if errors.Is(err, errNotAcceptable) {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	scope := "public"
+	if private {
+		scope = "private"
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, maxAge))
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Authorization")
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+type SetTranslationRequest struct {
+	Title    string  `json:"title" example:"Matrix"`
+	Synopsis *string `json:"synopsis" example:"Un pirate informatique découvre que le monde n'est qu'une simulation."`
+	Tagline  *string `json:"tagline" example:"Bienvenue dans le monde réel."`
+}
+
+type SetGenreTranslationRequest struct {
+	Name string `json:"name" example:"Comedy"`
+}
+
+// GetGenres godoc
+// @Summary      Lister les genres
+// @Description  Renvoie les genres avec leurs libellés par langue. Le champ name est traduit
+// @Description  selon le paramètre lang ou l'en-tête Accept-Language.
+// @Tags         genres
+// @Produce      json
+// @Param        lang  query     string  false  "Langue de la réponse (fr, en)"
+// @Success      200   {array}   store.Genre
+// @Failure      400   {string}  string "Langue non gérée"
+// @Router       /genres [get]
+func (app *application) getGenresHandler(w http.ResponseWriter, r *http.Request) {
+	lang, err := requestLanguage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	genres, err := app.store.Genres.GetGenres()
+	if err != nil {
+		log.Println("Error fetching genres:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if lang != "" {
+		for i := range genres {
+			genres[i].Name = genres[i].Label(lang)
+		}
+	}
+	setContentLanguage(w, lang)
+
+	respond(w, r, http.StatusOK, genres)
+}
+
+// SetGenreTranslation godoc
+// @Summary      Traduire un genre
+// @Tags         genres
+// @Accept       json
+// @Param        id     path  int                         true  "ID du genre"
+// @Param        lang   path  string                      true  "Langue (fr, en)"
+// @Param        input  body  SetGenreTranslationRequest  true  "Libellé traduit"
+// @Success      204
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      404    {string}  string "Genre non trouvé"
+// @Router       /genres/{id}/translations/{lang} [put]
+// @Security     BearerAuth
+func (app *application) setGenreTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	lang := r.PathValue("lang")
+	if err := store.CheckLanguage(lang); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var input SetGenreTranslationRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if err := store.ValidateGenreLabel(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.Translations.SetGenreTranslation(id, lang, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Genre not found", http.StatusNotFound)
+		} else {
+			log.Println("Error setting genre translation:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteGenreTranslation godoc
+// @Summary      Supprimer la traduction d'un genre
+// @Tags         genres
+// @Param        id    path  int     true  "ID du genre"
+// @Param        lang  path  string  true  "Langue (fr, en)"
+// @Success      204
+// @Failure      404   {string}  string "Traduction non trouvée"
+// @Router       /genres/{id}/translations/{lang} [delete]
+// @Security     BearerAuth
+func (app *application) deleteGenreTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.Translations.DeleteGenreTranslation(id, r.PathValue("lang")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Translation not found", http.StatusNotFound)
+		} else {
+			log.Println("Error deleting genre translation:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMovieTranslations godoc
+// @Summary      Traductions d'un film
+// @Tags         translations
+// @Produce      json
+// @Param        id   path      int  true  "ID du film"
+// @Success      200  {object}  map[string]any "translations ([]store.MovieTranslation)"
+// @Failure      404  {string}  string "Film non trouvé"
+// @Router       /movies/{id}/translations [get]
+func (app *application) getMovieTranslationsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	translations, err := app.store.Translations.GetMovieTranslations(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		} else {
+			log.Println("Error fetching translations:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, map[string]any{"translations": translations})
+}
+
+// SetMovieTranslation godoc
+// @Summary      Traduire un film
+// @Description  Crée ou remplace le titre, le synopsis et l'accroche du film dans une langue.
+// @Description  Un synopsis ou une accroche absents reprennent ceux du film.
+// @Tags         translations
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                    true  "ID du film"
+// @Param        lang   path      string                 true  "Langue (fr, en)"
+// @Param        input  body      SetTranslationRequest  true  "Textes traduits"
+// @Success      200    {object}  store.MovieTranslation
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      404    {string}  string "Film non trouvé"
+// @Router       /movies/{id}/translations/{lang} [put]
+// @Security     BearerAuth
+func (app *application) setMovieTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input SetTranslationRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	translation := store.MovieTranslation{
+		MovieID:  id,
+		Language: r.PathValue("lang"),
+		Title:    input.Title,
+		Synopsis: input.Synopsis,
+		Tagline:  input.Tagline,
+	}
+
+	if err := translation.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	translation, err = app.store.Translations.SetMovieTranslation(translation)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		} else {
+			log.Println("Error setting translation:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, translation)
+}
+
+// DeleteMovieTranslation godoc
+// @Summary      Supprimer la traduction d'un film
+// @Tags         translations
+// @Param        id    path  int     true  "ID du film"
+// @Param        lang  path  string  true  "Langue (fr, en)"
+// @Success      204
+// @Failure      404   {string}  string "Traduction non trouvée"
+// @Router       /movies/{id}/translations/{lang} [delete]
+// @Security     BearerAuth
+func (app *application) deleteMovieTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.Translations.DeleteMovieTranslation(id, r.PathValue("lang")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Translation not found", http.StatusNotFound)
+		} else {
+			log.Println("Error deleting translation:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- HELPERS ---
+
+// Langue demandée : le paramètre lang, sinon la meilleure langue gérée
+// de l'en-tête Accept-Language, sinon la langue par défaut.
+// Renvoie "" si la requête n'exprime aucune préférence (données d'origine).
+func requestLanguage(r *http.Request) (string, error) {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		lang = strings.ToLower(lang)
+		if err := store.CheckLanguage(lang); err != nil {
+			return "", err
+		}
+		return lang, nil
+	}
+
+	header := r.Header.Get("Accept-Language")
+	if header == "" {
+		return "", nil
+	}
+
+	return negotiateLanguage(header), nil
+}
+
+// Choisit parmi les langues gérées selon un en-tête Accept-Language
+// (ex: "en-US,en;q=0.9,fr;q=0.8"). "en-US" correspond à "en".
+func negotiateLanguage(header string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		c := candidate{tag: strings.ToLower(strings.TrimSpace(tag)), q: 1}
+
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			c.q = q
+		}
+
+		if c.tag != "" && c.q > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+
+	// À qualité égale, l'ordre de l'en-tête est conservé
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		primary, _, _ := strings.Cut(c.tag, "-")
+		if slices.Contains(store.SupportedLanguages, primary) {
+			return primary
+		}
+	}
+
+	return store.DefaultLanguage
+}
+
+// Indique la langue de la réponse ; la réponse dépend toujours d'Accept-Language
+func setContentLanguage(w http.ResponseWriter, lang string) {
+	w.Header().Add("Vary", "Accept-Language")
+	if lang != "" {
+		w.Header().Set("Content-Language", lang)
+	}
+}
+
+// Traduit les films dans la langue demandée (rien si lang est vide)
+func (app *application) localizeMovies(w http.ResponseWriter, lang string, movies []store.Movie) error {
+	setContentLanguage(w, lang)
+	if lang == "" {
+		return nil
+	}
+	return app.store.Translations.LocalizeMovies(movies, lang)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+type CreateUserRequest struct {
+	Name string `json:"name" example:"alice"`
+	Role string `json:"role" example:"user" enums:"user,editor,admin"`
+}
+
+// Réponse de création : le token n'est renvoyé qu'une seule fois
+type CreateUserResponse struct {
+	User  store.User `json:"user"`
+	Token string     `json:"token" example:"3f9a..."`
+}
+
+// CreateUser godoc
+// @Summary      Créer un utilisateur
+// @Description  Réservé aux administrateurs. Le token d'accès est généré et renvoyé une seule fois, seule son empreinte est conservée.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        input  body      CreateUserRequest  true  "Nom et rôle (user, editor, admin)"
+// @Success      201    {object}  CreateUserResponse
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      403    {string}  string "Rôle insuffisant"
+// @Failure      409    {string}  string "Nom déjà utilisé"
+// @Router       /users [post]
+// @Security     BearerAuth
+func (app *application) createUserHandler(w http.ResponseWriter, r *http.Request) {
+	var user store.User
+
+	if err := decodeBody(r, &user); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	if err := user.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		log.Println("Error generating token:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	newUser, err := app.store.Users.AddUser(user, store.HashToken(token))
+	if err != nil {
+		if errors.Is(err, store.ErrDuplicateUserName) {
+			http.Error(w, err.Error(), http.StatusConflict)
+		} else {
+			log.Println("Error adding user:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusCreated, CreateUserResponse{User: newUser, Token: token})
+}
+
+// GetCurrentUser godoc
+// @Summary      Utilisateur courant
+// @Description  Renvoie l'utilisateur correspondant au token (ou le compte de service de la clé API)
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  store.User
+// @Failure      401  {string}  string "Non authentifié"
+// @Router       /users/me [get]
+// @Security     BearerAuth
+func (app *application) getCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	respond(w, r, http.StatusOK, app.contextGetUser(r))
+}
+
+// Génère un token aléatoire de 256 bits encodé en hexadécimal
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
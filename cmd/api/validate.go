@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Vérifie une route contre sa description dans /openapi.json : ses
+// paramètres et son corps JSON avant le handler (OPENAPI_VALIDATE), et,
+// dans les tests, la réponse qu'il renvoie (app.responseMismatch)
+func (app *application) validateOpenAPI(spec *openAPIDocument, pattern string, next http.HandlerFunc) http.HandlerFunc {
+	op := spec.operation(pattern)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if app.validateRequests {
+			if status, err := spec.checkRequest(op, r); err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+		}
+
+		if app.responseMismatch == nil {
+			next(w, r)
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		if err := spec.checkResponse(op, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+			app.responseMismatch(r, fmt.Errorf("%s: %w", pattern, err))
+		}
+	}
+}
+
+// Renvoie le statut à répondre (400 ou 415) si la requête ne suit pas l'opération
+func (d *openAPIDocument) checkRequest(op *openAPIOperation, r *http.Request) (int, error) {
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		value := r.PathValue(p.Name)
+		if p.In == "query" {
+			value = query.Get(p.Name)
+		}
+		if value == "" {
+			continue
+		}
+		if err := checkParam(p.Schema, value); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("Invalid %s parameter %s: %w", p.In, p.Name, err)
+		}
+	}
+
+	if op.RequestBody == nil {
+		return 0, nil
+	}
+
+	mediaType := requestMediaType(r.Header.Get("Content-Type"))
+	media, ok := op.RequestBody.Content[mediaType]
+	if !ok {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("Content-Type must be one of %s", strings.Join(slices.Sorted(maps.Keys(op.RequestBody.Content)), ", "))
+	}
+
+	// Seul le JSON est vérifié : XML et MessagePack sont convertis
+	// d'après le type Go par decodeBody, les autres formats sont lus tels quels
+	if mediaType != "application/json" {
+		return 0, nil
+	}
+
+	// Au-delà de la taille maximale d'un lot, le handler refusera le corps
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBulkBodyBytes+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil || len(body) > maxBulkBodyBytes {
+		return 0, nil
+	}
+
+	value, err := decodeJSONValue(body)
+	if err != nil {
+		return http.StatusBadRequest, &bodyError{"JSON", err}
+	}
+	if err := d.checkValue(media.Schema, value, "body"); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("Invalid request: %w", err)
+	}
+
+	return 0, nil
+}
+
+// Vérifie que le statut est documenté et qu'une réponse JSON suit son schéma
+func (d *openAPIDocument) checkResponse(op *openAPIOperation, status int, contentType string, body []byte) error {
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		// Les erreurs relèvent de la réponse par défaut
+		if status < http.StatusBadRequest && status != http.StatusNotModified {
+			return fmt.Errorf("undocumented status %d", status)
+		}
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	media, ok := response.Content[mediaType]
+	if mediaType != "application/json" || !ok {
+		return nil
+	}
+
+	value, err := decodeJSONValue(body)
+	if err != nil {
+		return err
+	}
+	return d.checkValue(media.Schema, value, "response")
+}
+
+// Lit un document JSON en gardant les nombres tels quels (entier ou non)
+func decodeJSONValue(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Vérifie la valeur d'un paramètre de chemin ou de requête
+func checkParam(schema *jsonSchema, value string) error {
+	var err error
+	switch schema.Type {
+	case "integer":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "number":
+		_, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		_, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return fmt.Errorf("must be %s", schema.Type)
+	}
+
+	if schema.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return errors.New("must be an RFC 3339 date")
+		}
+	}
+	if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, value) {
+		return fmt.Errorf("must be one of %s", strings.Join(schema.Enum, ", "))
+	}
+	return nil
+}
+
+// Vérifie une valeur JSON (lue par decodeJSONValue) contre un schéma.
+// at situe la valeur dans le document (body.genres[0]) pour le message d'erreur.
+func (d *openAPIDocument) checkValue(schema *jsonSchema, value any, at string) error {
+	if schema.Ref != "" {
+		return d.checkValue(d.Components.Schemas[strings.TrimPrefix(schema.Ref, schemaRefPrefix)], value, at)
+	}
+
+	if len(schema.AnyOf) > 0 {
+		var first error
+		for _, alt := range schema.AnyOf {
+			err := d.checkValue(alt, value, at)
+			if err == nil {
+				return nil
+			}
+			// L'erreur de l'alternative null n'apprend rien
+			if first == nil && alt.Type != "null" {
+				first = err
+			}
+		}
+		return first
+	}
+
+	if types := schema.types(); len(types) > 0 {
+		typ := jsonType(value)
+		if !slices.Contains(types, typ) && !(typ == "integer" && slices.Contains(types, "number")) {
+			return fmt.Errorf("%s must be %s", at, strings.Join(types, " or "))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				return fmt.Errorf("%s must be an RFC 3339 date", at)
+			}
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, v) {
+			return fmt.Errorf("%s must be one of %s", at, strings.Join(schema.Enum, ", "))
+		}
+
+	case []any:
+		if schema.Items != nil {
+			for i, item := range v {
+				if err := d.checkValue(schema.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			if property, ok := schema.Properties[key]; ok {
+				if err := d.checkValue(property, v[key], at+"."+key); err != nil {
+					return err
+				}
+				continue
+			}
+
+			switch additional := schema.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					return fmt.Errorf("%s.%s is not a known field", at, key)
+				}
+			case *jsonSchema:
+				if err := d.checkValue(additional, v[key], at+"."+key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Types admis par le schéma, aucun s'il accepte toute valeur
+func (s *jsonSchema) types() []string {
+	switch typ := s.Type.(type) {
+	case string:
+		return []string{typ}
+	case []string:
+		return typ
+	case []any:
+		// Schéma relu depuis du JSON
+		types := make([]string, 0, len(typ))
+		for _, t := range typ {
+			if name, ok := t.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// Type JSON d'une valeur lue par decodeJSONValue
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return ""
+}
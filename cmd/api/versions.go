@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+// En-tête qui choisit la version des routes sans préfixe (API-Version: 2)
+const apiVersionHeader = "API-Version"
+
+const apiVersionContextKey = contextKey("api_version")
+
+// Version de l'API servie sous /<name>/
+type apiVersion struct {
+	name    string
+	handler http.Handler
+	// Dates de fin, vides tant que la version n'est pas remplacée
+	deprecation deprecation
+	successor   string
+}
+
+// Dépréciation d'une version : les réponses l'annoncent avec les en-têtes
+// Deprecation (RFC 9745), Sunset (RFC 8594) et Link vers la version suivante
+type deprecation struct {
+	since  time.Time
+	sunset time.Time
+}
+
+// Versions publiées, de la plus ancienne à la plus récente
+var apiVersionNames = []string{"v1", "v2"}
+
+// Routes versionnées : /v1/..., /v2/..., et les routes sans préfixe,
+// alias de v1 sauf si l'en-tête API-Version en demande une autre
+func (app *application) versionedRoutes(router *http.ServeMux) {
+	versions := []apiVersion{
+		{name: "v1", handler: app.versionRoutes("v1", app.v1RouteTable()), deprecation: app.v1Deprecation, successor: "v2"},
+		{name: "v2", handler: app.versionRoutes("v2", app.v2RouteTable())},
+	}
+
+	for _, v := range versions {
+		router.Handle("/"+v.name+"/", http.StripPrefix("/"+v.name, v.withHeaders()))
+	}
+
+	router.Handle("/", selectVersion(versions))
+}
+
+// Choisit la version des routes sans préfixe d'après l'en-tête API-Version
+// (1, 2, v1 ou v2), la première version s'il est absent
+func selectVersion(versions []apiVersion) http.Handler {
+	supported := make([]string, len(versions))
+	for i, v := range versions {
+		supported[i] = strings.TrimPrefix(v.name, "v")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", apiVersionHeader)
+
+		requested := r.Header.Get(apiVersionHeader)
+		if requested == "" {
+			versions[0].withHeaders().ServeHTTP(w, r)
+			return
+		}
+
+		name := "v" + strings.TrimPrefix(strings.ToLower(strings.TrimSpace(requested)), "v")
+		for _, v := range versions {
+			if v.name == name {
+				v.withHeaders().ServeHTTP(w, r)
+				return
+			}
+		}
+
+		http.Error(w, fmt.Sprintf("Unsupported API-Version (supported: %s)", strings.Join(supported, ", ")), http.StatusBadRequest)
+	})
+}
+
+// Ajoute à chaque réponse la version servie et, si elle est dépréciée,
+// ses dates de fin et l'adresse de la même ressource dans la version suivante
+func (v apiVersion) withHeaders() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(apiVersionHeader, strings.TrimPrefix(v.name, "v"))
+
+		if !v.deprecation.since.IsZero() {
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", v.deprecation.since.Unix()))
+			if !v.deprecation.sunset.IsZero() {
+				w.Header().Set("Sunset", v.deprecation.sunset.UTC().Format(http.TimeFormat))
+			}
+			if v.successor != "" {
+				w.Header().Add("Link", fmt.Sprintf(`</%s%s>; rel="successor-version"`, v.successor, r.URL.Path))
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), apiVersionContextKey, v.name)
+		v.handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Version servie pour la requête (v1 hors des routes versionnées)
+func requestAPIVersion(r *http.Request) string {
+	if name, ok := r.Context().Value(apiVersionContextKey).(string); ok {
+		return name
+	}
+	return "v1"
+}
+
+// Film tel que la v2 le renvoie : rating réunit la note éditoriale
+// et la moyenne des votes, user_ratings garde le détail des votes
+type movieV2 struct {
+	store.Movie
+	Rating movieRating `json:"rating"`
+}
+
+type movieRating struct {
+	Editorial *float64 `json:"editorial"`
+	// Moyenne des votes des utilisateurs, null sans vote
+	Users *float64 `json:"users"`
+}
+
+// Représentation d'un film dans la version de la requête
+func versionedMovie(r *http.Request, movie store.Movie) any {
+	if requestAPIVersion(r) == "v1" {
+		return movie
+	}
+
+	v2 := movieV2{Movie: movie, Rating: movieRating{Editorial: movie.Rating}}
+	if movie.UserRatings != nil {
+		v2.Rating.Users = movie.UserRatings.Average
+	}
+	return v2
+}
+
+// Chemin sans le préfixe de version (/v1/movies → /movies)
+func unversionedPath(path string) string {
+	for _, name := range apiVersionNames {
+		if rest, ok := strings.CutPrefix(path, "/"+name+"/"); ok {
+			return "/" + rest
+		}
+	}
+	return path
+}
+
+// Lit une date de dépréciation (2026-12-31 ou RFC 3339), zéro si vide
+func parseVersionDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
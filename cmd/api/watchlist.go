@@ -0,0 +1,319 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+type AddWatchlistRequest struct {
+	MovieID  int  `json:"movie_id" example:"1"`
+	Position *int `json:"position" example:"1"`
+}
+
+type MoveItemRequest struct {
+	Position int `json:"position" example:"1"`
+}
+
+type MarkWatchedRequest struct {
+	WatchedAt  *time.Time `json:"watched_at" example:"2024-01-15T20:30:00Z"`
+	WatchCount *int       `json:"watch_count" example:"2"`
+}
+
+// GetWatchlist godoc
+// @Summary      Ma liste "à voir"
+// @Description  Renvoie les films de la liste de l'utilisateur connecté, dans son ordre
+// @Tags         watchlist
+// @Produce      json
+// @Param        page       query  int  false  "Page"
+// @Param        page_size  query  int  false  "Taille de page"
+// @Success      200  {object}  map[string]any "metadata et watchlist ([]store.WatchlistItem)"
+// @Router       /users/me/watchlist [get]
+// @Security     BearerAuth
+func (app *application) getWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	filters := parsePageFilters(r.URL.Query())
+	user := app.contextGetUser(r)
+
+	items, metadata, err := app.store.Watchlists.GetWatchlist(user.ID, filters)
+	if err != nil {
+		log.Println("Error fetching watchlist:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"metadata":  metadata,
+		"watchlist": items,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// AddToWatchlist godoc
+// @Summary      Ajouter un film à ma liste "à voir"
+// @Description  Sans position, le film est ajouté à la fin de la liste
+// @Tags         watchlist
+// @Accept       json
+// @Produce      json
+// @Param        input  body      AddWatchlistRequest  true  "Film et position facultative"
+// @Success      201    {object}  store.WatchlistItem
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      404    {string}  string "Film non trouvé"
+// @Failure      409    {string}  string "Film déjà dans la liste"
+// @Router       /users/me/watchlist [post]
+// @Security     BearerAuth
+func (app *application) addToWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	var input AddWatchlistRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	if input.MovieID <= 0 {
+		http.Error(w, "movie_id is required", http.StatusBadRequest)
+		return
+	}
+	if input.Position != nil && *input.Position < 1 {
+		http.Error(w, "position must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	item, err := app.store.Watchlists.AddToWatchlist(user.ID, input.MovieID, input.Position)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		case errors.Is(err, store.ErrAlreadyInWatchlist):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Println("Error adding to watchlist:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusCreated, item)
+}
+
+// MoveWatchlistItem godoc
+// @Summary      Déplacer un film dans ma liste "à voir"
+// @Tags         watchlist
+// @Accept       json
+// @Produce      json
+// @Param        movie_id  path      int                   true  "ID du film"
+// @Param        input     body      MoveItemRequest  true  "Nouvelle position (1 = en tête)"
+// @Success      200       {object}  store.WatchlistItem
+// @Failure      400       {string}  string "Position invalide"
+// @Failure      404       {string}  string "Film absent de la liste"
+// @Router       /users/me/watchlist/{movie_id} [put]
+// @Security     BearerAuth
+func (app *application) moveWatchlistItemHandler(w http.ResponseWriter, r *http.Request) {
+	movieID, err := strconv.Atoi(r.PathValue("movie_id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input MoveItemRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	if input.Position < 1 {
+		http.Error(w, "position must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	item, err := app.store.Watchlists.MoveWatchlistItem(user.ID, movieID, input.Position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not in watchlist", http.StatusNotFound)
+		} else {
+			log.Println("Error moving watchlist item:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, item)
+}
+
+// RemoveFromWatchlist godoc
+// @Summary      Retirer un film de ma liste "à voir"
+// @Tags         watchlist
+// @Param        movie_id  path      int  true  "ID du film"
+// @Success      204       {string}  string "Film retiré"
+// @Failure      404       {string}  string "Film absent de la liste"
+// @Router       /users/me/watchlist/{movie_id} [delete]
+// @Security     BearerAuth
+func (app *application) removeFromWatchlistHandler(w http.ResponseWriter, r *http.Request) {
+	movieID, err := strconv.Atoi(r.PathValue("movie_id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	if err := app.store.Watchlists.RemoveFromWatchlist(user.ID, movieID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not in watchlist", http.StatusNotFound)
+		} else {
+			log.Println("Error removing from watchlist:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWatched godoc
+// @Summary      Mes films vus
+// @Tags         watchlist
+// @Produce      json
+// @Param        sort       query  string  false  "Tri : watched_at ou watch_count (par défaut -watched_at)"
+// @Param        page       query  int     false  "Page"
+// @Param        page_size  query  int     false  "Taille de page"
+// @Success      200  {object}  map[string]any "metadata et watched ([]store.WatchedMovie)"
+// @Router       /users/me/watched [get]
+// @Security     BearerAuth
+func (app *application) getWatchedHandler(w http.ResponseWriter, r *http.Request) {
+	queryValues := r.URL.Query()
+
+	filters := parsePageFilters(queryValues)
+	filters.Sort = "-watched_at"
+	if s := queryValues.Get("sort"); s != "" {
+		filters.Sort = s
+	}
+	filters.SortSafelist = []string{"watched_at", "watch_count"}
+
+	user := app.contextGetUser(r)
+
+	watched, metadata, err := app.store.Watchlists.GetWatched(user.ID, filters)
+	if err != nil {
+		log.Println("Error fetching watched movies:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"metadata": metadata,
+		"watched":  watched,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// MarkWatched godoc
+// @Summary      Marquer un film comme vu
+// @Description  Le film est retiré de la liste "à voir". watched_at vaut maintenant par défaut ;
+// @Description  watch_count (1 par défaut) permet d'enregistrer les revisionnages.
+// @Tags         watchlist
+// @Accept       json
+// @Produce      json
+// @Param        movie_id  path      int                 true  "ID du film"
+// @Param        input     body      MarkWatchedRequest  false "Date et nombre de visionnages"
+// @Success      200       {object}  store.WatchedMovie
+// @Failure      400       {string}  string "Erreur de validation"
+// @Failure      404       {string}  string "Film non trouvé"
+// @Router       /users/me/watched/{movie_id} [put]
+// @Security     BearerAuth
+func (app *application) markWatchedHandler(w http.ResponseWriter, r *http.Request) {
+	movieID, err := strconv.Atoi(r.PathValue("movie_id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	// Le corps est facultatif : sans lui, le film est vu maintenant
+	var input MarkWatchedRequest
+	if r.ContentLength != 0 {
+		if err := decodeBody(r, &input); err != nil {
+			respondBodyError(w, err)
+			return
+		}
+	}
+
+	if input.WatchedAt != nil && input.WatchedAt.After(time.Now()) {
+		http.Error(w, "watched_at cannot be in the future", http.StatusBadRequest)
+		return
+	}
+	if input.WatchCount != nil && *input.WatchCount < 1 {
+		http.Error(w, "watch_count must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	watched, err := app.store.Watchlists.MarkWatched(user.ID, movieID, input.WatchedAt, input.WatchCount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not found", http.StatusNotFound)
+		} else {
+			log.Println("Error marking movie as watched:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, watched)
+}
+
+// UnmarkWatched godoc
+// @Summary      Retirer un film de mes films vus
+// @Tags         watchlist
+// @Param        movie_id  path      int  true  "ID du film"
+// @Success      204       {string}  string "Film retiré"
+// @Failure      404       {string}  string "Film absent des films vus"
+// @Router       /users/me/watched/{movie_id} [delete]
+// @Security     BearerAuth
+func (app *application) unmarkWatchedHandler(w http.ResponseWriter, r *http.Request) {
+	movieID, err := strconv.Atoi(r.PathValue("movie_id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	if err := app.store.Watchlists.UnmarkWatched(user.ID, movieID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Movie not in watched list", http.StatusNotFound)
+		} else {
+			log.Println("Error unmarking watched movie:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- HELPERS ---
+
+// Lie les filtres watched et in_watchlist à l'utilisateur connecté.
+// Ces filtres n'ont pas de sens sans utilisateur (anonyme ou clé API).
+func (app *application) setFilterUser(r *http.Request, filters *store.Filters) error {
+	if filters.Watched == nil && filters.InWatchlist == nil {
+		return nil
+	}
+
+	user := app.contextGetUser(r)
+	if user.ID == 0 {
+		return errors.New("watched and in_watchlist filters require a user token")
+	}
+
+	filters.UserID = user.ID
+	return nil
+}
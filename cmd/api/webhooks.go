@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vfaust1/movie-api/internal/store"
+)
+
+const (
+	// Délai de réponse accordé au partenaire
+	webhookTimeout = 10 * time.Second
+	// Un envoi réservé n'est repris par une autre instance qu'après ce délai
+	webhookLease = time.Minute
+	// Envois traités en parallèle
+	webhookBatchSize = 20
+	// Relecture de la file sans notification, pour les envois reprogrammés
+	webhookPollInterval = 10 * time.Second
+	// Tentatives par envoi : de 30 s en doublant, plafonné à 2 h (environ 4 h au total)
+	webhookMaxAttempts = 10
+	webhookBaseDelay   = 30 * time.Second
+	webhookMaxDelay    = 2 * time.Hour
+	// Échecs consécutifs après lesquels le webhook est désactivé
+	webhookDisableAfter = 20
+)
+
+// En-têtes ajoutés à chaque envoi ; la signature est
+// "sha256=" suivi du HMAC-SHA256 hexadécimal du corps avec le secret
+const (
+	webhookEventHeader     = "X-Movie-Event"
+	webhookDeliveryHeader  = "X-Movie-Delivery"
+	webhookSignatureHeader = "X-Movie-Signature"
+)
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" example:"https://partner.example.com/hooks/movies"`
+	Secret     string   `json:"secret,omitempty" example:"un-secret-partage-assez-long"`
+	EventTypes []string `json:"event_types" example:"movie.created,movie.deleted"`
+}
+
+type UpdateWebhookRequest struct {
+	URL        string   `json:"url" example:"https://partner.example.com/hooks/movies"`
+	Secret     string   `json:"secret,omitempty" example:""`
+	EventTypes []string `json:"event_types" example:"movie.created,movie.updated,movie.deleted"`
+	Active     *bool    `json:"active,omitempty" example:"true"`
+}
+
+// Envoie en tâche de fond les événements de la file aux webhooks
+type webhookDispatcher struct {
+	store  store.WebhookRepository
+	client *http.Client
+}
+
+func newWebhookDispatcher(webhooks store.WebhookRepository) *webhookDispatcher {
+	return &webhookDispatcher{
+		store: webhooks,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			// Une redirection compte comme un échec : l'URL enregistrée doit répondre elle-même
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Traite la file à chaque nouvel événement et à intervalle régulier,
+// jusqu'à l'annulation de ctx
+func (d *webhookDispatcher) run(ctx context.Context, events *eventBroker) {
+	wake := events.subscribe()
+	defer events.unsubscribe(wake)
+
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Envoie tous les envois arrivés à échéance, par lots
+func (d *webhookDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.store.ClaimDeliveries(webhookBatchSize, webhookLease)
+		if err != nil {
+			log.Println("Error claiming webhook deliveries:", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// Fait une tentative d'envoi et enregistre son résultat
+func (d *webhookDispatcher) deliver(ctx context.Context, delivery store.WebhookDelivery) {
+	attempt := store.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		WebhookID:  delivery.WebhookID,
+		Attempt:    delivery.Attempts + 1,
+	}
+
+	start := time.Now()
+	statusCode, err := d.send(ctx, delivery)
+	attempt.DurationMS = int(time.Since(start).Milliseconds())
+
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	if err != nil {
+		message := err.Error()
+		attempt.Error = &message
+	}
+	attempt.Succeeded = err == nil
+
+	var retryAt *time.Time
+	if !attempt.Succeeded && attempt.Attempt < webhookMaxAttempts {
+		next := time.Now().Add(webhookBackoff(attempt.Attempt))
+		retryAt = &next
+	}
+
+	disabled, err := d.store.RecordAttempt(attempt, retryAt, webhookDisableAfter)
+	if err != nil {
+		log.Println("Error recording webhook attempt:", err)
+		return
+	}
+	if disabled {
+		log.Printf("Webhook %d disabled after %d consecutive failures", delivery.WebhookID, webhookDisableAfter)
+	}
+}
+
+// Envoie l'événement signé. Renvoie le statut HTTP (0 sans réponse)
+// et une erreur si le partenaire n'a pas répondu par un 2xx.
+func (d *webhookDispatcher) send(ctx context.Context, delivery store.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "movie-api-webhooks/1.0")
+	req.Header.Set(webhookEventHeader, delivery.Event.Type)
+	req.Header.Set(webhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(webhookSignatureHeader, signWebhook(delivery.Secret, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// Vider (un peu) le corps permet de réutiliser la connexion
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Délai avant la tentative suivant la tentative n : 30 s, 1 min, 2 min...
+// plafonné, avec 20 % d'aléa pour ne pas relancer tous les envois ensemble
+func webhookBackoff(attempt int) time.Duration {
+	delay := webhookMaxDelay
+	if attempt < 20 {
+		delay = min(webhookBaseDelay<<(attempt-1), webhookMaxDelay)
+	}
+	jitter := time.Duration(rand.Int64N(int64(delay / 5)))
+	return delay - delay/10 + jitter
+}
+
+// GetWebhooks godoc
+// @Summary      Lister les webhooks
+// @Description  Réservé aux administrateurs. Les secrets ne sont pas renvoyés.
+// @Tags         webhooks
+// @Produce      json
+// @Success      200  {array}   store.Webhook
+// @Failure      403  {string}  string "Rôle insuffisant"
+// @Router       /webhooks [get]
+// @Security     BearerAuth
+func (app *application) getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := app.store.Webhooks.GetWebhooks()
+	if err != nil {
+		log.Println("Error fetching webhooks:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusOK, webhooks)
+}
+
+// GetWebhook godoc
+// @Summary      Récupérer un webhook
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path      int  true  "ID du webhook"
+// @Success      200  {object}  store.Webhook
+// @Failure      404  {string}  string "Webhook non trouvé"
+// @Router       /webhooks/{id} [get]
+// @Security     BearerAuth
+func (app *application) getWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	webhook, ok := app.fetchWebhook(w, id)
+	if !ok {
+		return
+	}
+
+	respond(w, r, http.StatusOK, webhook)
+}
+
+// CreateWebhook godoc
+// @Summary      Créer un webhook
+// @Description  Réservé aux administrateurs. Chaque création, modification ou suppression de film
+// @Description  des types choisis (tous par défaut) est envoyée en POST à l'URL, signée dans
+// @Description  l'en-tête X-Movie-Signature (sha256=HMAC-SHA256 du corps avec le secret).
+// @Description  Sans secret, un secret est généré ; il n'est renvoyé qu'ici.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        input  body      CreateWebhookRequest  true  "URL, secret et événements"
+// @Success      201    {object}  store.Webhook
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      403    {string}  string "Rôle insuffisant"
+// @Router       /webhooks [post]
+// @Security     BearerAuth
+func (app *application) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var input CreateWebhookRequest
+
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	webhook := store.Webhook{URL: input.URL, Secret: input.Secret, EventTypes: input.EventTypes}
+	if err := webhook.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if webhook.Secret == "" {
+		secret, err := generateToken()
+		if err != nil {
+			log.Println("Error generating webhook secret:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	newWebhook, err := app.store.Webhooks.AddWebhook(webhook)
+	if err != nil {
+		log.Println("Error adding webhook:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, newWebhook)
+}
+
+// UpdateWebhook godoc
+// @Summary      Modifier un webhook
+// @Description  Remplace l'URL et les événements ; le secret n'est changé que s'il est fourni.
+// @Description  "active": true réactive un webhook désactivé après trop d'échecs.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                   true  "ID du webhook"
+// @Param        input  body      UpdateWebhookRequest  true  "Nouvelle configuration"
+// @Success      200    {object}  store.Webhook
+// @Failure      400    {string}  string "Erreur de validation"
+// @Failure      404    {string}  string "Webhook non trouvé"
+// @Router       /webhooks/{id} [put]
+// @Security     BearerAuth
+func (app *application) updateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	var input UpdateWebhookRequest
+	if err := decodeBody(r, &input); err != nil {
+		respondBodyError(w, err)
+		return
+	}
+
+	webhook, ok := app.fetchWebhook(w, id)
+	if !ok {
+		return
+	}
+
+	webhook.URL, webhook.Secret, webhook.EventTypes = input.URL, input.Secret, input.EventTypes
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+
+	if err := webhook.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := app.store.Webhooks.UpdateWebhook(webhook)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+		} else {
+			log.Println("Error updating webhook:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	respond(w, r, http.StatusOK, updated)
+}
+
+// DeleteWebhook godoc
+// @Summary      Supprimer un webhook
+// @Description  Supprime aussi ses envois en attente et leur historique.
+// @Tags         webhooks
+// @Param        id   path      int  true  "ID du webhook"
+// @Success      204  "No Content"
+// @Failure      404  {string}  string "Webhook non trouvé"
+// @Router       /webhooks/{id} [delete]
+// @Security     BearerAuth
+func (app *application) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	if err := app.store.Webhooks.DeleteWebhook(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+		} else {
+			log.Println("Error deleting webhook:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary      Historique des envois d'un webhook
+// @Description  Envois les plus récents en premier, avec le statut HTTP ou l'erreur de chaque tentative.
+// @Description  Un envoi échoué est retenté jusqu'à 10 fois avec un délai doublé à chaque fois ;
+// @Description  après 20 échecs consécutifs le webhook est désactivé.
+// @Tags         webhooks
+// @Produce      json
+// @Param        id         path   int  true   "ID du webhook"
+// @Param        page       query  int  false  "Page"
+// @Param        page_size  query  int  false  "Taille de page"
+// @Success      200  {object}  map[string]any "metadata et deliveries ([]store.WebhookDelivery)"
+// @Failure      404  {string}  string "Webhook non trouvé"
+// @Router       /webhooks/{id}/deliveries [get]
+// @Security     BearerAuth
+func (app *application) getWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "ID must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := app.fetchWebhook(w, id); !ok {
+		return
+	}
+
+	deliveries, metadata, err := app.store.Webhooks.GetDeliveries(id, parsePageFilters(r.URL.Query()))
+	if err != nil {
+		log.Println("Error fetching webhook deliveries:", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"metadata":   metadata,
+		"deliveries": deliveries,
+	}
+
+	respond(w, r, http.StatusOK, response)
+}
+
+// Charge un webhook et répond 404 ou 500 en cas d'échec
+func (app *application) fetchWebhook(w http.ResponseWriter, id int) (store.Webhook, bool) {
+	webhook, err := app.store.Webhooks.GetWebhookByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+		} else {
+			log.Println("Error fetching webhook:", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return store.Webhook{}, false
+	}
+
+	return webhook, true
+}
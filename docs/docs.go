@@ -19,43 +19,142 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/movies": {
+        "/genres": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Renvoie la liste complète des films",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Renvoie les genres avec leurs libellés par langue. Le champ name est traduit\nselon le paramètre lang ou l'en-tête Accept-Language.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "movies"
+                    "genres"
+                ],
+                "summary": "Lister les genres",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Langue de la réponse (fr, en)",
+                        "name": "lang",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Lister les films",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/main.Movie"
+                                "$ref": "#/definitions/store.Genre"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Langue non gérée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres/{id}/translations/{lang}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "genres"
+                ],
+                "summary": "Traduire un genre",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du genre",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Langue (fr, en)",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Libellé traduit",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetGenreTranslationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Genre non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             },
-            "post": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Ajoute un nouveau film à la base de données",
+                "tags": [
+                    "genres"
+                ],
+                "summary": "Supprimer la traduction d'un genre",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du genre",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Langue (fr, en)",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Traduction non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/graphql": {
+            "post": {
+                "description": "Expose les films, les genres et leurs relations. Les arguments de \"movies\" reprennent\nles filtres de GET /movies (title, page, page_size, sort, language, min_runtime, ...).\nLes mutations create_movie, update_movie et delete_movie demandent le rôle editor.\nLes requêtes trop profondes (8 niveaux) ou trop coûteuses sont refusées.",
                 "consumes": [
                     "application/json"
                 ],
@@ -63,29 +162,65 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "movies"
+                    "graphql"
                 ],
-                "summary": "Créer un film",
+                "summary": "Requête GraphQL",
                 "parameters": [
                     {
-                        "description": "Infos du film",
+                        "description": "Requête, variables et opération",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.CreateMovieRequest"
+                            "$ref": "#/definitions/main.GraphQLRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Film créé",
+                    "200": {
+                        "description": "data et errors",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Erreur",
+                        "description": "Requête invalide ou trop coûteuse",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/images/{path}": {
+            "get": {
+                "description": "Sert les affiches et vignettes dont les URL sont données par GET /movies/{id}.\nLes réponses peuvent être gardées en cache un an.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/webp"
+                ],
+                "tags": [
+                    "posters"
+                ],
+                "summary": "Télécharger une image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chemin de l'image (ex: posters/1/3f2a9c/w342.jpg)",
+                        "name": "path",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "Image non trouvée",
                         "schema": {
                             "type": "string"
                         }
@@ -93,14 +228,59 @@ const docTemplate = `{
                 }
             }
         },
-        "/movies/{id}": {
+        "/lists": {
             "get": {
+                "description": "Renvoie les listes publiques et celles de l'utilisateur connecté, les plus récemment modifiées en premier",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Lister les listes de films",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recherche par nom",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Seulement mes listes",
+                        "name": "mine",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Taille de page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "metadata et lists ([]store.MovieList)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Renvoie les détails d'un film spécifique",
+                "description": "kind vaut \"list\" (sélection) ou \"collection\" (saga). Les listes sont publiques par défaut.",
                 "consumes": [
                     "application/json"
                 ],
@@ -108,13 +288,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "movies"
+                    "lists"
                 ],
-                "summary": "Récupérer un film par ID",
+                "summary": "Créer une liste",
+                "parameters": [
+                    {
+                        "description": "Infos de la liste",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateListRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.MovieList"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/lists/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Récupérer une liste avec ses films",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID du film",
+                        "description": "ID de la liste",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -124,11 +340,11 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.Movie"
+                            "$ref": "#/definitions/store.MovieList"
                         }
                     },
                     "404": {
-                        "description": "Film non trouvé",
+                        "description": "Liste non trouvée",
                         "schema": {
                             "type": "string"
                         }
@@ -141,7 +357,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Met à jour les informations d'un film existant",
+                "description": "Réservé au propriétaire de la liste et aux éditeurs",
                 "consumes": [
                     "application/json"
                 ],
@@ -149,24 +365,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "movies"
+                    "lists"
                 ],
-                "summary": "Modifier un film",
+                "summary": "Modifier une liste",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID du Film",
+                        "description": "ID de la liste",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Nouvelles infos du film",
+                        "description": "Nouvelles infos",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.CreateMovieRequest"
+                            "$ref": "#/definitions/main.CreateListRequest"
                         }
                     }
                 ],
@@ -174,7 +390,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.Movie"
+                            "$ref": "#/definitions/store.MovieList"
                         }
                     },
                     "400": {
@@ -183,8 +399,14 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Liste d'un autre utilisateur",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": "Film non trouvé",
+                        "description": "Liste non trouvée",
                         "schema": {
                             "type": "string"
                         }
@@ -197,7 +419,50 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Efface définitivement un film de la base de données",
+                "description": "Réservé au propriétaire de la liste et aux éditeurs. Les films ne sont pas supprimés.",
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Supprimer une liste",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la liste",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Liste supprimée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Liste d'un autre utilisateur",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Liste non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/lists/{id}/items": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sans position, le film est ajouté à la fin de la liste",
                 "consumes": [
                     "application/json"
                 ],
@@ -205,99 +470,3770 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "movies"
+                    "lists"
                 ],
-                "summary": "Supprimer un film",
+                "summary": "Ajouter un film à une liste",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID du Film",
+                        "description": "ID de la liste",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Film, position et note facultatives",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddListItemRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Film supprimé avec succès",
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.MovieList"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "Film non trouvé",
+                        "description": "Liste ou film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Film déjà dans la liste",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "main.CreateMovieRequest": {
-            "type": "object",
-            "properties": {
-                "genres": {
+        },
+        "/lists/{id}/items/{movie_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Déplacer un film dans une liste",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la liste",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "movie_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nouvelle position (1 = en tête)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.MoveItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.MovieList"
+                        }
+                    },
+                    "400": {
+                        "description": "Position invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film absent de la liste",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Retirer un film d'une liste",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la liste",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "movie_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Film retiré",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film absent de la liste",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renvoie la liste complète des films. Avec updated_since, ne renvoie que\nles films modifiés depuis cette date ainsi que les films supprimés (\"deleted\").\nLes films supprimés ne sont pas paginés : ils sont tous renvoyés sur la première\npage, et absents des suivantes.\nLa recherche par titre porte aussi sur les titres traduits.\nfields limite les colonnes lues et renvoyées, include ajoute les genres et le générique.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "text/csv",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Lister les films",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Date RFC 3339 (ex: 2024-01-15T10:00:00Z)",
+                        "name": "updated_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID ou nom (partiel) du réalisateur",
+                        "name": "director",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID ou nom (partiel) d'un acteur",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tri (ex: -weighted_rating, -average_rating, -rating_count, runtime, -budget, -box_office)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Langue originale, code ISO 639-1 (ex: en)",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pays de production, code ISO 3166-1 alpha-2 (ex: FR)",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Classification MPAA ou CNC (ex: PG-13, -12)",
+                        "name": "certification",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Durée minimale en minutes",
+                        "name": "min_runtime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Durée maximale en minutes",
+                        "name": "max_runtime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Identifiant IMDb (ex: tt0133093)",
+                        "name": "imdb_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Identifiant TMDB",
+                        "name": "tmdb_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Films vus (true) ou non vus (false) par l'utilisateur connecté",
+                        "name": "watched",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Films présents ou non dans la liste \\",
+                        "name": "in_watchlist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Langue du titre, du synopsis et des genres (fr, en), sinon Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Champs à renvoyer, séparés par des virgules (ex: id,title,release_year)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Relations à inclure : genres, credits (ex: genres,credits)",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Movie"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Non modifié depuis If-Modified-Since",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Paramètre invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ajoute un nouveau film à la base de données",
+                "consumes": [
+                    "application/json",
+                    "application/xml",
+                    "application/msgpack"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Créer un film",
+                "parameters": [
+                    {
+                        "description": "Infos du film",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/store.Movie"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Movie"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Film déjà existant (existing_id)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/bulk": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Accepte un tableau JSON, XML ou MessagePack (ou un flux NDJSON avec Content-Type\napplication/x-ndjson) d'opérations {\"op\": \"create|update|delete\", \"id\": 1, \"movie\": {...}}.\nLes opérations sont appliquées dans l'ordre du lot.\nAvec atomic=true tout est appliqué dans une seule transaction, ou rien (422).\nSinon chaque opération est indépendante et son statut est renvoyé dans \"results\".",
+                "consumes": [
+                    "application/json",
+                    "application/xml",
+                    "application/msgpack",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Créer, modifier et supprimer des films par lot",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Tout ou rien",
+                        "name": "atomic",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Opérations",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.BulkOperation"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Requête invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/duplicates": {
+            "get": {
+                "description": "Renvoie les paires de films dont les titres se ressemblent (similarité trigramme,\ncasse, espaces et accents ignorés) et sortis à year_tolerance ans d'écart au plus.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Lister les doublons probables",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Similarité minimale entre 0 et 1 (défaut 0.6)",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Écart d'années toléré (défaut 1)",
+                        "name": "year_tolerance",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Paramètre invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/events": {
+            "get": {
+                "description": "Flux Server-Sent Events des créations, modifications et suppressions de films\n(événements movie.created, movie.updated et movie.deleted).\ndata contient le film modifié, ou son id et ses genres pour une suppression.\nSans Last-Event-ID, seuls les nouveaux événements sont envoyés ; sinon le flux\nreprend juste après cet événement (journal conservé 7 jours).",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Flux des changements du catalogue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ne garder que les films de ce genre",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Reprendre après cet événement (à défaut de l'en-tête)",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Dernier événement reçu, renvoyé par EventSource à la reconnexion",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Flux text/event-stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Last-Event-ID invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/export": {
+            "get": {
+                "description": "Renvoie en flux tous les films (genres compris) correspondant aux mêmes filtres\nque GET /movies (title, sort, updated_since), sans pagination.\nEn CSV, les genres sont séparés par \"|\".",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/json"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Exporter le catalogue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv, ndjson ou json (défaut)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recherche par titre",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tri (ex: -rating)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Movie"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Format inconnu",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Colonnes : title, release_year, rating, review, genres (séparés par \"|\").\nLes en-têtes français (titre, année, note, critique) sont reconnus ; mapping permet\nd'associer d'autres en-têtes, ex: {\"Nom du film\": \"title\"}.\nAvec dry_run=true, rien n'est enregistré : seul le rapport ligne par ligne est renvoyé.",
+                "consumes": [
+                    "text/csv",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Importer des films depuis un CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Fichier CSV (multipart)",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Valider sans enregistrer",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Correspondance JSON en-tête -\u003e champ",
+                        "name": "mapping",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Séparateur (défaut , ; encoder ; en %3B)",
+                        "name": "delimiter",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Fichier invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renvoie les détails d'un film spécifique, générique compris",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Récupérer un film par ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Langue du titre, du synopsis et des genres (fr, en), sinon Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Champs à renvoyer, séparés par des virgules (ex: id,title), sans relations sauf include",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Relations à inclure avec fields : genres, credits",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Movie"
+                        }
+                    },
+                    "304": {
+                        "description": "Non modifié depuis If-Modified-Since",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Langue, champ ou relation non gérés",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Met à jour les informations d'un film existant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Modifier un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du Film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nouvelles infos du film",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/store.Movie"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Movie"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Film déjà existant (existing_id)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Efface définitivement un film de la base de données, ainsi que son affiche",
+                "consumes": [
+                    "application/json",
+                    "application/xml",
+                    "application/msgpack"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Supprimer un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du Film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Film supprimé avec succès",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/credits": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rôles : director, writer, actor (avec character et billing_order), composer",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Remplacer le générique d'un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Générique complet",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Credit"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "credits ([]store.Credit)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/lists": {
+            "get": {
+                "description": "Renvoie les listes publiques (et celles de l'utilisateur connecté) qui contiennent le film",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lists"
+                ],
+                "summary": "Listes contenant un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "lists ([]store.MovieList)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ajoute les genres du doublon au film, complète sa note et sa critique,\npuis supprime le doublon. Renvoie le film fusionné.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "movies"
+                ],
+                "summary": "Fusionner un doublon",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film conservé",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ID du doublon à supprimer",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.MergeMovieRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.Movie"
+                        }
+                    },
+                    "400": {
+                        "description": "Requête invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/poster": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Accepte une image JPEG, PNG ou WebP (10 Mo max, 200x300 pixels min), envoyée\ndirectement dans le corps ou dans le champ \"poster\" d'un formulaire multipart.\nDes vignettes JPEG (w185, w342, w500) sont générées. Remplace l'affiche précédente.",
+                "consumes": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/webp",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "posters"
+                ],
+                "summary": "Envoyer l'affiche d'un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image (formulaire multipart)",
+                        "name": "poster",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Poster"
+                        }
+                    },
+                    "400": {
+                        "description": "Image invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Image trop lourde",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "posters"
+                ],
+                "summary": "Supprimer l'affiche d'un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Pas d'affiche",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/ratings/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Ma note d'un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.UserRating"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non noté",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enregistre ou remplace la note (0 à 10) de l'utilisateur connecté. Renvoie la note et les notes agrégées du film.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Noter un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Note de 0 à 10",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetRatingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "rating (store.UserRating) et user_ratings (store.RatingSummary)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Note invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Retirer ma note",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Note supprimée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non noté",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/reviews": {
+            "get": {
+                "description": "Renvoie les critiques approuvées, les plus récentes en premier",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Critiques d'un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Écarter les critiques qui dévoilent l'intrigue",
+                        "name": "hide_spoilers",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tri : created_at ou helpful_count (ex: -helpful_count)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Taille de page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "metadata et reviews ([]store.Review)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "La critique est en attente de modération, sauf si son auteur est éditeur. Une seule critique par utilisateur et par film.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Critiquer un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Texte et drapeau spoiler",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Review"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Film déjà critiqué",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/similar": {
+            "get": {
+                "description": "Classe les films selon les genres et les personnes (même rôle) en commun,\nla proximité de l'année de sortie et la note. reason explique le rapprochement.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recommendations"
+                ],
+                "summary": "Films similaires",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Nombre de films (10 par défaut, 50 au plus)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "similar ([]store.Recommendation)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/translations": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Traductions d'un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "translations ([]store.MovieTranslation)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}/translations/{lang}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Crée ou remplace le titre, le synopsis et l'accroche du film dans une langue.\nUn synopsis ou une accroche absents reprennent ceux du film.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Traduire un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Langue (fr, en)",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Textes traduits",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SetTranslationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.MovieTranslation"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Supprimer la traduction d'un film",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Langue (fr, en)",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Traduction non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/people": {
+            "get": {
+                "description": "Renvoie la liste paginée des réalisateurs, acteurs et membres de l'équipe",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "people"
+                ],
+                "summary": "Lister les personnes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recherche par nom",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Taille de page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "metadata et people ([]store.Person)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "people"
+                ],
+                "summary": "Créer une personne",
+                "parameters": [
+                    {
+                        "description": "Infos de la personne",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreatePersonRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Person"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/people/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "people"
+                ],
+                "summary": "Récupérer une personne par ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la personne",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Person"
+                        }
+                    },
+                    "404": {
+                        "description": "Personne non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "people"
+                ],
+                "summary": "Modifier une personne",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la personne",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nouvelles infos",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreatePersonRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Person"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Personne non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Supprime la personne et sa participation à tous les films",
+                "tags": [
+                    "people"
+                ],
+                "summary": "Supprimer une personne",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la personne",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Personne supprimée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Personne non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/people/{id}/filmography": {
+            "get": {
+                "description": "Renvoie les films d'une personne avec son rôle, du plus récent au plus ancien",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "people"
+                ],
+                "summary": "Filmographie d'une personne",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la personne",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "filmography ([]store.FilmographyEntry)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Personne non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Réservé aux éditeurs. Renvoie les critiques ayant le statut demandé (pending par défaut), les plus anciennes en premier.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "File de modération",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, approved ou rejected",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Taille de page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "metadata et reviews ([]store.Review)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Statut invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Réservé à l'auteur. Une critique modifiée repasse en modération, sauf si son auteur est éditeur.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Modifier sa critique",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la critique",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nouveau texte et drapeau spoiler",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Review"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Critique d'un autre utilisateur",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Critique non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Réservé à l'auteur et aux éditeurs",
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Supprimer une critique",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la critique",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Critique supprimée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Critique d'un autre utilisateur",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Critique non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews/{id}/moderation": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Réservé aux éditeurs. La note éventuelle est visible par l'auteur.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Modérer une critique",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la critique",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nouveau statut",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ModerateReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Review"
+                        }
+                    },
+                    "400": {
+                        "description": "Statut invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Critique non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews/{id}/vote": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Indique si une critique approuvée est utile ou non. Un nouveau vote remplace le précédent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Voter pour une critique",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la critique",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Utile ou non",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.VoteReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Review"
+                        }
+                    },
+                    "403": {
+                        "description": "Vote sur sa propre critique",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Critique non trouvée",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Retirer son vote",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID de la critique",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Vote retiré",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Aucun vote",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats": {
+            "get": {
+                "description": "Nombre de films par genre, décennie et année, note moyenne par genre et décennie,\nrépartition des notes, films les mieux notés par genre et films sans note ni critique.\nAccepte les mêmes filtres que GET /movies. Réponse cacheable 60 secondes (ETag).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Statistiques du catalogue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recherche par titre",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date RFC 3339",
+                        "name": "updated_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID ou nom (partiel) du réalisateur",
+                        "name": "director",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID ou nom (partiel) d'un acteur",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Langue originale (ISO 639-1)",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pays de production (ISO 3166-1 alpha-2)",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Classification MPAA ou CNC",
+                        "name": "certification",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Durée minimale en minutes",
+                        "name": "min_runtime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Durée maximale en minutes",
+                        "name": "max_runtime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Films vus ou non par l'utilisateur connecté",
+                        "name": "watched",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Films présents ou non dans la liste \\",
+                        "name": "in_watchlist",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.CatalogueStats"
+                        }
+                    },
+                    "304": {
+                        "description": "Non modifié",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Paramètre invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Réservé aux administrateurs. Le token d'accès est généré et renvoyé une seule fois, seule son empreinte est conservée.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Créer un utilisateur",
+                "parameters": [
+                    {
+                        "description": "Nom et rôle (user, editor, admin)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Rôle insuffisant",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Nom déjà utilisé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renvoie l'utilisateur correspondant au token (ou le compte de service de la clé API)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Utilisateur courant",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Non authentifié",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/recommendations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Films proches de ceux que l'utilisateur a bien notés (7 ou plus) ou vus,\nhors films déjà vus ou notés. because liste les films à l'origine de la recommandation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recommendations"
+                ],
+                "summary": "Mes recommandations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Nombre de films (10 par défaut, 50 au plus)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "recommendations ([]store.Recommendation)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/watched": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Mes films vus",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tri : watched_at ou watch_count (par défaut -watched_at)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Taille de page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "metadata et watched ([]store.WatchedMovie)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/watched/{movie_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Le film est retiré de la liste \"à voir\". watched_at vaut maintenant par défaut ;\nwatch_count (1 par défaut) permet d'enregistrer les revisionnages.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Marquer un film comme vu",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "movie_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Date et nombre de visionnages",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.MarkWatchedRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.WatchedMovie"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Retirer un film de mes films vus",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "movie_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Film retiré",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film absent des films vus",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/watchlist": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renvoie les films de la liste de l'utilisateur connecté, dans son ordre",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Ma liste \"à voir\"",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Taille de page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "metadata et watchlist ([]store.WatchlistItem)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sans position, le film est ajouté à la fin de la liste",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Ajouter un film à ma liste \"à voir\"",
+                "parameters": [
+                    {
+                        "description": "Film et position facultative",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.AddWatchlistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.WatchlistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Film déjà dans la liste",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/watchlist/{movie_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Déplacer un film dans ma liste \"à voir\"",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "movie_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nouvelle position (1 = en tête)",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.MoveItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.WatchlistItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Position invalide",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film absent de la liste",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Retirer un film de ma liste \"à voir\"",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du film",
+                        "name": "movie_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Film retiré",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Film absent de la liste",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Réservé aux administrateurs. Les secrets ne sont pas renvoyés.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Lister les webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/store.Webhook"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Rôle insuffisant",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Réservé aux administrateurs. Chaque création, modification ou suppression de film\ndes types choisis (tous par défaut) est envoyée en POST à l'URL, signée dans\nl'en-tête X-Movie-Signature (sha256=HMAC-SHA256 du corps avec le secret).\nSans secret, un secret est généré ; il n'est renvoyé qu'ici.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Créer un webhook",
+                "parameters": [
+                    {
+                        "description": "URL, secret et événements",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/store.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Rôle insuffisant",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Récupérer un webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Webhook"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remplace l'URL et les événements ; le secret n'est changé que s'il est fourni.\n\"active\": true réactive un webhook désactivé après trop d'échecs.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Modifier un webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Nouvelle configuration",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UpdateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/store.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Erreur de validation",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Supprime aussi ses envois en attente et leur historique.",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Supprimer un webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Webhook non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Envois les plus récents en premier, avec le statut HTTP ou l'erreur de chaque tentative.\nUn envoi échoué est retenté jusqu'à 10 fois avec un délai doublé à chaque fois ;\naprès 20 échecs consécutifs le webhook est désactivé.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Historique des envois d'un webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID du webhook",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Taille de page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "metadata et deliveries ([]store.WebhookDelivery)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook non trouvé",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.AddListItemRequest": {
+            "type": "object",
+            "properties": {
+                "movie_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "note": {
+                    "type": "string",
+                    "example": "Le premier et le meilleur"
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "main.AddWatchlistRequest": {
+            "type": "object",
+            "properties": {
+                "movie_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "main.CreateListRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Les incontournables de la décennie"
+                },
+                "is_public": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "list",
+                        "collection"
+                    ],
+                    "example": "list"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Best Sci-Fi of the 90s"
+                }
+            }
+        },
+        "main.CreatePersonRequest": {
+            "type": "object",
+            "properties": {
+                "biography": {
+                    "type": "string",
+                    "example": "Réalisatrice et scénariste américaine"
+                },
+                "birth_year": {
+                    "type": "integer",
+                    "example": 1965
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Lana Wachowski"
+                }
+            }
+        },
+        "main.CreateReviewRequest": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "Une mise en scène vertigineuse."
+                },
+                "spoiler": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "main.CreateUserRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "editor",
+                        "admin"
+                    ],
+                    "example": "user"
+                }
+            }
+        },
+        "main.CreateUserResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "3f9a..."
+                },
+                "user": {
+                    "$ref": "#/definitions/store.User"
+                }
+            }
+        },
+        "main.CreateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "movie.created",
+                        "movie.deleted"
+                    ]
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "un-secret-partage-assez-long"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/movies"
+                }
+            }
+        },
+        "main.GraphQLRequest": {
+            "type": "object",
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "{ movies(page_size: 5) { movies { id title genres { name } } } }"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {}
+                }
+            }
+        },
+        "main.MarkWatchedRequest": {
+            "type": "object",
+            "properties": {
+                "watch_count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "watched_at": {
+                    "type": "string",
+                    "example": "2024-01-15T20:30:00Z"
+                }
+            }
+        },
+        "main.MergeMovieRequest": {
+            "type": "object",
+            "properties": {
+                "duplicate_id": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "main.ModerateReviewRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "example": "Merci pour votre critique"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "approved",
+                        "rejected",
+                        "pending"
+                    ],
+                    "example": "approved"
+                }
+            }
+        },
+        "main.MoveItemRequest": {
+            "type": "object",
+            "properties": {
+                "position": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "main.Movie": {
+            "type": "object",
+            "properties": {
+                "box_office": {
+                    "type": "integer",
+                    "example": 467222728
+                },
+                "budget": {
+                    "type": "integer",
+                    "example": 63000000
+                },
+                "cnc_rating": {
+                    "type": "string",
+                    "enum": [
+                        "TP",
+                        "-12",
+                        "-16",
+                        "-18"
+                    ],
+                    "example": "-12"
+                },
+                "countries": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "US",
+                        "AU"
+                    ]
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T10:00:00Z"
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Action",
+                        "Sci-Fi"
+                    ]
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "imdb_id": {
+                    "type": "string",
+                    "example": "tt0133093"
+                },
+                "mpaa_rating": {
+                    "type": "string",
+                    "enum": [
+                        "G",
+                        "PG",
+                        "PG-13",
+                        "R",
+                        "NC-17"
+                    ],
+                    "example": "R"
+                },
+                "original_language": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "original_title": {
+                    "type": "string",
+                    "example": "The Matrix"
+                },
+                "poster": {
+                    "$ref": "#/definitions/store.Poster"
+                },
+                "rating": {
+                    "type": "number",
+                    "example": 8.7
+                },
+                "release_year": {
+                    "type": "integer",
+                    "example": 1999
+                },
+                "review": {
+                    "type": "string",
+                    "example": "Un chef d'oeuvre de SF"
+                },
+                "runtime": {
+                    "type": "integer",
+                    "example": 136
+                },
+                "synopsis": {
+                    "type": "string",
+                    "example": "Un pirate informatique découvre que le monde n'est qu'une simulation."
+                },
+                "tagline": {
+                    "type": "string",
+                    "example": "Welcome to the Real World."
+                },
+                "title": {
+                    "type": "string",
+                    "example": "The Matrix"
+                },
+                "tmdb_id": {
+                    "type": "integer",
+                    "example": 603
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-01-16T08:30:00Z"
+                }
+            }
+        },
+        "main.SetGenreTranslationRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Comedy"
+                }
+            }
+        },
+        "main.SetRatingRequest": {
+            "type": "object",
+            "properties": {
+                "score": {
+                    "type": "integer",
+                    "example": 8
+                }
+            }
+        },
+        "main.SetTranslationRequest": {
+            "type": "object",
+            "properties": {
+                "synopsis": {
+                    "type": "string",
+                    "example": "Un pirate informatique découvre que le monde n'est qu'une simulation."
+                },
+                "tagline": {
+                    "type": "string",
+                    "example": "Bienvenue dans le monde réel."
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Matrix"
+                }
+            }
+        },
+        "main.UpdateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "movie.created",
+                        "movie.updated",
+                        "movie.deleted"
+                    ]
+                },
+                "secret": {
+                    "type": "string",
+                    "example": ""
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://partner.example.com/hooks/movies"
+                }
+            }
+        },
+        "main.VoteReviewRequest": {
+            "type": "object",
+            "properties": {
+                "helpful": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "store.BulkOperation": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "movie": {
+                    "$ref": "#/definitions/store.Movie"
+                },
+                "op": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.CatalogueStats": {
+            "type": "object",
+            "properties": {
+                "average_rating": {
+                    "type": "number"
+                },
+                "by_decade": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.DecadeStats"
+                    }
+                },
+                "by_genre": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.GenreStats"
+                    }
+                },
+                "by_year": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.YearCount"
+                    }
+                },
+                "missing_rating": {
+                    "type": "integer"
+                },
+                "missing_review": {
+                    "type": "integer"
+                },
+                "rating_distribution": {
                     "type": "array",
                     "items": {
+                        "$ref": "#/definitions/store.RatingBucket"
+                    }
+                },
+                "total_movies": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.Credit": {
+            "type": "object",
+            "properties": {
+                "billing_order": {
+                    "type": "integer"
+                },
+                "character": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "person_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.DecadeStats": {
+            "type": "object",
+            "properties": {
+                "average_rating": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "decade": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.Genre": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "translations": {
+                    "description": "libellés par langue",
+                    "type": "object",
+                    "additionalProperties": {
                         "type": "string"
-                    },
-                    "example": [
-                        "Action",
-                        "Sci-Fi"
-                    ]
+                    }
+                }
+            }
+        },
+        "store.GenreStats": {
+            "type": "object",
+            "properties": {
+                "average_rating": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "top_rated": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.RatedMovie"
+                    }
+                }
+            }
+        },
+        "store.ListItem": {
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "movie": {
+                    "$ref": "#/definitions/store.Movie"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.Movie": {
+            "type": "object",
+            "properties": {
+                "box_office": {
+                    "description": "recettes mondiales, en dollars US",
+                    "type": "integer"
+                },
+                "budget": {
+                    "description": "en dollars US",
+                    "type": "integer"
+                },
+                "cnc_rating": {
+                    "type": "string"
+                },
+                "countries": {
+                    "description": "ISO 3166-1 alpha-2",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "credits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/store.Credit"
+                    }
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "imdb_id": {
+                    "type": "string"
+                },
+                "mpaa_rating": {
+                    "type": "string"
+                },
+                "original_language": {
+                    "description": "ISO 639-1",
+                    "type": "string"
+                },
+                "original_title": {
+                    "type": "string"
+                },
+                "poster": {
+                    "$ref": "#/definitions/store.Poster"
                 },
                 "rating": {
-                    "type": "number",
-                    "example": 8.7
+                    "type": "number"
                 },
                 "release_year": {
-                    "type": "integer",
-                    "example": 1999
+                    "type": "integer"
                 },
                 "review": {
-                    "type": "string",
-                    "example": "Un chef d'oeuvre de SF"
+                    "description": "critique éditoriale",
+                    "type": "string"
+                },
+                "runtime": {
+                    "description": "Métadonnées étendues, toutes facultatives",
+                    "type": "integer"
+                },
+                "synopsis": {
+                    "type": "string"
+                },
+                "tagline": {
+                    "type": "string"
                 },
                 "title": {
-                    "type": "string",
-                    "example": "The Matrix"
+                    "type": "string"
+                },
+                "tmdb_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_ratings": {
+                    "$ref": "#/definitions/store.RatingSummary"
                 }
             }
         },
-        "main.Movie": {
+        "store.MovieList": {
             "type": "object",
             "properties": {
-                "genres": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_public": {
+                    "type": "boolean"
+                },
+                "item_count": {
+                    "type": "integer"
+                },
+                "items": {
                     "type": "array",
                     "items": {
+                        "$ref": "#/definitions/store.ListItem"
+                    }
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "integer"
+                },
+                "owner_name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.MovieTranslation": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string"
+                },
+                "movie_id": {
+                    "type": "integer"
+                },
+                "synopsis": {
+                    "type": "string"
+                },
+                "tagline": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Person": {
+            "type": "object",
+            "properties": {
+                "biography": {
+                    "type": "string"
+                },
+                "birth_year": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.Poster": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "thumbnails": {
+                    "type": "object",
+                    "additionalProperties": {
                         "type": "string"
-                    },
-                    "example": [
-                        "Action",
-                        "Sci-Fi"
-                    ]
+                    }
                 },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.RatedMovie": {
+            "type": "object",
+            "properties": {
                 "id": {
-                    "type": "integer",
-                    "example": 1
+                    "type": "integer"
                 },
                 "rating": {
-                    "type": "number",
-                    "example": 8.7
+                    "type": "number"
                 },
                 "release_year": {
-                    "type": "integer",
-                    "example": 1999
-                },
-                "review": {
-                    "type": "string",
-                    "example": "Un chef d'oeuvre de SF"
+                    "type": "integer"
                 },
                 "title": {
-                    "type": "string",
-                    "example": "The Matrix"
+                    "type": "string"
+                }
+            }
+        },
+        "store.RatingBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "max": {
+                    "type": "integer"
+                },
+                "min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.RatingSummary": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "number"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "distribution": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "weighted_score": {
+                    "type": "number"
+                }
+            }
+        },
+        "store.Review": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "helpful_count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "moderation_note": {
+                    "type": "string"
+                },
+                "movie_id": {
+                    "type": "integer"
+                },
+                "spoiler": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "unhelpful_count": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "user_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.UserRating": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "movie_id": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.WatchedMovie": {
+            "type": "object",
+            "properties": {
+                "movie": {
+                    "$ref": "#/definitions/store.Movie"
+                },
+                "watch_count": {
+                    "type": "integer"
+                },
+                "watched_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.WatchlistItem": {
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "movie": {
+                    "$ref": "#/definitions/store.Movie"
+                },
+                "position": {
+                    "type": "integer"
+                }
+            }
+        },
+        "store.Webhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "disabled_at": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "failure_count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "store.YearCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "year": {
+                    "type": "integer"
                 }
             }
         }
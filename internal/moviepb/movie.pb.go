@@ -0,0 +1,1263 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: movie.proto
+
+// API gRPC du catalogue, pour les services internes.
+// Code Go généré dans internal/moviepb (voir le README).
+
+package moviepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Movie struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title            string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	ReleaseYear      int32                  `protobuf:"varint,3,opt,name=release_year,json=releaseYear,proto3" json:"release_year,omitempty"`
+	Rating           *float64               `protobuf:"fixed64,4,opt,name=rating,proto3,oneof" json:"rating,omitempty"`
+	Review           *string                `protobuf:"bytes,5,opt,name=review,proto3,oneof" json:"review,omitempty"`
+	Genres           []string               `protobuf:"bytes,6,rep,name=genres,proto3" json:"genres,omitempty"`
+	Runtime          *int32                 `protobuf:"varint,7,opt,name=runtime,proto3,oneof" json:"runtime,omitempty"`
+	OriginalTitle    *string                `protobuf:"bytes,8,opt,name=original_title,json=originalTitle,proto3,oneof" json:"original_title,omitempty"`
+	OriginalLanguage *string                `protobuf:"bytes,9,opt,name=original_language,json=originalLanguage,proto3,oneof" json:"original_language,omitempty"`
+	Countries        []string               `protobuf:"bytes,10,rep,name=countries,proto3" json:"countries,omitempty"`
+	MpaaRating       *string                `protobuf:"bytes,11,opt,name=mpaa_rating,json=mpaaRating,proto3,oneof" json:"mpaa_rating,omitempty"`
+	CncRating        *string                `protobuf:"bytes,12,opt,name=cnc_rating,json=cncRating,proto3,oneof" json:"cnc_rating,omitempty"`
+	Synopsis         *string                `protobuf:"bytes,13,opt,name=synopsis,proto3,oneof" json:"synopsis,omitempty"`
+	Tagline          *string                `protobuf:"bytes,14,opt,name=tagline,proto3,oneof" json:"tagline,omitempty"`
+	Budget           *int64                 `protobuf:"varint,15,opt,name=budget,proto3,oneof" json:"budget,omitempty"`
+	BoxOffice        *int64                 `protobuf:"varint,16,opt,name=box_office,json=boxOffice,proto3,oneof" json:"box_office,omitempty"`
+	ImdbId           *string                `protobuf:"bytes,17,opt,name=imdb_id,json=imdbId,proto3,oneof" json:"imdb_id,omitempty"`
+	TmdbId           *int32                 `protobuf:"varint,18,opt,name=tmdb_id,json=tmdbId,proto3,oneof" json:"tmdb_id,omitempty"`
+	UserRatings      *RatingSummary         `protobuf:"bytes,19,opt,name=user_ratings,json=userRatings,proto3" json:"user_ratings,omitempty"`
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,20,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,21,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Movie) Reset() {
+	*x = Movie{}
+	mi := &file_movie_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Movie) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Movie) ProtoMessage() {}
+
+func (x *Movie) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Movie.ProtoReflect.Descriptor instead.
+func (*Movie) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Movie) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Movie) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Movie) GetReleaseYear() int32 {
+	if x != nil {
+		return x.ReleaseYear
+	}
+	return 0
+}
+
+func (x *Movie) GetRating() float64 {
+	if x != nil && x.Rating != nil {
+		return *x.Rating
+	}
+	return 0
+}
+
+func (x *Movie) GetReview() string {
+	if x != nil && x.Review != nil {
+		return *x.Review
+	}
+	return ""
+}
+
+func (x *Movie) GetGenres() []string {
+	if x != nil {
+		return x.Genres
+	}
+	return nil
+}
+
+func (x *Movie) GetRuntime() int32 {
+	if x != nil && x.Runtime != nil {
+		return *x.Runtime
+	}
+	return 0
+}
+
+func (x *Movie) GetOriginalTitle() string {
+	if x != nil && x.OriginalTitle != nil {
+		return *x.OriginalTitle
+	}
+	return ""
+}
+
+func (x *Movie) GetOriginalLanguage() string {
+	if x != nil && x.OriginalLanguage != nil {
+		return *x.OriginalLanguage
+	}
+	return ""
+}
+
+func (x *Movie) GetCountries() []string {
+	if x != nil {
+		return x.Countries
+	}
+	return nil
+}
+
+func (x *Movie) GetMpaaRating() string {
+	if x != nil && x.MpaaRating != nil {
+		return *x.MpaaRating
+	}
+	return ""
+}
+
+func (x *Movie) GetCncRating() string {
+	if x != nil && x.CncRating != nil {
+		return *x.CncRating
+	}
+	return ""
+}
+
+func (x *Movie) GetSynopsis() string {
+	if x != nil && x.Synopsis != nil {
+		return *x.Synopsis
+	}
+	return ""
+}
+
+func (x *Movie) GetTagline() string {
+	if x != nil && x.Tagline != nil {
+		return *x.Tagline
+	}
+	return ""
+}
+
+func (x *Movie) GetBudget() int64 {
+	if x != nil && x.Budget != nil {
+		return *x.Budget
+	}
+	return 0
+}
+
+func (x *Movie) GetBoxOffice() int64 {
+	if x != nil && x.BoxOffice != nil {
+		return *x.BoxOffice
+	}
+	return 0
+}
+
+func (x *Movie) GetImdbId() string {
+	if x != nil && x.ImdbId != nil {
+		return *x.ImdbId
+	}
+	return ""
+}
+
+func (x *Movie) GetTmdbId() int32 {
+	if x != nil && x.TmdbId != nil {
+		return *x.TmdbId
+	}
+	return 0
+}
+
+func (x *Movie) GetUserRatings() *RatingSummary {
+	if x != nil {
+		return x.UserRatings
+	}
+	return nil
+}
+
+func (x *Movie) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Movie) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// Champs modifiables d'un film, mêmes règles que POST /movies
+type MovieInput struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Title            string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	ReleaseYear      int32                  `protobuf:"varint,2,opt,name=release_year,json=releaseYear,proto3" json:"release_year,omitempty"`
+	Rating           *float64               `protobuf:"fixed64,3,opt,name=rating,proto3,oneof" json:"rating,omitempty"`
+	Review           *string                `protobuf:"bytes,4,opt,name=review,proto3,oneof" json:"review,omitempty"`
+	Genres           []string               `protobuf:"bytes,5,rep,name=genres,proto3" json:"genres,omitempty"`
+	Runtime          *int32                 `protobuf:"varint,6,opt,name=runtime,proto3,oneof" json:"runtime,omitempty"`
+	OriginalTitle    *string                `protobuf:"bytes,7,opt,name=original_title,json=originalTitle,proto3,oneof" json:"original_title,omitempty"`
+	OriginalLanguage *string                `protobuf:"bytes,8,opt,name=original_language,json=originalLanguage,proto3,oneof" json:"original_language,omitempty"`
+	Countries        []string               `protobuf:"bytes,9,rep,name=countries,proto3" json:"countries,omitempty"`
+	MpaaRating       *string                `protobuf:"bytes,10,opt,name=mpaa_rating,json=mpaaRating,proto3,oneof" json:"mpaa_rating,omitempty"`
+	CncRating        *string                `protobuf:"bytes,11,opt,name=cnc_rating,json=cncRating,proto3,oneof" json:"cnc_rating,omitempty"`
+	Synopsis         *string                `protobuf:"bytes,12,opt,name=synopsis,proto3,oneof" json:"synopsis,omitempty"`
+	Tagline          *string                `protobuf:"bytes,13,opt,name=tagline,proto3,oneof" json:"tagline,omitempty"`
+	Budget           *int64                 `protobuf:"varint,14,opt,name=budget,proto3,oneof" json:"budget,omitempty"`
+	BoxOffice        *int64                 `protobuf:"varint,15,opt,name=box_office,json=boxOffice,proto3,oneof" json:"box_office,omitempty"`
+	ImdbId           *string                `protobuf:"bytes,16,opt,name=imdb_id,json=imdbId,proto3,oneof" json:"imdb_id,omitempty"`
+	TmdbId           *int32                 `protobuf:"varint,17,opt,name=tmdb_id,json=tmdbId,proto3,oneof" json:"tmdb_id,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MovieInput) Reset() {
+	*x = MovieInput{}
+	mi := &file_movie_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MovieInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MovieInput) ProtoMessage() {}
+
+func (x *MovieInput) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MovieInput.ProtoReflect.Descriptor instead.
+func (*MovieInput) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MovieInput) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *MovieInput) GetReleaseYear() int32 {
+	if x != nil {
+		return x.ReleaseYear
+	}
+	return 0
+}
+
+func (x *MovieInput) GetRating() float64 {
+	if x != nil && x.Rating != nil {
+		return *x.Rating
+	}
+	return 0
+}
+
+func (x *MovieInput) GetReview() string {
+	if x != nil && x.Review != nil {
+		return *x.Review
+	}
+	return ""
+}
+
+func (x *MovieInput) GetGenres() []string {
+	if x != nil {
+		return x.Genres
+	}
+	return nil
+}
+
+func (x *MovieInput) GetRuntime() int32 {
+	if x != nil && x.Runtime != nil {
+		return *x.Runtime
+	}
+	return 0
+}
+
+func (x *MovieInput) GetOriginalTitle() string {
+	if x != nil && x.OriginalTitle != nil {
+		return *x.OriginalTitle
+	}
+	return ""
+}
+
+func (x *MovieInput) GetOriginalLanguage() string {
+	if x != nil && x.OriginalLanguage != nil {
+		return *x.OriginalLanguage
+	}
+	return ""
+}
+
+func (x *MovieInput) GetCountries() []string {
+	if x != nil {
+		return x.Countries
+	}
+	return nil
+}
+
+func (x *MovieInput) GetMpaaRating() string {
+	if x != nil && x.MpaaRating != nil {
+		return *x.MpaaRating
+	}
+	return ""
+}
+
+func (x *MovieInput) GetCncRating() string {
+	if x != nil && x.CncRating != nil {
+		return *x.CncRating
+	}
+	return ""
+}
+
+func (x *MovieInput) GetSynopsis() string {
+	if x != nil && x.Synopsis != nil {
+		return *x.Synopsis
+	}
+	return ""
+}
+
+func (x *MovieInput) GetTagline() string {
+	if x != nil && x.Tagline != nil {
+		return *x.Tagline
+	}
+	return ""
+}
+
+func (x *MovieInput) GetBudget() int64 {
+	if x != nil && x.Budget != nil {
+		return *x.Budget
+	}
+	return 0
+}
+
+func (x *MovieInput) GetBoxOffice() int64 {
+	if x != nil && x.BoxOffice != nil {
+		return *x.BoxOffice
+	}
+	return 0
+}
+
+func (x *MovieInput) GetImdbId() string {
+	if x != nil && x.ImdbId != nil {
+		return *x.ImdbId
+	}
+	return ""
+}
+
+func (x *MovieInput) GetTmdbId() int32 {
+	if x != nil && x.TmdbId != nil {
+		return *x.TmdbId
+	}
+	return 0
+}
+
+type RatingSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int32                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Average       *float64               `protobuf:"fixed64,2,opt,name=average,proto3,oneof" json:"average,omitempty"`
+	WeightedScore *float64               `protobuf:"fixed64,3,opt,name=weighted_score,json=weightedScore,proto3,oneof" json:"weighted_score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RatingSummary) Reset() {
+	*x = RatingSummary{}
+	mi := &file_movie_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RatingSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RatingSummary) ProtoMessage() {}
+
+func (x *RatingSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RatingSummary.ProtoReflect.Descriptor instead.
+func (*RatingSummary) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RatingSummary) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *RatingSummary) GetAverage() float64 {
+	if x != nil && x.Average != nil {
+		return *x.Average
+	}
+	return 0
+}
+
+func (x *RatingSummary) GetWeightedScore() float64 {
+	if x != nil && x.WeightedScore != nil {
+		return *x.WeightedScore
+	}
+	return 0
+}
+
+// Filtres de GET /movies (sans les filtres personnels)
+type MovieFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	UpdatedSince  *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=updated_since,json=updatedSince,proto3" json:"updated_since,omitempty"`
+	Director      string                 `protobuf:"bytes,3,opt,name=director,proto3" json:"director,omitempty"`
+	Actor         string                 `protobuf:"bytes,4,opt,name=actor,proto3" json:"actor,omitempty"`
+	Language      string                 `protobuf:"bytes,5,opt,name=language,proto3" json:"language,omitempty"`
+	Country       string                 `protobuf:"bytes,6,opt,name=country,proto3" json:"country,omitempty"`
+	Certification string                 `protobuf:"bytes,7,opt,name=certification,proto3" json:"certification,omitempty"`
+	MinRuntime    *int32                 `protobuf:"varint,8,opt,name=min_runtime,json=minRuntime,proto3,oneof" json:"min_runtime,omitempty"`
+	MaxRuntime    *int32                 `protobuf:"varint,9,opt,name=max_runtime,json=maxRuntime,proto3,oneof" json:"max_runtime,omitempty"`
+	ImdbId        string                 `protobuf:"bytes,10,opt,name=imdb_id,json=imdbId,proto3" json:"imdb_id,omitempty"`
+	TmdbId        *int32                 `protobuf:"varint,11,opt,name=tmdb_id,json=tmdbId,proto3,oneof" json:"tmdb_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MovieFilter) Reset() {
+	*x = MovieFilter{}
+	mi := &file_movie_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MovieFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MovieFilter) ProtoMessage() {}
+
+func (x *MovieFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MovieFilter.ProtoReflect.Descriptor instead.
+func (*MovieFilter) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MovieFilter) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *MovieFilter) GetUpdatedSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedSince
+	}
+	return nil
+}
+
+func (x *MovieFilter) GetDirector() string {
+	if x != nil {
+		return x.Director
+	}
+	return ""
+}
+
+func (x *MovieFilter) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *MovieFilter) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *MovieFilter) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *MovieFilter) GetCertification() string {
+	if x != nil {
+		return x.Certification
+	}
+	return ""
+}
+
+func (x *MovieFilter) GetMinRuntime() int32 {
+	if x != nil && x.MinRuntime != nil {
+		return *x.MinRuntime
+	}
+	return 0
+}
+
+func (x *MovieFilter) GetMaxRuntime() int32 {
+	if x != nil && x.MaxRuntime != nil {
+		return *x.MaxRuntime
+	}
+	return 0
+}
+
+func (x *MovieFilter) GetImdbId() string {
+	if x != nil {
+		return x.ImdbId
+	}
+	return ""
+}
+
+func (x *MovieFilter) GetTmdbId() int32 {
+	if x != nil && x.TmdbId != nil {
+		return *x.TmdbId
+	}
+	return 0
+}
+
+type Metadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CurrentPage   int32                  `protobuf:"varint,1,opt,name=current_page,json=currentPage,proto3" json:"current_page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	FirstPage     int32                  `protobuf:"varint,3,opt,name=first_page,json=firstPage,proto3" json:"first_page,omitempty"`
+	LastPage      int32                  `protobuf:"varint,4,opt,name=last_page,json=lastPage,proto3" json:"last_page,omitempty"`
+	TotalRecords  int32                  `protobuf:"varint,5,opt,name=total_records,json=totalRecords,proto3" json:"total_records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	mi := &file_movie_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Metadata) GetCurrentPage() int32 {
+	if x != nil {
+		return x.CurrentPage
+	}
+	return 0
+}
+
+func (x *Metadata) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *Metadata) GetFirstPage() int32 {
+	if x != nil {
+		return x.FirstPage
+	}
+	return 0
+}
+
+func (x *Metadata) GetLastPage() int32 {
+	if x != nil {
+		return x.LastPage
+	}
+	return 0
+}
+
+func (x *Metadata) GetTotalRecords() int32 {
+	if x != nil {
+		return x.TotalRecords
+	}
+	return 0
+}
+
+type ListRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Filter   *MovieFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Page     int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Colonne de tri, préfixée par - pour l'ordre décroissant
+	Sort          string `protobuf:"bytes,4,opt,name=sort,proto3" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_movie_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListRequest) GetFilter() *MovieFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+type ListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movies        []*Movie               `protobuf:"bytes,1,rep,name=movies,proto3" json:"movies,omitempty"`
+	Metadata      *Metadata              `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_movie_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListResponse) GetMovies() []*Movie {
+	if x != nil {
+		return x.Movies
+	}
+	return nil
+}
+
+func (x *ListResponse) GetMetadata() *Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_movie_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movie         *MovieInput            `protobuf:"bytes,1,opt,name=movie,proto3" json:"movie,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRequest) Reset() {
+	*x = CreateRequest{}
+	mi := &file_movie_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRequest) ProtoMessage() {}
+
+func (x *CreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRequest.ProtoReflect.Descriptor instead.
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateRequest) GetMovie() *MovieInput {
+	if x != nil {
+		return x.Movie
+	}
+	return nil
+}
+
+type UpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Movie         *MovieInput            `protobuf:"bytes,2,opt,name=movie,proto3" json:"movie,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_movie_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateRequest) GetMovie() *MovieInput {
+	if x != nil {
+		return x.Movie
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_movie_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteRequest) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListAllRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *MovieFilter           `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Sort          string                 `protobuf:"bytes,2,opt,name=sort,proto3" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAllRequest) Reset() {
+	*x = ListAllRequest{}
+	mi := &file_movie_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAllRequest) ProtoMessage() {}
+
+func (x *ListAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_movie_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAllRequest.ProtoReflect.Descriptor instead.
+func (*ListAllRequest) Descriptor() ([]byte, []int) {
+	return file_movie_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListAllRequest) GetFilter() *MovieFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListAllRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+var File_movie_proto protoreflect.FileDescriptor
+
+const file_movie_proto_rawDesc = "" +
+	"\n" +
+	"\vmovie.proto\x12\vmovieapi.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xae\a\n" +
+	"\x05Movie\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12!\n" +
+	"\frelease_year\x18\x03 \x01(\x05R\vreleaseYear\x12\x1b\n" +
+	"\x06rating\x18\x04 \x01(\x01H\x00R\x06rating\x88\x01\x01\x12\x1b\n" +
+	"\x06review\x18\x05 \x01(\tH\x01R\x06review\x88\x01\x01\x12\x16\n" +
+	"\x06genres\x18\x06 \x03(\tR\x06genres\x12\x1d\n" +
+	"\aruntime\x18\a \x01(\x05H\x02R\aruntime\x88\x01\x01\x12*\n" +
+	"\x0eoriginal_title\x18\b \x01(\tH\x03R\roriginalTitle\x88\x01\x01\x120\n" +
+	"\x11original_language\x18\t \x01(\tH\x04R\x10originalLanguage\x88\x01\x01\x12\x1c\n" +
+	"\tcountries\x18\n" +
+	" \x03(\tR\tcountries\x12$\n" +
+	"\vmpaa_rating\x18\v \x01(\tH\x05R\n" +
+	"mpaaRating\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"cnc_rating\x18\f \x01(\tH\x06R\tcncRating\x88\x01\x01\x12\x1f\n" +
+	"\bsynopsis\x18\r \x01(\tH\aR\bsynopsis\x88\x01\x01\x12\x1d\n" +
+	"\atagline\x18\x0e \x01(\tH\bR\atagline\x88\x01\x01\x12\x1b\n" +
+	"\x06budget\x18\x0f \x01(\x03H\tR\x06budget\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"box_office\x18\x10 \x01(\x03H\n" +
+	"R\tboxOffice\x88\x01\x01\x12\x1c\n" +
+	"\aimdb_id\x18\x11 \x01(\tH\vR\x06imdbId\x88\x01\x01\x12\x1c\n" +
+	"\atmdb_id\x18\x12 \x01(\x05H\fR\x06tmdbId\x88\x01\x01\x12=\n" +
+	"\fuser_ratings\x18\x13 \x01(\v2\x1a.movieapi.v1.RatingSummaryR\vuserRatings\x129\n" +
+	"\n" +
+	"created_at\x18\x14 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x15 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\t\n" +
+	"\a_ratingB\t\n" +
+	"\a_reviewB\n" +
+	"\n" +
+	"\b_runtimeB\x11\n" +
+	"\x0f_original_titleB\x14\n" +
+	"\x12_original_languageB\x0e\n" +
+	"\f_mpaa_ratingB\r\n" +
+	"\v_cnc_ratingB\v\n" +
+	"\t_synopsisB\n" +
+	"\n" +
+	"\b_taglineB\t\n" +
+	"\a_budgetB\r\n" +
+	"\v_box_officeB\n" +
+	"\n" +
+	"\b_imdb_idB\n" +
+	"\n" +
+	"\b_tmdb_id\"\xee\x05\n" +
+	"\n" +
+	"MovieInput\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12!\n" +
+	"\frelease_year\x18\x02 \x01(\x05R\vreleaseYear\x12\x1b\n" +
+	"\x06rating\x18\x03 \x01(\x01H\x00R\x06rating\x88\x01\x01\x12\x1b\n" +
+	"\x06review\x18\x04 \x01(\tH\x01R\x06review\x88\x01\x01\x12\x16\n" +
+	"\x06genres\x18\x05 \x03(\tR\x06genres\x12\x1d\n" +
+	"\aruntime\x18\x06 \x01(\x05H\x02R\aruntime\x88\x01\x01\x12*\n" +
+	"\x0eoriginal_title\x18\a \x01(\tH\x03R\roriginalTitle\x88\x01\x01\x120\n" +
+	"\x11original_language\x18\b \x01(\tH\x04R\x10originalLanguage\x88\x01\x01\x12\x1c\n" +
+	"\tcountries\x18\t \x03(\tR\tcountries\x12$\n" +
+	"\vmpaa_rating\x18\n" +
+	" \x01(\tH\x05R\n" +
+	"mpaaRating\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"cnc_rating\x18\v \x01(\tH\x06R\tcncRating\x88\x01\x01\x12\x1f\n" +
+	"\bsynopsis\x18\f \x01(\tH\aR\bsynopsis\x88\x01\x01\x12\x1d\n" +
+	"\atagline\x18\r \x01(\tH\bR\atagline\x88\x01\x01\x12\x1b\n" +
+	"\x06budget\x18\x0e \x01(\x03H\tR\x06budget\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"box_office\x18\x0f \x01(\x03H\n" +
+	"R\tboxOffice\x88\x01\x01\x12\x1c\n" +
+	"\aimdb_id\x18\x10 \x01(\tH\vR\x06imdbId\x88\x01\x01\x12\x1c\n" +
+	"\atmdb_id\x18\x11 \x01(\x05H\fR\x06tmdbId\x88\x01\x01B\t\n" +
+	"\a_ratingB\t\n" +
+	"\a_reviewB\n" +
+	"\n" +
+	"\b_runtimeB\x11\n" +
+	"\x0f_original_titleB\x14\n" +
+	"\x12_original_languageB\x0e\n" +
+	"\f_mpaa_ratingB\r\n" +
+	"\v_cnc_ratingB\v\n" +
+	"\t_synopsisB\n" +
+	"\n" +
+	"\b_taglineB\t\n" +
+	"\a_budgetB\r\n" +
+	"\v_box_officeB\n" +
+	"\n" +
+	"\b_imdb_idB\n" +
+	"\n" +
+	"\b_tmdb_id\"\x8f\x01\n" +
+	"\rRatingSummary\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x05R\x05count\x12\x1d\n" +
+	"\aaverage\x18\x02 \x01(\x01H\x00R\aaverage\x88\x01\x01\x12*\n" +
+	"\x0eweighted_score\x18\x03 \x01(\x01H\x01R\rweightedScore\x88\x01\x01B\n" +
+	"\n" +
+	"\b_averageB\x11\n" +
+	"\x0f_weighted_score\"\xa1\x03\n" +
+	"\vMovieFilter\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12?\n" +
+	"\rupdated_since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\fupdatedSince\x12\x1a\n" +
+	"\bdirector\x18\x03 \x01(\tR\bdirector\x12\x14\n" +
+	"\x05actor\x18\x04 \x01(\tR\x05actor\x12\x1a\n" +
+	"\blanguage\x18\x05 \x01(\tR\blanguage\x12\x18\n" +
+	"\acountry\x18\x06 \x01(\tR\acountry\x12$\n" +
+	"\rcertification\x18\a \x01(\tR\rcertification\x12$\n" +
+	"\vmin_runtime\x18\b \x01(\x05H\x00R\n" +
+	"minRuntime\x88\x01\x01\x12$\n" +
+	"\vmax_runtime\x18\t \x01(\x05H\x01R\n" +
+	"maxRuntime\x88\x01\x01\x12\x17\n" +
+	"\aimdb_id\x18\n" +
+	" \x01(\tR\x06imdbId\x12\x1c\n" +
+	"\atmdb_id\x18\v \x01(\x05H\x02R\x06tmdbId\x88\x01\x01B\x0e\n" +
+	"\f_min_runtimeB\x0e\n" +
+	"\f_max_runtimeB\n" +
+	"\n" +
+	"\b_tmdb_id\"\xab\x01\n" +
+	"\bMetadata\x12!\n" +
+	"\fcurrent_page\x18\x01 \x01(\x05R\vcurrentPage\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"first_page\x18\x03 \x01(\x05R\tfirstPage\x12\x1b\n" +
+	"\tlast_page\x18\x04 \x01(\x05R\blastPage\x12#\n" +
+	"\rtotal_records\x18\x05 \x01(\x05R\ftotalRecords\"\x84\x01\n" +
+	"\vListRequest\x120\n" +
+	"\x06filter\x18\x01 \x01(\v2\x18.movieapi.v1.MovieFilterR\x06filter\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x12\n" +
+	"\x04sort\x18\x04 \x01(\tR\x04sort\"m\n" +
+	"\fListResponse\x12*\n" +
+	"\x06movies\x18\x01 \x03(\v2\x12.movieapi.v1.MovieR\x06movies\x121\n" +
+	"\bmetadata\x18\x02 \x01(\v2\x15.movieapi.v1.MetadataR\bmetadata\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\">\n" +
+	"\rCreateRequest\x12-\n" +
+	"\x05movie\x18\x01 \x01(\v2\x17.movieapi.v1.MovieInputR\x05movie\"N\n" +
+	"\rUpdateRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12-\n" +
+	"\x05movie\x18\x02 \x01(\v2\x17.movieapi.v1.MovieInputR\x05movie\"\x1f\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"V\n" +
+	"\x0eListAllRequest\x120\n" +
+	"\x06filter\x18\x01 \x01(\v2\x18.movieapi.v1.MovieFilterR\x06filter\x12\x12\n" +
+	"\x04sort\x18\x02 \x01(\tR\x04sort2\xef\x02\n" +
+	"\fMovieService\x12;\n" +
+	"\x04List\x12\x18.movieapi.v1.ListRequest\x1a\x19.movieapi.v1.ListResponse\x122\n" +
+	"\x03Get\x12\x17.movieapi.v1.GetRequest\x1a\x12.movieapi.v1.Movie\x128\n" +
+	"\x06Create\x12\x1a.movieapi.v1.CreateRequest\x1a\x12.movieapi.v1.Movie\x128\n" +
+	"\x06Update\x12\x1a.movieapi.v1.UpdateRequest\x1a\x12.movieapi.v1.Movie\x12<\n" +
+	"\x06Delete\x12\x1a.movieapi.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\x12<\n" +
+	"\aListAll\x12\x1b.movieapi.v1.ListAllRequest\x1a\x12.movieapi.v1.Movie0\x01B7Z5github.com/vfaust1/movie-api/internal/moviepb;moviepbb\x06proto3"
+
+var (
+	file_movie_proto_rawDescOnce sync.Once
+	file_movie_proto_rawDescData []byte
+)
+
+func file_movie_proto_rawDescGZIP() []byte {
+	file_movie_proto_rawDescOnce.Do(func() {
+		file_movie_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_movie_proto_rawDesc), len(file_movie_proto_rawDesc)))
+	})
+	return file_movie_proto_rawDescData
+}
+
+var file_movie_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_movie_proto_goTypes = []any{
+	(*Movie)(nil),                 // 0: movieapi.v1.Movie
+	(*MovieInput)(nil),            // 1: movieapi.v1.MovieInput
+	(*RatingSummary)(nil),         // 2: movieapi.v1.RatingSummary
+	(*MovieFilter)(nil),           // 3: movieapi.v1.MovieFilter
+	(*Metadata)(nil),              // 4: movieapi.v1.Metadata
+	(*ListRequest)(nil),           // 5: movieapi.v1.ListRequest
+	(*ListResponse)(nil),          // 6: movieapi.v1.ListResponse
+	(*GetRequest)(nil),            // 7: movieapi.v1.GetRequest
+	(*CreateRequest)(nil),         // 8: movieapi.v1.CreateRequest
+	(*UpdateRequest)(nil),         // 9: movieapi.v1.UpdateRequest
+	(*DeleteRequest)(nil),         // 10: movieapi.v1.DeleteRequest
+	(*ListAllRequest)(nil),        // 11: movieapi.v1.ListAllRequest
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 13: google.protobuf.Empty
+}
+var file_movie_proto_depIdxs = []int32{
+	2,  // 0: movieapi.v1.Movie.user_ratings:type_name -> movieapi.v1.RatingSummary
+	12, // 1: movieapi.v1.Movie.created_at:type_name -> google.protobuf.Timestamp
+	12, // 2: movieapi.v1.Movie.updated_at:type_name -> google.protobuf.Timestamp
+	12, // 3: movieapi.v1.MovieFilter.updated_since:type_name -> google.protobuf.Timestamp
+	3,  // 4: movieapi.v1.ListRequest.filter:type_name -> movieapi.v1.MovieFilter
+	0,  // 5: movieapi.v1.ListResponse.movies:type_name -> movieapi.v1.Movie
+	4,  // 6: movieapi.v1.ListResponse.metadata:type_name -> movieapi.v1.Metadata
+	1,  // 7: movieapi.v1.CreateRequest.movie:type_name -> movieapi.v1.MovieInput
+	1,  // 8: movieapi.v1.UpdateRequest.movie:type_name -> movieapi.v1.MovieInput
+	3,  // 9: movieapi.v1.ListAllRequest.filter:type_name -> movieapi.v1.MovieFilter
+	5,  // 10: movieapi.v1.MovieService.List:input_type -> movieapi.v1.ListRequest
+	7,  // 11: movieapi.v1.MovieService.Get:input_type -> movieapi.v1.GetRequest
+	8,  // 12: movieapi.v1.MovieService.Create:input_type -> movieapi.v1.CreateRequest
+	9,  // 13: movieapi.v1.MovieService.Update:input_type -> movieapi.v1.UpdateRequest
+	10, // 14: movieapi.v1.MovieService.Delete:input_type -> movieapi.v1.DeleteRequest
+	11, // 15: movieapi.v1.MovieService.ListAll:input_type -> movieapi.v1.ListAllRequest
+	6,  // 16: movieapi.v1.MovieService.List:output_type -> movieapi.v1.ListResponse
+	0,  // 17: movieapi.v1.MovieService.Get:output_type -> movieapi.v1.Movie
+	0,  // 18: movieapi.v1.MovieService.Create:output_type -> movieapi.v1.Movie
+	0,  // 19: movieapi.v1.MovieService.Update:output_type -> movieapi.v1.Movie
+	13, // 20: movieapi.v1.MovieService.Delete:output_type -> google.protobuf.Empty
+	0,  // 21: movieapi.v1.MovieService.ListAll:output_type -> movieapi.v1.Movie
+	16, // [16:22] is the sub-list for method output_type
+	10, // [10:16] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_movie_proto_init() }
+func file_movie_proto_init() {
+	if File_movie_proto != nil {
+		return
+	}
+	file_movie_proto_msgTypes[0].OneofWrappers = []any{}
+	file_movie_proto_msgTypes[1].OneofWrappers = []any{}
+	file_movie_proto_msgTypes[2].OneofWrappers = []any{}
+	file_movie_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_movie_proto_rawDesc), len(file_movie_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_movie_proto_goTypes,
+		DependencyIndexes: file_movie_proto_depIdxs,
+		MessageInfos:      file_movie_proto_msgTypes,
+	}.Build()
+	File_movie_proto = out.File
+	file_movie_proto_goTypes = nil
+	file_movie_proto_depIdxs = nil
+}
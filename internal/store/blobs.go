@@ -0,0 +1,126 @@
+package store
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBlobNotFound = errors.New("blob not found")
+	ErrInvalidKey   = errors.New("invalid blob key")
+)
+
+// Stockage des fichiers (affiches...). Les clés sont des chemins relatifs
+// séparés par des "/", ex: "posters/42/3f2a.../w342.jpg". Leur extension
+// donne le type de contenu.
+type BlobStore interface {
+	Put(key string, data []byte) error
+	// Renvoie ErrBlobNotFound si la clé n'existe pas
+	Get(key string) (Blob, error)
+	// Supprime toutes les clés qui commencent par prefix + "/"
+	DeletePrefix(prefix string) error
+}
+
+// Fichier lu depuis un BlobStore, à fermer après lecture
+type Blob struct {
+	Content     io.ReadSeekCloser
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// BlobStore sur le disque local, sous le dossier Dir
+type LocalBlobStore struct {
+	Dir string
+}
+
+func NewLocalBlobStore(dir string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalBlobStore{Dir: dir}, nil
+}
+
+// Écrit d'abord dans un fichier temporaire puis le renomme,
+// pour qu'une lecture ne voie jamais un fichier à moitié écrit.
+func (s *LocalBlobStore) Put(key string, data []byte) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *LocalBlobStore) Get(key string) (Blob, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return Blob{}, err
+	}
+
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return Blob{}, ErrBlobNotFound
+	}
+	if err != nil {
+		return Blob{}, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return Blob{}, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return Blob{}, ErrBlobNotFound
+	}
+
+	return Blob{
+		Content:     f,
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+func (s *LocalBlobStore) DeletePrefix(prefix string) error {
+	name, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(name)
+}
+
+// Chemin du fichier de la clé, qui doit rester sous Dir
+func (s *LocalBlobStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(clean[1:])), nil
+}
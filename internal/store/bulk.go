@@ -0,0 +1,407 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// Nombre de films insérés par requête multi-lignes
+const bulkInsertChunkSize = 1000
+
+var (
+	ErrInvalidBulkOp = errors.New("op must be one of create, update, delete")
+	ErrBulkAborted   = errors.New("bulk operation aborted, nothing was applied")
+)
+
+// Une opération d'un lot : création, mise à jour ou suppression d'un film
+type BulkOperation struct {
+	Op    string `json:"op"`
+	ID    int    `json:"id,omitempty"`
+	Movie Movie  `json:"movie"`
+}
+
+// Résultat d'une opération, dans le même ordre que les opérations envoyées.
+// Err vaut nil si l'opération a été appliquée.
+type BulkResult struct {
+	ID    int
+	Movie *Movie
+	Err   error
+}
+
+// Applique un lot d'opérations, dans l'ordre où elles sont envoyées.
+// En mode atomique, tout est fait dans une seule transaction :
+// à la première erreur rien n'est enregistré et ErrBulkAborted est renvoyée.
+// Sinon chaque opération est isolée par un savepoint et les erreurs
+// sont seulement reportées dans les résultats.
+// Les créations consécutives sont insérées ensemble, par paquets.
+func (m MovieModel) BulkApply(ops []BulkOperation, atomic bool) ([]BulkResult, error) {
+	results := make([]BulkResult, len(ops))
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	genreIDs, err := loadGenreIDs(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		switch op.Op {
+		case BulkCreate:
+			results[i].Err = checkGenres(genreIDs, op.Movie.Genres)
+		case BulkUpdate, BulkDelete:
+			results[i].ID = op.ID
+		default:
+			results[i].Err = ErrInvalidBulkOp
+		}
+	}
+
+	if atomic && firstError(results) >= 0 {
+		return results, ErrBulkAborted
+	}
+
+	// Événements des opérations appliquées, écrits en une fois avant le commit
+	var events []movieEvent
+
+	for _, step := range bulkSteps(ops, results) {
+		if ops[step[0]].Op == BulkCreate {
+			created, err := createMovies(tx, genreIDs, ops, results, step, atomic)
+			if errors.Is(err, ErrBulkAborted) {
+				return results, err
+			}
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, created...)
+			continue
+		}
+
+		i := step[0]
+		op := ops[i]
+		var event movieEvent
+		err := withSavepoint(tx, func() error {
+			var err error
+			if op.Op == BulkDelete {
+				event, err = deleteMovie(tx, op.ID)
+				return err
+			}
+			movie := op.Movie
+			movie.ID = op.ID
+			event = movieEvent{Type: EventMovieUpdated, MovieID: op.ID}
+			return updateMovie(tx, movie)
+		})
+		if err != nil {
+			results[i].Err = err
+			if atomic {
+				return results, ErrBulkAborted
+			}
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if err := recordMovieEvents(tx, events...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Étapes d'un lot, dans l'ordre des opérations : chaque mise à jour ou
+// suppression seule, les créations qui se suivent ensemble pour être
+// insérées par paquets. Les opérations déjà en erreur sont sautées.
+func bulkSteps(ops []BulkOperation, results []BulkResult) [][]int {
+	var steps [][]int
+	for i, op := range ops {
+		if results[i].Err != nil {
+			continue
+		}
+		if last := len(steps) - 1; op.Op == BulkCreate && last >= 0 && ops[steps[last][0]].Op == BulkCreate {
+			steps[last] = append(steps[last], i)
+			continue
+		}
+		steps = append(steps, []int{i})
+	}
+	return steps
+}
+
+// Crée les films des opérations désignées par creates, qui se suivent dans
+// le lot : les doublons sont écartés d'après l'état laissé par les opérations
+// précédentes, les autres insérés par paquets. Renvoie les événements des
+// films créés, ou ErrBulkAborted en mode atomique si l'un d'eux échoue.
+func createMovies(tx *sql.Tx, genreIDs map[string]int, ops []BulkOperation, results []BulkResult, creates []int, atomic bool) ([]movieEvent, error) {
+	requested := len(creates)
+	creates, err := excludeDuplicates(tx, ops, results, creates)
+	if err != nil {
+		return nil, err
+	}
+	if atomic && len(creates) < requested {
+		return nil, ErrBulkAborted
+	}
+
+	for start := 0; start < len(creates); start += bulkInsertChunkSize {
+		end := min(start+bulkInsertChunkSize, len(creates))
+		chunk := creates[start:end]
+
+		err := withSavepoint(tx, func() error {
+			return insertMovies(tx, genreIDs, ops, results, chunk)
+		})
+		if err == nil {
+			continue
+		}
+		if atomic {
+			for _, i := range chunk {
+				results[i].Err = err
+			}
+			return nil, ErrBulkAborted
+		}
+
+		// Le paquet a échoué : on réessaie film par film
+		// pour ne perdre que les lignes fautives
+		for _, i := range chunk {
+			results[i].Err = withSavepoint(tx, func() error {
+				return insertMovies(tx, genreIDs, ops, results, []int{i})
+			})
+		}
+	}
+
+	var events []movieEvent
+	for _, i := range creates {
+		if results[i].Err == nil {
+			events = append(events, movieEvent{Type: EventMovieCreated, MovieID: results[i].ID})
+		}
+	}
+	return events, nil
+}
+
+// Insère les films des opérations désignées par indexes avec une seule
+// requête multi-lignes, puis leurs genres. Les ids sont réservés à l'avance
+// pour relier chaque ligne à son opération.
+func insertMovies(tx *sql.Tx, genreIDs map[string]int, ops []BulkOperation, results []BulkResult, indexes []int) error {
+	queryIDs := "SELECT nextval(pg_get_serial_sequence('movies', 'id')) FROM generate_series(1, $1)"
+
+	rows, err := tx.Query(queryIDs, len(indexes))
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int64, 0, len(indexes))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	titles := make([]string, len(indexes))
+	years := make([]int64, len(indexes))
+	ratings := make([]*float64, len(indexes))
+	reviews := make([]*string, len(indexes))
+	var linkMovies, linkGenres []int64
+
+	// Une colonne par métadonnée ; les pays sont joints par des virgules,
+	// unnest ne sachant pas produire un tableau par ligne
+	size := len(indexes)
+	runtimes, tmdbIDs := make([]*int, size), make([]*int, size)
+	budgets, boxOffices := make([]*int64, size), make([]*int64, size)
+	countries := make([]string, size)
+	originalTitles, languages := make([]*string, size), make([]*string, size)
+	mpaaRatings, cncRatings := make([]*string, size), make([]*string, size)
+	synopses, taglines, imdbIDs := make([]*string, size), make([]*string, size), make([]*string, size)
+
+	for n, i := range indexes {
+		movie := ops[i].Movie
+		titles[n] = movie.Title
+		years[n] = int64(movie.ReleaseYear)
+		ratings[n] = movie.Rating
+		reviews[n] = movie.Review
+		runtimes[n], originalTitles[n], languages[n] = movie.Runtime, movie.OriginalTitle, movie.OriginalLanguage
+		countries[n] = strings.Join(movie.Countries, ",")
+		mpaaRatings[n], cncRatings[n] = movie.MPAARating, movie.CNCRating
+		synopses[n], taglines[n] = movie.Synopsis, movie.Tagline
+		budgets[n], boxOffices[n] = movie.Budget, movie.BoxOffice
+		imdbIDs[n], tmdbIDs[n] = movie.IMDbID, movie.TMDBID
+
+		for _, name := range movie.Genres {
+			linkMovies = append(linkMovies, ids[n])
+			linkGenres = append(linkGenres, int64(genreIDs[name]))
+		}
+	}
+
+	queryMovies := `
+		INSERT INTO movies (id, title, release_year, rating, review, ` + metadataColumns("") + `)
+		SELECT id, title, release_year, rating, review, runtime, original_title, original_language,
+			string_to_array(countries, ','), mpaa_rating, cnc_rating, synopsis, tagline, budget, box_office, imdb_id, tmdb_id
+		FROM unnest($1::int[], $2::text[], $3::int[], $4::real[], $5::text[],
+			$6::int[], $7::text[], $8::text[], $9::text[], $10::text[], $11::text[],
+			$12::text[], $13::text[], $14::bigint[], $15::bigint[], $16::text[], $17::int[])
+			AS t(id, title, release_year, rating, review, runtime, original_title, original_language,
+				countries, mpaa_rating, cnc_rating, synopsis, tagline, budget, box_office, imdb_id, tmdb_id)
+		RETURNING id, created_at, updated_at`
+
+	rows, err = tx.Query(queryMovies, ids, titles, years, ratings, reviews,
+		runtimes, originalTitles, languages, countries, mpaaRatings, cncRatings,
+		synopses, taglines, budgets, boxOffices, imdbIDs, tmdbIDs)
+	if err != nil {
+		return translateDuplicate(err)
+	}
+
+	inserted := make(map[int]Movie, len(indexes))
+	for rows.Next() {
+		var row Movie
+		if err := rows.Scan(&row.ID, &row.CreatedAt, &row.UpdatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		inserted[row.ID] = row
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return translateDuplicate(err)
+	}
+
+	if len(linkMovies) > 0 {
+		queryLinks := `
+			INSERT INTO movie_genres (movie_id, genre_id)
+			SELECT * FROM unnest($1::int[], $2::int[])
+			ON CONFLICT DO NOTHING`
+
+		if _, err := tx.Exec(queryLinks, linkMovies, linkGenres); err != nil {
+			return err
+		}
+	}
+
+	for n, i := range indexes {
+		movie := ops[i].Movie
+		movie.ID = int(ids[n])
+		movie.CreatedAt = inserted[movie.ID].CreatedAt
+		movie.UpdatedAt = inserted[movie.ID].UpdatedAt
+		results[i] = BulkResult{ID: movie.ID, Movie: &movie}
+	}
+
+	return nil
+}
+
+// Vérifie en une requête les créations qui doublonnent un film existant,
+// reporte l'erreur dans leur résultat et renvoie les créations restantes.
+// Les doublons à l'intérieur du lot sont bloqués par l'index unique.
+func excludeDuplicates(tx *sql.Tx, ops []BulkOperation, results []BulkResult, creates []int) ([]int, error) {
+	if len(creates) == 0 {
+		return creates, nil
+	}
+
+	titles := make([]string, len(creates))
+	years := make([]int64, len(creates))
+	for n, i := range creates {
+		titles[n] = ops[i].Movie.Title
+		years[n] = int64(ops[i].Movie.ReleaseYear)
+	}
+
+	query := `
+		SELECT k.idx, m.id
+		FROM unnest($1::text[], $2::int[]) WITH ORDINALITY AS k(title, release_year, idx)
+		JOIN movies m ON m.title_key = movie_title_key(k.title) AND m.release_year = k.release_year`
+
+	rows, err := tx.Query(query, titles, years)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var idx, existingID int
+		if err := rows.Scan(&idx, &existingID); err != nil {
+			return nil, err
+		}
+		results[creates[idx-1]].Err = &DuplicateMovieError{ExistingID: existingID}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	remaining := creates[:0]
+	for _, i := range creates {
+		if results[i].Err == nil {
+			remaining = append(remaining, i)
+		}
+	}
+
+	return remaining, nil
+}
+
+// Exécute fn dans un savepoint : en cas d'erreur seules
+// les modifications de fn sont annulées, la transaction reste utilisable
+func withSavepoint(tx *sql.Tx, fn func() error) error {
+	if _, err := tx.Exec("SAVEPOINT bulk_item"); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, rbErr := tx.Exec("ROLLBACK TO SAVEPOINT bulk_item"); rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+
+	_, err := tx.Exec("RELEASE SAVEPOINT bulk_item")
+	return err
+}
+
+func loadGenreIDs(q dbtx) (map[string]int, error) {
+	rows, err := q.Query("SELECT id, name FROM genres")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genreIDs := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		genreIDs[name] = id
+	}
+
+	return genreIDs, rows.Err()
+}
+
+func checkGenres(genreIDs map[string]int, genres []string) error {
+	for _, name := range genres {
+		if _, ok := genreIDs[name]; !ok {
+			return fmt.Errorf("%w: '%s'", ErrGenreNotFound, name)
+		}
+	}
+	return nil
+}
+
+func firstError(results []BulkResult) int {
+	for i, res := range results {
+		if res.Err != nil {
+			return i
+		}
+	}
+	return -1
+}
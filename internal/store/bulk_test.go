@@ -0,0 +1,65 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBulkSteps(t *testing.T) {
+	create := func(title string) BulkOperation {
+		return BulkOperation{Op: BulkCreate, Movie: Movie{Title: title, ReleaseYear: 2010}}
+	}
+	update := func(id int, title string) BulkOperation {
+		return BulkOperation{Op: BulkUpdate, ID: id, Movie: Movie{Title: title, ReleaseYear: 2010}}
+	}
+	remove := func(id int) BulkOperation { return BulkOperation{Op: BulkDelete, ID: id} }
+
+	tests := []struct {
+		name   string
+		ops    []BulkOperation
+		failed []int // opérations déjà en erreur (op ou genre invalide)
+		want   [][]int
+	}{
+		{
+			name: "Créations groupées",
+			ops:  []BulkOperation{create("A"), create("B"), create("C")},
+			want: [][]int{{0, 1, 2}},
+		},
+		{
+			// Le film 5 doit être supprimé avant que X soit créé
+			name: "Création après une suppression",
+			ops:  []BulkOperation{remove(5), create("X")},
+			want: [][]int{{0}, {1}},
+		},
+		{
+			// Le titre A est libéré par la mise à jour avant d'être repris
+			name: "Création après une mise à jour qui libère son titre",
+			ops:  []BulkOperation{update(7, "A"), create("A")},
+			want: [][]int{{0}, {1}},
+		},
+		{
+			name: "Créations séparées par une mise à jour",
+			ops:  []BulkOperation{create("A"), create("B"), update(7, "C"), create("D")},
+			want: [][]int{{0, 1}, {2}, {3}},
+		},
+		{
+			name:   "Opérations en erreur sautées",
+			ops:    []BulkOperation{create("A"), {Op: "upsert"}, create("B"), remove(3)},
+			failed: []int{1},
+			want:   [][]int{{0, 2}, {3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make([]BulkResult, len(tt.ops))
+			for _, i := range tt.failed {
+				results[i].Err = ErrInvalidBulkOp
+			}
+
+			if got := bulkSteps(tt.ops, results); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bulkSteps() = %v, attendu %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package store
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache clé / valeur à durée de vie. Les valeurs sont des octets pour qu'un
+// cache partagé entre instances (Redis, memcached...) puisse remplacer
+// le cache en mémoire sans changer les décorateurs.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	// Vide tout le cache
+	Purge()
+}
+
+// Cache en mémoire limité en nombre d'entrées : au-delà, l'entrée
+// utilisée le moins récemment est retirée
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // de la plus récente à la plus ancienne
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *LRUCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.items, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (c *LRUCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// Nombre d'entrées, expirées comprises tant qu'elles n'ont pas été relues
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Compteurs du cache, exposés pour le suivi
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// Décorateur de MovieRepository qui met en cache GetMoviebyID, GetMovieFields et GetMovies
+// (hors filtres personnels). Toute écriture passant par lui vide le cache ;
+// Invalidate permet aussi de le vider quand le catalogue est modifié ailleurs
+// (autre instance, affiches, traductions). Les notes des utilisateurs
+// ne le vident pas : elles peuvent avoir jusqu'à ttl de retard.
+type CachedMovieRepository struct {
+	MovieRepository
+	cache Cache
+	ttl   time.Duration
+	// Incrémentée à chaque invalidation et incluse dans les clés : une lecture
+	// commencée avant une écriture ne peut pas remettre en cache une valeur périmée
+	generation atomic.Int64
+	hits       atomic.Int64
+	misses     atomic.Int64
+}
+
+func NewCachedMovieRepository(repo MovieRepository, cache Cache, ttl time.Duration) *CachedMovieRepository {
+	return &CachedMovieRepository{MovieRepository: repo, cache: cache, ttl: ttl}
+}
+
+func (r *CachedMovieRepository) Invalidate() {
+	r.generation.Add(1)
+	r.cache.Purge()
+}
+
+func (r *CachedMovieRepository) Stats() CacheStats {
+	return CacheStats{Hits: r.hits.Load(), Misses: r.misses.Load()}
+}
+
+// Lit la valeur en cache, ou la charge avec load puis la met en cache.
+// Les valeurs sont gardées en JSON, décodées à chaque lecture :
+// l'appelant peut modifier ce qu'il reçoit (traductions) sans toucher au cache.
+func cached[T any](r *CachedMovieRepository, key string, load func() (T, error)) (T, error) {
+	key = fmt.Sprintf("%d:%s", r.generation.Load(), key)
+
+	var value T
+	if data, ok := r.cache.Get(key); ok {
+		if err := json.Unmarshal(data, &value); err == nil {
+			r.hits.Add(1)
+			return value, nil
+		}
+	}
+	r.misses.Add(1)
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	if data, err := json.Marshal(value); err == nil {
+		r.cache.Set(key, data, r.ttl)
+	}
+	return value, nil
+}
+
+func (r *CachedMovieRepository) GetMoviebyID(id int) (Movie, error) {
+	return cached(r, fmt.Sprintf("movie:%d", id), func() (Movie, error) {
+		return r.MovieRepository.GetMoviebyID(id)
+	})
+}
+
+func (r *CachedMovieRepository) GetMovieFields(id int, fields []string) (Movie, error) {
+	return cached(r, fmt.Sprintf("movie:%d:%s", id, strings.Join(fields, ",")), func() (Movie, error) {
+		return r.MovieRepository.GetMovieFields(id, fields)
+	})
+}
+
+// Comme les films, vidée à chaque modification du catalogue
+func (r *CachedMovieRepository) LastModified() (time.Time, error) {
+	return cached(r, "last_modified", r.MovieRepository.LastModified)
+}
+
+// Une page de films et ses métadonnées, mises en cache ensemble
+type moviePage struct {
+	Movies   []Movie  `json:"movies"`
+	Metadata Metadata `json:"metadata"`
+}
+
+func (r *CachedMovieRepository) GetMovies(title string, filters Filters) ([]Movie, Metadata, error) {
+	// Les filtres personnels dépendent de l'utilisateur et changent souvent
+	if filters.UserID != 0 {
+		return r.MovieRepository.GetMovies(title, filters)
+	}
+
+	params, err := json.Marshal(struct {
+		Title   string
+		Filters Filters
+	}{title, filters})
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	page, err := cached(r, "movies:"+string(params), func() (moviePage, error) {
+		movies, metadata, err := r.MovieRepository.GetMovies(title, filters)
+		return moviePage{movies, metadata}, err
+	})
+	return page.Movies, page.Metadata, err
+}
+
+func (r *CachedMovieRepository) AddMovie(movie Movie) (Movie, error) {
+	defer r.Invalidate()
+	return r.MovieRepository.AddMovie(movie)
+}
+
+func (r *CachedMovieRepository) UpdateMovie(movie Movie) error {
+	defer r.Invalidate()
+	return r.MovieRepository.UpdateMovie(movie)
+}
+
+func (r *CachedMovieRepository) DeleteMovie(id int) error {
+	defer r.Invalidate()
+	return r.MovieRepository.DeleteMovie(id)
+}
+
+func (r *CachedMovieRepository) BulkApply(ops []BulkOperation, atomic bool) ([]BulkResult, error) {
+	defer r.Invalidate()
+	return r.MovieRepository.BulkApply(ops, atomic)
+}
+
+func (r *CachedMovieRepository) MergeMovies(targetID, duplicateID int) (Movie, error) {
+	defer r.Invalidate()
+	return r.MovieRepository.MergeMovies(targetID, duplicateID)
+}
+
+func (r *CachedMovieRepository) SetCredits(movieID int, credits []Credit) ([]Credit, error) {
+	defer r.Invalidate()
+	return r.MovieRepository.SetCredits(movieID, credits)
+}
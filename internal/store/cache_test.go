@@ -0,0 +1,89 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	cache := NewLRUCache(2)
+
+	cache.Set("a", []byte("1"), time.Minute)
+	cache.Set("b", []byte("2"), time.Minute)
+	// a devient la plus récente : c'est b qui est retirée
+	cache.Get("a")
+	cache.Set("c", []byte("3"), time.Minute)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b aurait dû être retirée du cache")
+	}
+	if v, ok := cache.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("Get(a) = %q, %v", v, ok)
+	}
+
+	cache.Set("d", []byte("4"), -time.Second)
+	if _, ok := cache.Get("d"); ok {
+		t.Error("d a expiré et ne devrait plus être lue")
+	}
+
+	cache.Purge()
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d après Purge", cache.Len())
+	}
+}
+
+// Dépôt qui compte les lectures faites en base
+type countingMovieRepository struct {
+	MovieRepository
+	reads int
+}
+
+func (r *countingMovieRepository) GetMoviebyID(id int) (Movie, error) {
+	r.reads++
+	return Movie{ID: id, Title: fmt.Sprintf("Film %d", r.reads)}, nil
+}
+
+func (r *countingMovieRepository) GetMovies(title string, filters Filters) ([]Movie, Metadata, error) {
+	r.reads++
+	return []Movie{{ID: 1, Title: "Film"}}, Metadata{TotalRecords: 1}, nil
+}
+
+func (r *countingMovieRepository) UpdateMovie(movie Movie) error { return nil }
+
+func TestCachedMovieRepository(t *testing.T) {
+	db := &countingMovieRepository{}
+	repo := NewCachedMovieRepository(db, NewLRUCache(10), time.Minute)
+
+	first, _ := repo.GetMoviebyID(1)
+	// Modifier le film reçu ne doit pas toucher au cache
+	first.Title = "Modifié"
+	second, _ := repo.GetMoviebyID(1)
+	if db.reads != 1 || second.Title != "Film 1" {
+		t.Errorf("%d lectures, titre %q", db.reads, second.Title)
+	}
+
+	repo.GetMovies("", Filters{Page: 1, PageSize: 20})
+	repo.GetMovies("", Filters{Page: 1, PageSize: 20})
+	repo.GetMovies("", Filters{Page: 2, PageSize: 20})
+	if db.reads != 3 {
+		t.Errorf("%d lectures, attendu 3", db.reads)
+	}
+
+	// Les filtres personnels ne sont jamais mis en cache
+	repo.GetMovies("", Filters{Page: 1, PageSize: 20, UserID: 5})
+	repo.GetMovies("", Filters{Page: 1, PageSize: 20, UserID: 5})
+	if db.reads != 5 {
+		t.Errorf("%d lectures, attendu 5", db.reads)
+	}
+
+	// Une écriture vide le cache
+	repo.UpdateMovie(Movie{ID: 1})
+	if movie, _ := repo.GetMoviebyID(1); movie.Title != "Film 6" {
+		t.Errorf("Après UpdateMovie, titre %q, attendu une nouvelle lecture", movie.Title)
+	}
+
+	if stats := repo.Stats(); stats.Hits != 2 || stats.Misses != 4 {
+		t.Errorf("Stats() = %+v, attendu 2 hits et 4 misses", stats)
+	}
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	CreditDirector = "director"
+	CreditWriter   = "writer"
+	CreditActor    = "actor"
+	CreditComposer = "composer"
+)
+
+var ErrPersonNotFound = errors.New("person not found")
+
+// Ordre d'affichage du générique : réalisation, scénario,
+// acteurs par ordre d'apparition, puis musique
+const creditRoleOrder = `array_position(ARRAY['director', 'writer', 'actor', 'composer'], c.role),
+		c.billing_order NULLS LAST`
+
+// Participation d'une personne à un film.
+// Character et BillingOrder ne concernent que les acteurs.
+type Credit struct {
+	PersonID     int     `json:"person_id"`
+	Name         string  `json:"name,omitempty"`
+	Role         string  `json:"role"`
+	Character    *string `json:"character,omitempty"`
+	BillingOrder *int    `json:"billing_order,omitempty"`
+}
+
+// Remplace le générique d'un film et renvoie le nouveau générique,
+// ou sql.ErrNoRows si le film n'existe pas.
+func (m MovieModel) SetCredits(movieID int, credits []Credit) ([]Credit, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	if err := tx.QueryRow("SELECT id FROM movies WHERE id = $1 FOR UPDATE", movieID).Scan(&id); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM movie_credits WHERE movie_id = $1", movieID); err != nil {
+		return nil, err
+	}
+
+	queryInsert := `
+		INSERT INTO movie_credits (movie_id, person_id, role, character_name, billing_order)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING`
+
+	for _, c := range credits {
+		_, err := tx.Exec(queryInsert, movieID, c.PersonID, c.Role, c.Character, c.BillingOrder)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return nil, fmt.Errorf("%w: %d", ErrPersonNotFound, c.PersonID)
+			}
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE movies SET updated_at = NOW() WHERE id = $1", movieID); err != nil {
+		return nil, err
+	}
+
+	saved, err := getMovieCredits(tx, movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordMovieEvents(tx, movieEvent{Type: EventMovieUpdated, MovieID: movieID}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+func getMovieCredits(q dbtx, movieID int) ([]Credit, error) {
+	query := `
+		SELECT c.person_id, p.name, c.role, c.character_name, c.billing_order
+		FROM movie_credits c
+		JOIN people p ON p.id = c.person_id
+		WHERE c.movie_id = $1
+		ORDER BY ` + creditRoleOrder + `, p.name ASC`
+
+	rows, err := q.Query(query, movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credits := []Credit{}
+	for rows.Next() {
+		var c Credit
+		if err := rows.Scan(&c.PersonID, &c.Name, &c.Role, &c.Character, &c.BillingOrder); err != nil {
+			return nil, err
+		}
+		credits = append(credits, c)
+	}
+
+	return credits, rows.Err()
+}
+
+// Renvoie le générique de plusieurs films en une requête,
+// regroupé par ID de film. Un film sans générique est absent de la map.
+func (m MovieModel) GetMovieCredits(movieIDs []int) (map[int][]Credit, error) {
+	query := `
+		SELECT c.movie_id, c.person_id, p.name, c.role, c.character_name, c.billing_order
+		FROM movie_credits c
+		JOIN people p ON p.id = c.person_id
+		WHERE c.movie_id = ANY($1::int[])
+		ORDER BY c.movie_id, ` + creditRoleOrder + `, p.name ASC`
+
+	credits := make(map[int][]Credit)
+
+	err := scanRows(m.DB, query, []any{movieIDs}, func(rows *sql.Rows) error {
+		var movieID int
+		var c Credit
+		if err := rows.Scan(&movieID, &c.PersonID, &c.Name, &c.Role, &c.Character, &c.BillingOrder); err != nil {
+			return err
+		}
+		credits[movieID] = append(credits[movieID], c)
+		return nil
+	})
+
+	return credits, err
+}
+
+// Vérifie qu'un crédit est cohérent avec son rôle
+func (c *Credit) Validate() error {
+	if c.PersonID <= 0 {
+		return errors.New("person_id is required")
+	}
+
+	switch c.Role {
+	case CreditDirector, CreditWriter, CreditComposer:
+		if c.Character != nil || c.BillingOrder != nil {
+			return errors.New("character and billing_order are only allowed for actors")
+		}
+	case CreditActor:
+		if c.Character != nil && len(*c.Character) > 100 {
+			return errors.New("character must not exceed 100 characters")
+		}
+		if c.BillingOrder != nil && *c.BillingOrder < 1 {
+			return errors.New("billing_order must be at least 1")
+		}
+	default:
+		return errors.New("role must be one of director, writer, actor, composer")
+	}
+
+	return nil
+}
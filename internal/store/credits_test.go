@@ -0,0 +1,56 @@
+package store
+
+import "testing"
+
+func TestCredit_Validate(t *testing.T) {
+	neo := "Neo"
+	first := 1
+	zero := 0
+
+	tests := []struct {
+		name    string
+		credit  Credit
+		wantErr bool
+	}{
+		{
+			name:    "Valid Director",
+			credit:  Credit{PersonID: 1, Role: CreditDirector},
+			wantErr: false,
+		},
+		{
+			name:    "Valid Actor",
+			credit:  Credit{PersonID: 2, Role: CreditActor, Character: &neo, BillingOrder: &first},
+			wantErr: false,
+		},
+		{
+			name:    "Missing Person",
+			credit:  Credit{Role: CreditWriter},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Role",
+			credit:  Credit{PersonID: 1, Role: "producer"},
+			wantErr: true,
+		},
+		{
+			name:    "Character For Composer",
+			credit:  Credit{PersonID: 1, Role: CreditComposer, Character: &neo},
+			wantErr: true,
+		},
+		{
+			name:    "Billing Order Zero",
+			credit:  Credit{PersonID: 2, Role: CreditActor, BillingOrder: &zero},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.credit.Validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -52,6 +52,129 @@ func createTables(db *sql.DB) error {
 		return err
 	}
 
+	// Horodatage des films, ajouté après coup : ALTER pour les bases existantes
+	queryMovieTimestamps := `
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+	CREATE INDEX IF NOT EXISTS movies_updated_at_idx ON movies (updated_at);`
+
+	if _, err := db.Exec(queryMovieTimestamps); err != nil {
+		return err
+	}
+
+	// Métadonnées étendues (durée, langue, pays, classification, identifiants externes...)
+	queryMovieMetadata := `
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS runtime INTEGER CHECK (runtime > 0);
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS original_title TEXT;
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS original_language TEXT;
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS countries TEXT[] NOT NULL DEFAULT '{}';
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS mpaa_rating TEXT;
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS cnc_rating TEXT;
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS synopsis TEXT;
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS tagline TEXT;
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS budget BIGINT CHECK (budget >= 0);
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS box_office BIGINT CHECK (box_office >= 0);
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS imdb_id TEXT;
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS tmdb_id INTEGER;
+	CREATE INDEX IF NOT EXISTS movies_original_language_idx ON movies (original_language);
+	CREATE INDEX IF NOT EXISTS movies_countries_idx ON movies USING GIN (countries);
+	CREATE INDEX IF NOT EXISTS movies_imdb_id_idx ON movies (imdb_id);
+	CREATE INDEX IF NOT EXISTS movies_tmdb_id_idx ON movies (tmdb_id);`
+
+	if _, err := db.Exec(queryMovieMetadata); err != nil {
+		return err
+	}
+
+	// Films supprimés, conservés pour les synchronisations (updated_since)
+	queryTombstones := `
+	CREATE TABLE IF NOT EXISTS movie_tombstones (
+		movie_id INT PRIMARY KEY,
+		deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS movie_tombstones_deleted_at_idx ON movie_tombstones (deleted_at);`
+
+	if _, err := db.Exec(queryTombstones); err != nil {
+		return err
+	}
+
+	// Journal des changements du catalogue, relu par GET /movies/events
+	queryEvents := `
+	CREATE TABLE IF NOT EXISTS movie_events (
+		id BIGSERIAL PRIMARY KEY,
+		type TEXT NOT NULL CHECK (type IN ('movie.created', 'movie.updated', 'movie.deleted')),
+		movie_id INT NOT NULL,
+		payload JSONB NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS movie_events_created_at_idx ON movie_events (created_at);`
+
+	if _, err := db.Exec(queryEvents); err != nil {
+		return err
+	}
+
+	// Webhooks des partenaires. Les envois sont créés dans la transaction
+	// de chaque changement (outbox) puis traités en tâche de fond.
+	queryWebhooks := `
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id SERIAL PRIMARY KEY,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		event_types TEXT[] NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		failure_count INT NOT NULL DEFAULT 0,
+		disabled_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id BIGSERIAL PRIMARY KEY,
+		webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+		event_id BIGINT NOT NULL REFERENCES movie_events(id) ON DELETE CASCADE,
+		status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+		attempts INT NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		last_status_code INT,
+		last_error TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		delivered_at TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, id);
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_event_idx ON webhook_deliveries (event_id);
+	CREATE TABLE IF NOT EXISTS webhook_attempts (
+		delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+		attempt INT NOT NULL,
+		status_code INT,
+		error TEXT,
+		duration_ms INT NOT NULL,
+		succeeded BOOLEAN NOT NULL,
+		attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (delivery_id, attempt)
+	);`
+
+	if _, err := db.Exec(queryWebhooks); err != nil {
+		return err
+	}
+
+	// Réponses des requêtes POST envoyées avec un en-tête Idempotency-Key
+	queryIdempotency := `
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		principal TEXT NOT NULL,
+		key TEXT NOT NULL,
+		request_hash TEXT NOT NULL,
+		status INT,
+		header JSONB,
+		body BYTEA,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (principal, key)
+	);
+	CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);`
+
+	if _, err := db.Exec(queryIdempotency); err != nil {
+		return err
+	}
+
 	queryGenres := `
     CREATE TABLE IF NOT EXISTS genres (
         id SERIAL PRIMARY KEY,
@@ -73,12 +196,236 @@ func createTables(db *sql.DB) error {
 		return err
 	}
 
+	// Clé de titre normalisée (casse, espaces et accents ignorés)
+	// pour détecter les doublons, et index trigramme pour les quasi-doublons
+	queryTitleKey := `
+	CREATE EXTENSION IF NOT EXISTS unaccent;
+	CREATE EXTENSION IF NOT EXISTS pg_trgm;
+	CREATE OR REPLACE FUNCTION movie_title_key(title TEXT) RETURNS TEXT
+	LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE AS
+	$$ SELECT lower(regexp_replace(btrim(public.unaccent('public.unaccent'::regdictionary, title)), '\s+', ' ', 'g')) $$;
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS title_key TEXT GENERATED ALWAYS AS (movie_title_key(title)) STORED;
+	CREATE INDEX IF NOT EXISTS movies_title_key_trgm_idx ON movies USING gin (title_key gin_trgm_ops);`
+
+	if _, err := db.Exec(queryTitleKey); err != nil {
+		return err
+	}
+
+	// Les bases existantes peuvent déjà contenir des doublons : dans ce cas
+	// l'index unique ne peut pas être créé et seule la vérification
+	// applicative de AddMovie/UpdateMovie protège le catalogue
+	queryUniqueTitle := `
+	CREATE UNIQUE INDEX IF NOT EXISTS movies_title_key_year_idx ON movies (title_key, release_year);`
+
+	if _, err := db.Exec(queryUniqueTitle); err != nil {
+		if !isUniqueViolation(err) {
+			return err
+		}
+		log.Println("Warning: duplicate movies found, unique index not created. See GET /movies/duplicates")
+	}
+
+	queryPeople := `
+	CREATE TABLE IF NOT EXISTS people (
+		id SERIAL PRIMARY KEY,
+		name TEXT NOT NULL,
+		birth_year INTEGER,
+		biography TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS people_name_trgm_idx ON people USING gin (name gin_trgm_ops);`
+
+	if _, err := db.Exec(queryPeople); err != nil {
+		return err
+	}
+
+	// Générique d'un film : une personne peut avoir plusieurs rôles,
+	// et jouer plusieurs personnages dans le même film
+	queryMovieCredits := `
+	CREATE TABLE IF NOT EXISTS movie_credits (
+		id SERIAL PRIMARY KEY,
+		movie_id INT NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+		person_id INT NOT NULL REFERENCES people(id) ON DELETE CASCADE,
+		role TEXT NOT NULL CHECK (role IN ('director', 'writer', 'actor', 'composer')),
+		character_name TEXT,
+		billing_order INTEGER
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS movie_credits_unique_idx
+		ON movie_credits (movie_id, person_id, role, COALESCE(character_name, ''));
+	CREATE INDEX IF NOT EXISTS movie_credits_person_idx ON movie_credits (person_id, role);`
+
+	if _, err := db.Exec(queryMovieCredits); err != nil {
+		return err
+	}
+
+	queryUsers := `
+	CREATE TABLE IF NOT EXISTS users (
+		id SERIAL PRIMARY KEY,
+		name TEXT UNIQUE NOT NULL,
+		role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'editor', 'admin')),
+		token_hash TEXT UNIQUE NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);`
+
+	if _, err := db.Exec(queryUsers); err != nil {
+		return err
+	}
+
+	// Notes des utilisateurs, avec leurs totaux gardés sur la ligne du film
+	queryUserRatings := `
+	CREATE TABLE IF NOT EXISTS user_ratings (
+		user_id INT REFERENCES users(id) ON DELETE CASCADE,
+		movie_id INT REFERENCES movies(id) ON DELETE CASCADE,
+		score SMALLINT NOT NULL CHECK (score BETWEEN 0 AND 10),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (user_id, movie_id)
+	);
+	CREATE INDEX IF NOT EXISTS user_ratings_movie_idx ON user_ratings (movie_id);
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS user_rating_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE movies ADD COLUMN IF NOT EXISTS user_rating_sum INTEGER NOT NULL DEFAULT 0;`
+
+	if _, err := db.Exec(queryUserRatings); err != nil {
+		return err
+	}
+
+	// Critiques des utilisateurs (la colonne movies.review reste la critique éditoriale)
+	queryReviews := `
+	CREATE TABLE IF NOT EXISTS reviews (
+		id SERIAL PRIMARY KEY,
+		movie_id INT NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+		user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		body TEXT NOT NULL,
+		spoiler BOOLEAN NOT NULL DEFAULT FALSE,
+		status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+		moderation_note TEXT,
+		helpful_count INTEGER NOT NULL DEFAULT 0,
+		unhelpful_count INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		UNIQUE (movie_id, user_id)
+	);
+	CREATE INDEX IF NOT EXISTS reviews_status_idx ON reviews (status, created_at);
+	CREATE TABLE IF NOT EXISTS review_votes (
+		review_id INT REFERENCES reviews(id) ON DELETE CASCADE,
+		user_id INT REFERENCES users(id) ON DELETE CASCADE,
+		helpful BOOLEAN NOT NULL,
+		PRIMARY KEY (review_id, user_id)
+	);`
+
+	if _, err := db.Exec(queryReviews); err != nil {
+		return err
+	}
+
+	// Listes personnelles : films à voir (ordonnés) et films vus
+	queryWatchlists := `
+	CREATE TABLE IF NOT EXISTS watchlist_items (
+		user_id INT REFERENCES users(id) ON DELETE CASCADE,
+		movie_id INT REFERENCES movies(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (user_id, movie_id)
+	);
+	CREATE INDEX IF NOT EXISTS watchlist_items_movie_idx ON watchlist_items (movie_id);
+	CREATE TABLE IF NOT EXISTS watched_movies (
+		user_id INT REFERENCES users(id) ON DELETE CASCADE,
+		movie_id INT REFERENCES movies(id) ON DELETE CASCADE,
+		watched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		watch_count INTEGER NOT NULL DEFAULT 1 CHECK (watch_count >= 1),
+		PRIMARY KEY (user_id, movie_id)
+	);
+	CREATE INDEX IF NOT EXISTS watched_movies_movie_idx ON watched_movies (movie_id);`
+
+	if _, err := db.Exec(queryWatchlists); err != nil {
+		return err
+	}
+
+	// Listes éditoriales et collections, ordonnées
+	queryLists := `
+	CREATE TABLE IF NOT EXISTS movie_lists (
+		id SERIAL PRIMARY KEY,
+		owner_id INT REFERENCES users(id) ON DELETE SET NULL,
+		name TEXT NOT NULL,
+		description TEXT,
+		kind TEXT NOT NULL DEFAULT 'list' CHECK (kind IN ('list', 'collection')),
+		is_public BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS movie_lists_owner_idx ON movie_lists (owner_id);
+	CREATE TABLE IF NOT EXISTS movie_list_items (
+		list_id INT REFERENCES movie_lists(id) ON DELETE CASCADE,
+		movie_id INT REFERENCES movies(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		note TEXT,
+		added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (list_id, movie_id)
+	);
+	CREATE INDEX IF NOT EXISTS movie_list_items_movie_idx ON movie_list_items (movie_id);`
+
+	if _, err := db.Exec(queryLists); err != nil {
+		return err
+	}
+
+	// Traductions des titres, synopsis et genres (une ligne par langue)
+	queryTranslations := `
+	CREATE TABLE IF NOT EXISTS movie_translations (
+		movie_id INT REFERENCES movies(id) ON DELETE CASCADE,
+		language TEXT NOT NULL,
+		title TEXT NOT NULL,
+		synopsis TEXT,
+		tagline TEXT,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (movie_id, language)
+	);
+	CREATE INDEX IF NOT EXISTS movie_translations_title_idx ON movie_translations USING GIN (title gin_trgm_ops);
+
+	CREATE TABLE IF NOT EXISTS genre_translations (
+		genre_id INT REFERENCES genres(id) ON DELETE CASCADE,
+		language TEXT NOT NULL,
+		name TEXT NOT NULL,
+		PRIMARY KEY (genre_id, language)
+	);`
+
+	if _, err := db.Exec(queryTranslations); err != nil {
+		return err
+	}
+
+	// Affiche d'un film : les fichiers sont dans le BlobStore, sous posters/{id}/{version}
+	queryPosters := `
+	CREATE TABLE IF NOT EXISTS movie_posters (
+		movie_id INT PRIMARY KEY REFERENCES movies(id) ON DELETE CASCADE,
+		version TEXT NOT NULL,
+		format TEXT NOT NULL CHECK (format IN ('jpeg', 'png', 'webp')),
+		width INTEGER NOT NULL,
+		height INTEGER NOT NULL,
+		sizes TEXT[] NOT NULL DEFAULT '{}',
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);`
+
+	if _, err := db.Exec(queryPosters); err != nil {
+		return err
+	}
+
 	queryInsertGenres := `
     INSERT INTO genres (name) VALUES 
     ('Action'), ('Comédie'), ('Drame'), ('Sci-Fi'), ('Horreur'), ('Aventure')
     ON CONFLICT (name) DO NOTHING;`
 
-	_, err := db.Exec(queryInsertGenres)
+	if _, err := db.Exec(queryInsertGenres); err != nil {
+		return err
+	}
+
+	queryInsertGenreTranslations := `
+    INSERT INTO genre_translations (genre_id, language, name)
+    SELECT g.id, 'en', t.name FROM (VALUES
+        ('Action', 'Action'), ('Comédie', 'Comedy'), ('Drame', 'Drama'),
+        ('Sci-Fi', 'Sci-Fi'), ('Horreur', 'Horror'), ('Aventure', 'Adventure')
+    ) AS t(genre, name)
+    JOIN genres g ON g.name = t.genre
+    ON CONFLICT DO NOTHING;`
+
+	_, err := db.Exec(queryInsertGenreTranslations)
 
 	return err
 }
@@ -0,0 +1,334 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrDuplicateMovie = errors.New("a movie with the same title and release year already exists")
+	ErrSelfMerge      = errors.New("a movie cannot be merged into itself")
+)
+
+// Erreur renvoyée quand un film existe déjà avec le même titre
+// normalisé et la même année. ExistingID vaut 0 si le film
+// en conflit n'est pas encore enregistré (même lot).
+type DuplicateMovieError struct {
+	ExistingID int
+}
+
+func (e *DuplicateMovieError) Error() string {
+	if e.ExistingID == 0 {
+		return ErrDuplicateMovie.Error()
+	}
+	return fmt.Sprintf("%s (id %d)", ErrDuplicateMovie, e.ExistingID)
+}
+
+func (e *DuplicateMovieError) Is(target error) bool {
+	return target == ErrDuplicateMovie
+}
+
+// Version courte d'un film, pour les rapports
+type MovieRef struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	ReleaseYear int    `json:"release_year"`
+}
+
+// Deux films dont les titres se ressemblent
+type DuplicatePair struct {
+	Movie      MovieRef `json:"movie"`
+	Duplicate  MovieRef `json:"duplicate"`
+	Similarity float64  `json:"similarity"`
+}
+
+// Renvoie les paires de films aux titres similaires (similarité trigramme
+// supérieure ou égale à threshold) et sortis à yearTolerance ans d'écart au plus,
+// les plus ressemblantes en premier.
+func (m MovieModel) FindDuplicates(threshold float64, yearTolerance int, filters Filters) ([]DuplicatePair, Metadata, error) {
+	limit := filters.PageSize
+	offset := (filters.Page - 1) * filters.PageSize
+
+	query := `
+		SELECT count(*) OVER(), a.id, a.title, a.release_year, b.id, b.title, b.release_year,
+			similarity(a.title_key, b.title_key) AS score
+		FROM movies a
+		JOIN movies b ON a.id < b.id AND a.title_key % b.title_key
+		WHERE similarity(a.title_key, b.title_key) >= $1
+		AND abs(a.release_year - b.release_year) <= $2
+		ORDER BY score DESC, a.id ASC, b.id ASC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := m.DB.Query(query, threshold, yearTolerance, limit, offset)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	pairs := []DuplicatePair{}
+
+	for rows.Next() {
+		var p DuplicatePair
+		err := rows.Scan(&totalRecords, &p.Movie.ID, &p.Movie.Title, &p.Movie.ReleaseYear,
+			&p.Duplicate.ID, &p.Duplicate.Title, &p.Duplicate.ReleaseYear, &p.Similarity)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		pairs = append(pairs, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return pairs, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Fusionne le film duplicateID dans targetID : les genres, le générique,
+// les notes, les critiques, les listes et les traductions sont réunis,
+// la note, la critique et les métadonnées du doublon complètent celles de la cible,
+// puis le doublon est supprimé. Renvoie le film fusionné.
+func (m MovieModel) MergeMovies(targetID, duplicateID int) (Movie, error) {
+	if targetID == duplicateID {
+		return Movie{}, ErrSelfMerge
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return Movie{}, err
+	}
+	defer tx.Rollback()
+
+	queryLock := `
+		SELECT id, rating, review FROM movies
+		WHERE id = ANY($1::int[])
+		ORDER BY id
+		FOR UPDATE`
+
+	rows, err := tx.Query(queryLock, []int64{int64(targetID), int64(duplicateID)})
+	if err != nil {
+		return Movie{}, err
+	}
+
+	locked := make(map[int]Movie, 2)
+	for rows.Next() {
+		var movie Movie
+		if err := rows.Scan(&movie.ID, &movie.Rating, &movie.Review); err != nil {
+			rows.Close()
+			return Movie{}, err
+		}
+		locked[movie.ID] = movie
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Movie{}, err
+	}
+
+	target, ok := locked[targetID]
+	if !ok {
+		return Movie{}, sql.ErrNoRows
+	}
+	duplicate, ok := locked[duplicateID]
+	if !ok {
+		return Movie{}, sql.ErrNoRows
+	}
+
+	queryGenres := `
+		INSERT INTO movie_genres (movie_id, genre_id)
+		SELECT $1, genre_id FROM movie_genres WHERE movie_id = $2
+		ON CONFLICT DO NOTHING`
+
+	if _, err := tx.Exec(queryGenres, targetID, duplicateID); err != nil {
+		return Movie{}, err
+	}
+
+	queryCredits := `
+		INSERT INTO movie_credits (movie_id, person_id, role, character_name, billing_order)
+		SELECT $1, person_id, role, character_name, billing_order
+		FROM movie_credits WHERE movie_id = $2
+		ON CONFLICT DO NOTHING`
+
+	if _, err := tx.Exec(queryCredits, targetID, duplicateID); err != nil {
+		return Movie{}, err
+	}
+
+	// Les notes des utilisateurs sont reprises, sauf s'ils ont déjà noté la cible
+	queryRatings := `
+		INSERT INTO user_ratings (user_id, movie_id, score, created_at, updated_at)
+		SELECT user_id, $1, score, created_at, updated_at
+		FROM user_ratings WHERE movie_id = $2
+		ON CONFLICT DO NOTHING`
+
+	if _, err := tx.Exec(queryRatings, targetID, duplicateID); err != nil {
+		return Movie{}, err
+	}
+
+	if err := refreshRatingTotals(tx, targetID); err != nil {
+		return Movie{}, err
+	}
+
+	// Les critiques sont rattachées à la cible ; celles d'un utilisateur
+	// qui a déjà critiqué la cible disparaissent avec le doublon
+	queryReviews := `
+		UPDATE reviews SET movie_id = $1
+		WHERE movie_id = $2
+		AND user_id NOT IN (SELECT user_id FROM reviews WHERE movie_id = $1)`
+
+	if _, err := tx.Exec(queryReviews, targetID, duplicateID); err != nil {
+		return Movie{}, err
+	}
+
+	// Listes personnelles : un film vu dans l'une ou l'autre version
+	// garde la date la plus récente et le total des visionnages
+	queryWatched := `
+		INSERT INTO watched_movies (user_id, movie_id, watched_at, watch_count)
+		SELECT user_id, $1, watched_at, watch_count
+		FROM watched_movies WHERE movie_id = $2
+		ON CONFLICT (user_id, movie_id) DO UPDATE SET
+			watched_at = GREATEST(watched_movies.watched_at, EXCLUDED.watched_at),
+			watch_count = watched_movies.watch_count + EXCLUDED.watch_count`
+
+	if _, err := tx.Exec(queryWatched, targetID, duplicateID); err != nil {
+		return Movie{}, err
+	}
+
+	queryWatchlist := `
+		INSERT INTO watchlist_items (user_id, movie_id, position, added_at)
+		SELECT user_id, $1, position, added_at
+		FROM watchlist_items WHERE movie_id = $2
+		ON CONFLICT DO NOTHING`
+
+	if _, err := tx.Exec(queryWatchlist, targetID, duplicateID); err != nil {
+		return Movie{}, err
+	}
+
+	queryListItems := `
+		INSERT INTO movie_list_items (list_id, movie_id, position, note, added_at)
+		SELECT list_id, $1, position, note, added_at
+		FROM movie_list_items WHERE movie_id = $2
+		ON CONFLICT DO NOTHING`
+
+	if _, err := tx.Exec(queryListItems, targetID, duplicateID); err != nil {
+		return Movie{}, err
+	}
+
+	// Les traductions manquantes de la cible sont reprises du doublon
+	queryTranslations := `
+		INSERT INTO movie_translations (movie_id, language, title, synopsis, tagline, updated_at)
+		SELECT $1, language, title, synopsis, tagline, updated_at
+		FROM movie_translations WHERE movie_id = $2
+		ON CONFLICT DO NOTHING`
+
+	if _, err := tx.Exec(queryTranslations, targetID, duplicateID); err != nil {
+		return Movie{}, err
+	}
+
+	if target.Rating == nil {
+		target.Rating = duplicate.Rating
+	}
+	target.Review = mergeReviews(target.Review, duplicate.Review)
+
+	queryUpdate := `
+		UPDATE movies SET rating = $1, review = $2, updated_at = NOW()
+		WHERE id = $3`
+
+	if _, err := tx.Exec(queryUpdate, target.Rating, target.Review, targetID); err != nil {
+		return Movie{}, err
+	}
+
+	// Les métadonnées manquantes de la cible sont prises dans le doublon
+	queryMetadata := `
+		UPDATE movies t SET
+			runtime = COALESCE(t.runtime, d.runtime),
+			original_title = COALESCE(t.original_title, d.original_title),
+			original_language = COALESCE(t.original_language, d.original_language),
+			countries = CASE WHEN cardinality(t.countries) = 0 THEN d.countries ELSE t.countries END,
+			mpaa_rating = COALESCE(t.mpaa_rating, d.mpaa_rating),
+			cnc_rating = COALESCE(t.cnc_rating, d.cnc_rating),
+			synopsis = COALESCE(t.synopsis, d.synopsis),
+			tagline = COALESCE(t.tagline, d.tagline),
+			budget = COALESCE(t.budget, d.budget),
+			box_office = COALESCE(t.box_office, d.box_office),
+			imdb_id = COALESCE(t.imdb_id, d.imdb_id),
+			tmdb_id = COALESCE(t.tmdb_id, d.tmdb_id)
+		FROM movies d
+		WHERE t.id = $1 AND d.id = $2`
+
+	if _, err := tx.Exec(queryMetadata, targetID, duplicateID); err != nil {
+		return Movie{}, err
+	}
+
+	deleted, err := deleteMovie(tx, duplicateID)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	updated := movieEvent{Type: EventMovieUpdated, MovieID: targetID}
+	if err := recordMovieEvents(tx, updated, deleted); err != nil {
+		return Movie{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Movie{}, err
+	}
+
+	return m.GetMoviebyID(targetID)
+}
+
+// La critique du doublon est ajoutée à la suite de celle de la cible
+// tant que le total reste sous la limite imposée par Validate
+func mergeReviews(target, duplicate *string) *string {
+	if duplicate == nil || *duplicate == "" {
+		return target
+	}
+	if target == nil || *target == "" {
+		return duplicate
+	}
+	if *target == *duplicate {
+		return target
+	}
+
+	merged := *target + "\n\n" + *duplicate
+	if len(merged) > maxReviewLength {
+		return target
+	}
+	return &merged
+}
+
+// Renvoie une DuplicateMovieError si un autre film que excludeID
+// a déjà ce titre (normalisé) et cette année
+func findDuplicate(q dbtx, title string, releaseYear, excludeID int) error {
+	query := `
+		SELECT id FROM movies
+		WHERE title_key = movie_title_key($1) AND release_year = $2 AND id <> $3
+		LIMIT 1`
+
+	var existingID int
+	err := q.QueryRow(query, title, releaseYear, excludeID).Scan(&existingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return &DuplicateMovieError{ExistingID: existingID}
+}
+
+// Traduit la violation de l'index unique (titre, année) en ErrDuplicateMovie
+func translateDuplicate(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "movies_title_key_year_idx" {
+		return &DuplicateMovieError{}
+	}
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,249 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+const (
+	EventMovieCreated = "movie.created"
+	EventMovieUpdated = "movie.updated"
+	EventMovieDeleted = "movie.deleted"
+)
+
+// Canal PostgreSQL notifié à chaque nouvel événement,
+// pour prévenir toutes les instances de l'API
+const EventsChannel = "movie_events"
+
+// Un changement du catalogue, tel qu'enregistré dans le journal.
+// Payload est le film après modification (id et genres pour une suppression).
+type MovieEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	MovieID   int             `json:"movie_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type EventModel struct {
+	DB *sql.DB
+}
+
+// Événement à enregistrer en fin de transaction.
+// Sans payload, le film est relu au moment de l'écriture.
+type movieEvent struct {
+	Type    string
+	MovieID int
+	payload []byte
+}
+
+// Payload d'une suppression : les genres sont gardés pour le filtre par genre
+type deletedMovie struct {
+	ID     int      `json:"id"`
+	Genres []string `json:"genres"`
+}
+
+// Écrit les événements dans le journal, prépare leur envoi aux webhooks
+// et notifie les instances.
+// Les IDs viennent de la séquence de movie_events. Pour qu'ils suivent l'ordre
+// des commits, et qu'un client qui reprend après un ID ne puisse pas en manquer,
+// l'insertion se fait sous un verrou tenu jusqu'au commit : à appeler en dernier,
+// juste avant le commit. Les films sont relus avant de le prendre.
+// Les films créés ou modifiés qui n'existent plus sont ignorés.
+func recordMovieEvents(q dbtx, events ...movieEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var ids []int
+	for _, e := range events {
+		if e.payload == nil {
+			ids = append(ids, e.MovieID)
+		}
+	}
+
+	movies, err := eventMovies(q, ids)
+	if err != nil {
+		return err
+	}
+
+	types := make([]string, 0, len(events))
+	movieIDs := make([]int, 0, len(events))
+	payloads := make([]string, 0, len(events))
+	for _, e := range events {
+		payload := e.payload
+		if payload == nil {
+			movie, ok := movies[e.MovieID]
+			if !ok {
+				continue
+			}
+			if payload, err = json.Marshal(movie); err != nil {
+				return err
+			}
+		}
+		types = append(types, e.Type)
+		movieIDs = append(movieIDs, e.MovieID)
+		payloads = append(payloads, string(payload))
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	if _, err := q.Exec("SELECT pg_advisory_xact_lock(hashtext($1))", EventsChannel); err != nil {
+		return err
+	}
+
+	// Les envois aux webhooks abonnés sont créés dans la même transaction (outbox).
+	// Un seul NOTIFY par transaction : les instances relisent le journal.
+	query := `
+		WITH inserted AS (
+			INSERT INTO movie_events (type, movie_id, payload)
+			SELECT type, movie_id, payload::jsonb
+			FROM unnest($1::text[], $2::int[], $3::text[]) WITH ORDINALITY AS t(type, movie_id, payload, n)
+			ORDER BY n
+			RETURNING id, type
+		), deliveries AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id)
+			SELECT w.id, i.id
+			FROM inserted i
+			JOIN webhooks w ON w.active AND i.type = ANY(w.event_types)
+		)
+		SELECT pg_notify($4, MAX(id)::text) FROM inserted`
+
+	_, err = q.Exec(query, types, movieIDs, payloads, EventsChannel)
+	return err
+}
+
+// Relit les films (genres compris) dans l'état de la transaction en cours
+func eventMovies(q dbtx, ids []int) (map[int]Movie, error) {
+	movies := make(map[int]Movie, len(ids))
+	if len(ids) == 0 {
+		return movies, nil
+	}
+
+	query := `
+		SELECT ` + movieListColumns() + `,
+			COALESCE(array_agg(g.name ORDER BY g.name) FILTER (WHERE g.id IS NOT NULL), '{}')
+		FROM movies m
+		LEFT JOIN movie_genres mg ON mg.movie_id = m.id
+		LEFT JOIN genres g ON g.id = mg.genre_id
+		WHERE m.id = ANY($1::int[])
+		GROUP BY m.id`
+
+	typeMap := pgtype.NewMap()
+	err := scanRows(q, query, []any{ids}, func(rows *sql.Rows) error {
+		var movie Movie
+		dest := append(movie.listDest(typeMap), typeMap.SQLScanner(&movie.Genres))
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		movies[movie.ID] = movie
+		return nil
+	})
+
+	return movies, err
+}
+
+// Prépare l'événement de suppression d'un film, avant que ses genres disparaissent
+func deletedMovieEvent(q dbtx, id int) (movieEvent, error) {
+	query := `
+		SELECT COALESCE(array_agg(g.name ORDER BY g.name), '{}')
+		FROM movie_genres mg
+		JOIN genres g ON g.id = mg.genre_id
+		WHERE mg.movie_id = $1`
+
+	payload := deletedMovie{ID: id}
+	if err := q.QueryRow(query, id).Scan(pgtype.NewMap().SQLScanner(&payload.Genres)); err != nil {
+		return movieEvent{}, err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return movieEvent{}, err
+	}
+
+	return movieEvent{Type: EventMovieDeleted, MovieID: id, payload: data}, nil
+}
+
+// Renvoie au plus limit événements postérieurs à afterID, dans l'ordre.
+// Si genre n'est pas vide, seuls les films de ce genre sont gardés.
+func (m EventModel) GetEventsSince(afterID int64, genre string, limit int) ([]MovieEvent, error) {
+	query := `
+		SELECT id, type, movie_id, payload, created_at
+		FROM movie_events
+		WHERE id > $1
+			AND ($2 = '' OR EXISTS (
+				SELECT 1 FROM jsonb_array_elements_text(payload->'genres') g
+				WHERE lower(g) = lower($2)))
+		ORDER BY id
+		LIMIT $3`
+
+	events := []MovieEvent{}
+	err := scanRows(m.DB, query, []any{afterID, genre, limit}, func(rows *sql.Rows) error {
+		var e MovieEvent
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Type, &e.MovieID, &payload, &e.CreatedAt); err != nil {
+			return err
+		}
+		e.Payload = payload
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Renvoie l'ID du dernier événement, 0 si le journal est vide
+func (m EventModel) LastEventID() (int64, error) {
+	var id int64
+	err := m.DB.QueryRow("SELECT COALESCE(MAX(id), 0) FROM movie_events").Scan(&id)
+	return id, err
+}
+
+// Purge les événements plus anciens que la date donnée,
+// renvoie le nombre d'événements supprimés
+func (m EventModel) DeleteEventsBefore(before time.Time) (int64, error) {
+	res, err := m.DB.Exec("DELETE FROM movie_events WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Écoute les notifications du canal sur une connexion dédiée et appelle
+// notify à chacune, ainsi qu'une fois l'écoute en place pour rattraper
+// ce qui a pu être manqué. Bloque jusqu'à l'annulation de ctx ou une erreur.
+func (m EventModel) Listen(ctx context.Context, notify func()) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		// La connexion reste abonnée au canal : elle ne doit pas retourner dans le pool
+		defer pgxConn.Close(context.Background())
+
+		if _, err := pgxConn.Exec(ctx, fmt.Sprintf("LISTEN %s", EventsChannel)); err != nil {
+			return err
+		}
+		notify()
+
+		for {
+			if _, err := pgxConn.WaitForNotification(ctx); err != nil {
+				return err
+			}
+			notify()
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Relations qu'un client peut demander d'inclure à un film (?include=)
+const (
+	RelationGenres  = "genres"
+	RelationCredits = "credits"
+)
+
+var MovieRelations = []string{RelationGenres, RelationCredits}
+
+// Champ d'un film lu dans la table movies : ses colonnes SQL
+// et les destinations du Scan correspondantes
+type movieColumn struct {
+	field   string
+	columns string
+	dest    func(m *Movie, typeMap *pgtype.Map) []any
+}
+
+var movieColumnList = buildMovieColumns()
+
+// Champs sélectionnables avec ?fields=, dans l'ordre des colonnes lues
+var MovieFields = func() []string {
+	fields := make([]string, len(movieColumnList))
+	for i, c := range movieColumnList {
+		fields[i] = c.field
+	}
+	return fields
+}()
+
+func buildMovieColumns() []movieColumn {
+	columns := []movieColumn{
+		{"id", "m.id", func(m *Movie, _ *pgtype.Map) []any { return []any{&m.ID} }},
+		{"title", "m.title", func(m *Movie, _ *pgtype.Map) []any { return []any{&m.Title} }},
+		{"release_year", "m.release_year", func(m *Movie, _ *pgtype.Map) []any { return []any{&m.ReleaseYear} }},
+		{"rating", "ROUND(m.rating::numeric, 1)", func(m *Movie, _ *pgtype.Map) []any { return []any{&m.Rating} }},
+		{"review", "m.review", func(m *Movie, _ *pgtype.Map) []any { return []any{&m.Review} }},
+		{"created_at", "m.created_at", func(m *Movie, _ *pgtype.Map) []any { return []any{&m.CreatedAt} }},
+		{"updated_at", "m.updated_at", func(m *Movie, _ *pgtype.Map) []any { return []any{&m.UpdatedAt} }},
+		{"user_ratings", "m.user_rating_count, " + averageRatingSQL + ", " + weightedRatingSQL, func(m *Movie, _ *pgtype.Map) []any {
+			m.UserRatings = &RatingSummary{}
+			return []any{&m.UserRatings.Count, &m.UserRatings.Average, &m.UserRatings.WeightedScore}
+		}},
+	}
+
+	for i, field := range metadataFields {
+		columns = append(columns, movieColumn{field, "m." + field, func(m *Movie, typeMap *pgtype.Map) []any {
+			return []any{m.metadataDest(typeMap)[i]}
+		}})
+	}
+
+	return columns
+}
+
+// Vérifie que chaque champ demandé peut être sélectionné
+func CheckMovieFields(fields []string) error {
+	for _, f := range fields {
+		if !slices.Contains(MovieFields, f) {
+			return fmt.Errorf("unknown field '%s' (allowed: %s)", f, strings.Join(MovieFields, ", "))
+		}
+	}
+	return nil
+}
+
+// Vérifie que chaque relation demandée peut être incluse
+func CheckMovieRelations(relations []string) error {
+	for _, r := range relations {
+		if !slices.Contains(MovieRelations, r) {
+			return fmt.Errorf("unknown relation '%s' (allowed: %s)", r, strings.Join(MovieRelations, ", "))
+		}
+	}
+	return nil
+}
+
+// Colonnes des champs demandés, tous si fields est vide
+func selectedColumns(fields []string) []movieColumn {
+	if len(fields) == 0 {
+		return movieColumnList
+	}
+
+	var columns []movieColumn
+	for _, c := range movieColumnList {
+		if slices.Contains(fields, c.field) {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// Liste SELECT des champs demandés (préfixés par l'alias m)
+func movieColumns(fields []string) string {
+	columns := selectedColumns(fields)
+	sql := make([]string, len(columns))
+	for i, c := range columns {
+		sql[i] = c.columns
+	}
+	return strings.Join(sql, ", ")
+}
+
+// Destinations du Scan des colonnes de movieColumns
+func (m *Movie) fieldsDest(fields []string, typeMap *pgtype.Map) []any {
+	var dest []any
+	for _, c := range selectedColumns(fields) {
+		dest = append(dest, c.dest(m, typeMap)...)
+	}
+	return dest
+}
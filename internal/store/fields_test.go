@@ -0,0 +1,55 @@
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMovieColumns(t *testing.T) {
+	tests := []struct {
+		name        string
+		fields      []string
+		wantColumns int
+		wantErr     bool
+	}{
+		{name: "All Fields", fields: nil, wantColumns: 10 + len(metadataFields)},
+		{name: "Few Fields", fields: []string{"id", "title", "release_year"}, wantColumns: 3},
+		{name: "User Ratings", fields: []string{"id", "user_ratings"}, wantColumns: 4},
+		{name: "Metadata Field", fields: []string{"id", "countries"}, wantColumns: 2},
+		{name: "Unknown Field", fields: []string{"id", "password"}, wantErr: true},
+		{name: "Relation As Field", fields: []string{"genres"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMovieFields(tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckMovieFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// Chaque colonne lue doit avoir sa destination
+			var movie Movie
+			dest := movie.fieldsDest(tt.fields, pgtype.NewMap())
+			if len(dest) != tt.wantColumns {
+				t.Errorf("fieldsDest() = %d destinations, want %d", len(dest), tt.wantColumns)
+			}
+			if tt.fields != nil && !strings.HasPrefix(movieColumns(tt.fields), "m.id") {
+				t.Errorf("movieColumns() = %q, want m.id first", movieColumns(tt.fields))
+			}
+		})
+	}
+}
+
+func TestCheckMovieRelations(t *testing.T) {
+	if err := CheckMovieRelations([]string{RelationGenres, RelationCredits}); err != nil {
+		t.Errorf("CheckMovieRelations() error = %v", err)
+	}
+	if err := CheckMovieRelations([]string{"reviews"}); err == nil {
+		t.Error("CheckMovieRelations() should reject reviews")
+	}
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type GenreModel struct {
+	DB *sql.DB
+}
+
+type Genre struct {
+	ID           int               `json:"id"`
+	Name         string            `json:"name"`
+	Translations map[string]string `json:"translations,omitempty"` // libellés par langue
+}
+
+// Renvoie tous les genres connus, triés par nom, avec leurs traductions.
+func (m GenreModel) GetGenres() ([]Genre, error) {
+	query := `
+		SELECT g.id, g.name,
+			(SELECT jsonb_object_agg(t.language, t.name) FROM genre_translations t WHERE t.genre_id = g.id)
+		FROM genres g
+		ORDER BY g.name`
+
+	rows, err := m.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []Genre{}
+	for rows.Next() {
+		var g Genre
+		if err := g.scan(rows); err != nil {
+			return nil, err
+		}
+		genres = append(genres, g)
+	}
+
+	return genres, rows.Err()
+}
+
+// Renvoie les genres de plusieurs films en une requête, triés par nom
+// et regroupés par ID de film. Un film sans genre est absent de la map.
+func (m GenreModel) GetMovieGenres(movieIDs []int) (map[int][]Genre, error) {
+	query := `
+		SELECT mg.movie_id, g.id, g.name,
+			(SELECT jsonb_object_agg(t.language, t.name) FROM genre_translations t WHERE t.genre_id = g.id)
+		FROM movie_genres mg
+		JOIN genres g ON g.id = mg.genre_id
+		WHERE mg.movie_id = ANY($1::int[])
+		ORDER BY mg.movie_id, g.name`
+
+	genres := make(map[int][]Genre)
+
+	err := scanRows(m.DB, query, []any{movieIDs}, func(rows *sql.Rows) error {
+		var movieID int
+		var g Genre
+		if err := g.scan(rows, &movieID); err != nil {
+			return err
+		}
+		genres[movieID] = append(genres[movieID], g)
+		return nil
+	})
+
+	return genres, err
+}
+
+// Renvoie au plus limit films (les mieux notés d'abord) pour chacun
+// des genres, en une requête, regroupés par ID de genre.
+func (m GenreModel) GetGenreMovies(genreIDs []int, limit int) (map[int][]Movie, error) {
+	query := fmt.Sprintf(`
+		SELECT genre_id, %s
+		FROM (
+			SELECT mg.genre_id, m.*,
+				ROW_NUMBER() OVER (PARTITION BY mg.genre_id ORDER BY m.rating DESC NULLS LAST, m.id) AS rank
+			FROM movie_genres mg
+			JOIN movies m ON m.id = mg.movie_id
+			WHERE mg.genre_id = ANY($1::int[])
+		) m
+		WHERE rank <= $2
+		ORDER BY genre_id, rank`, movieListColumns())
+
+	movies := make(map[int][]Movie)
+	typeMap := pgtype.NewMap()
+
+	err := scanRows(m.DB, query, []any{genreIDs, limit}, func(rows *sql.Rows) error {
+		var genreID int
+		var movie Movie
+		if err := rows.Scan(append([]any{&genreID}, movie.listDest(typeMap)...)...); err != nil {
+			return err
+		}
+		movies[genreID] = append(movies[genreID], movie)
+		return nil
+	})
+
+	return movies, err
+}
+
+// Lit id, name et les traductions en JSON, précédés des colonnes prefix
+func (g *Genre) scan(rows *sql.Rows, prefix ...any) error {
+	var translations []byte
+	if err := rows.Scan(append(prefix, &g.ID, &g.Name, &translations)...); err != nil {
+		return err
+	}
+	if translations == nil {
+		return nil
+	}
+	return json.Unmarshal(translations, &g.Translations)
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+type IdempotencyModel struct {
+	DB *sql.DB
+}
+
+// Réponse enregistrée pour une clé d'idempotence.
+// Status vaut 0 tant que la première requête est en cours.
+type IdempotentResponse struct {
+	RequestHash string
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// Réserve la clé pour l'auteur de la requête. Renvoie true si la requête
+// doit être exécutée, sinon l'enregistrement existant (en cours ou terminé).
+// Une clé expirée est remplacée.
+func (m IdempotencyModel) ReserveKey(principal, key, requestHash string, ttl time.Duration) (IdempotentResponse, bool, error) {
+	queryExpired := "DELETE FROM idempotency_keys WHERE principal = $1 AND key = $2 AND expires_at <= NOW()"
+	if _, err := m.DB.Exec(queryExpired, principal, key); err != nil {
+		return IdempotentResponse{}, false, err
+	}
+
+	queryInsert := `
+		INSERT INTO idempotency_keys (principal, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+		ON CONFLICT (principal, key) DO NOTHING`
+
+	res, err := m.DB.Exec(queryInsert, principal, key, requestHash, ttl.Seconds())
+	if err != nil {
+		return IdempotentResponse{}, false, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return IdempotentResponse{}, false, err
+	}
+	if inserted > 0 {
+		return IdempotentResponse{RequestHash: requestHash}, true, nil
+	}
+
+	existing, err := m.GetKey(principal, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Libérée entre-temps par une requête en échec : on retente
+		return m.ReserveKey(principal, key, requestHash, ttl)
+	}
+	return existing, false, err
+}
+
+// Renvoie l'enregistrement d'une clé, sql.ErrNoRows s'il n'existe pas ou a expiré
+func (m IdempotencyModel) GetKey(principal, key string) (IdempotentResponse, error) {
+	query := `
+		SELECT request_hash, COALESCE(status, 0), header, body
+		FROM idempotency_keys
+		WHERE principal = $1 AND key = $2 AND expires_at > NOW()`
+
+	var resp IdempotentResponse
+	var header []byte
+	err := m.DB.QueryRow(query, principal, key).Scan(&resp.RequestHash, &resp.Status, &header, &resp.Body)
+	if err != nil {
+		return IdempotentResponse{}, err
+	}
+
+	if header != nil {
+		if err := json.Unmarshal(header, &resp.Header); err != nil {
+			return IdempotentResponse{}, err
+		}
+	}
+
+	return resp, nil
+}
+
+// Enregistre la réponse de la première requête, rejouée ensuite pour la même clé
+func (m IdempotencyModel) CompleteKey(principal, key string, resp IdempotentResponse) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE idempotency_keys SET status = $3, header = $4, body = $5
+		WHERE principal = $1 AND key = $2`
+
+	_, err = m.DB.Exec(query, principal, key, resp.Status, header, resp.Body)
+	return err
+}
+
+// Libère une clé dont la requête a échoué, pour qu'elle puisse être retentée
+func (m IdempotencyModel) ReleaseKey(principal, key string) error {
+	_, err := m.DB.Exec("DELETE FROM idempotency_keys WHERE principal = $1 AND key = $2 AND status IS NULL", principal, key)
+	return err
+}
+
+// Purge les clés expirées, renvoie le nombre de clés supprimées
+func (m IdempotencyModel) DeleteExpiredKeys() (int64, error) {
+	res, err := m.DB.Exec("DELETE FROM idempotency_keys WHERE expires_at <= NOW()")
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Limites des affiches envoyées
+const (
+	MaxPosterBytes  = 10 << 20
+	minPosterWidth  = 200
+	minPosterHeight = 300
+	maxPosterPixels = 40_000_000 // protège contre les images "bombes" avant décodage
+)
+
+// Vignettes générées, nommées d'après leur largeur comme celles de TMDB
+var posterSizes = []struct {
+	name  string
+	width int
+}{
+	{"w185", 185},
+	{"w342", 342},
+	{"w500", 500},
+}
+
+type posterFormat struct {
+	name   string
+	decode func(io.Reader) (image.Image, error)
+	config func(io.Reader) (image.Config, error)
+}
+
+// Formats acceptés, par type détecté dans le contenu
+var posterFormats = map[string]posterFormat{
+	"image/jpeg": {"jpeg", jpeg.Decode, jpeg.DecodeConfig},
+	"image/png":  {"png", png.Decode, png.DecodeConfig},
+	"image/webp": {"webp", webp.Decode, webp.DecodeConfig},
+}
+
+var (
+	ErrUnsupportedImage = errors.New("poster must be a JPEG, PNG or WebP image")
+	ErrInvalidImage     = errors.New("poster could not be decoded")
+)
+
+// Affiche prête à être stockée : l'original tel qu'envoyé et ses vignettes en JPEG,
+// par taille. Poster.MovieID reste à renseigner.
+type ProcessedPoster struct {
+	Poster     Poster
+	Original   []byte
+	Thumbnails map[string][]byte
+}
+
+// Vérifie le type et les dimensions de l'image, puis génère les vignettes
+// plus petites que l'original. La version est tirée du contenu.
+func ProcessPoster(data []byte) (ProcessedPoster, error) {
+	format, ok := posterFormats[http.DetectContentType(data)]
+	if !ok {
+		return ProcessedPoster{}, ErrUnsupportedImage
+	}
+
+	cfg, err := format.config(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedPoster{}, ErrInvalidImage
+	}
+
+	if cfg.Width < minPosterWidth || cfg.Height < minPosterHeight {
+		return ProcessedPoster{}, fmt.Errorf("poster must be at least %dx%d pixels", minPosterWidth, minPosterHeight)
+	}
+	if cfg.Width*cfg.Height > maxPosterPixels {
+		return ProcessedPoster{}, fmt.Errorf("poster must not exceed %d megapixels", maxPosterPixels/1_000_000)
+	}
+
+	img, err := format.decode(bytes.NewReader(data))
+	if err != nil {
+		return ProcessedPoster{}, ErrInvalidImage
+	}
+
+	sum := sha256.Sum256(data)
+
+	processed := ProcessedPoster{
+		Poster: Poster{
+			Version: hex.EncodeToString(sum[:8]),
+			Format:  format.name,
+			Width:   cfg.Width,
+			Height:  cfg.Height,
+		},
+		Original:   data,
+		Thumbnails: make(map[string][]byte, len(posterSizes)),
+	}
+
+	for _, size := range posterSizes {
+		if size.width >= cfg.Width {
+			continue
+		}
+
+		thumb, err := resizeJPEG(img, size.width)
+		if err != nil {
+			return ProcessedPoster{}, err
+		}
+		processed.Thumbnails[size.name] = thumb
+		processed.Poster.Sizes = append(processed.Poster.Sizes, size.name)
+	}
+
+	return processed, nil
+}
+
+// Redimensionne l'image à la largeur donnée, en gardant les proportions
+func resizeJPEG(img image.Image, width int) ([]byte, error) {
+	bounds := img.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	// Fond blanc pour les images transparentes, le JPEG n'ayant pas de canal alpha
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
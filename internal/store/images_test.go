@@ -0,0 +1,102 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessPoster(t *testing.T) {
+	processed, err := ProcessPoster(encodePNG(t, 400, 600))
+	if err != nil {
+		t.Fatalf("ProcessPoster() error = %v", err)
+	}
+
+	p := processed.Poster
+	if p.Format != "png" || p.Width != 400 || p.Height != 600 || p.Version == "" {
+		t.Errorf("Affiche inattendue : %+v", p)
+	}
+
+	// Seules les vignettes plus petites que l'original sont générées
+	if len(p.Sizes) != 2 || p.Sizes[0] != "w185" || p.Sizes[1] != "w342" {
+		t.Fatalf("Vignettes inattendues : %v", p.Sizes)
+	}
+
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(processed.Thumbnails["w342"]))
+	if err != nil {
+		t.Fatalf("La vignette n'est pas un JPEG : %v", err)
+	}
+	if cfg.Width != 342 || cfg.Height != 513 {
+		t.Errorf("Dimensions de la vignette : %dx%d", cfg.Width, cfg.Height)
+	}
+}
+
+func TestProcessPoster_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Not an image", []byte("GIF89a pas vraiment une image")},
+		{"Truncated PNG", encodePNG(t, 400, 600)[:40]},
+		{"Too small", encodePNG(t, 100, 150)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ProcessPoster(tt.data); err == nil {
+				t.Error("ProcessPoster() devait renvoyer une erreur")
+			}
+		})
+	}
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	blobs, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := blobs.Put("posters/1/abc/w185.jpg", []byte("jpeg")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	blob, err := blobs.Get("posters/1/abc/w185.jpg")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(blob.Content)
+	blob.Content.Close()
+	if string(data) != "jpeg" || blob.ContentType != "image/jpeg" {
+		t.Errorf("Blob inattendu : %q (%s)", data, blob.ContentType)
+	}
+
+	for _, key := range []string{"../secret", "posters/../../secret", "/etc/passwd", "posters//1"} {
+		if _, err := blobs.Get(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+
+	if err := blobs.DeletePrefix("posters/1"); err != nil {
+		t.Fatalf("DeletePrefix() error = %v", err)
+	}
+	if _, err := blobs.Get("posters/1/abc/w185.jpg"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get() après suppression error = %v, want ErrBlobNotFound", err)
+	}
+}
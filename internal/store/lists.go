@@ -0,0 +1,347 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ListKindList       = "list"
+	ListKindCollection = "collection"
+)
+
+var ErrAlreadyInList = errors.New("movie is already in the list")
+
+type ListModel struct {
+	DB *sql.DB
+}
+
+// Liste ordonnée de films : sélection éditoriale ("list")
+// ou saga ("collection"). OwnerID vaut nil pour les listes
+// créées avec la clé API.
+type MovieList struct {
+	ID          int        `json:"id"`
+	OwnerID     *int       `json:"owner_id"`
+	OwnerName   *string    `json:"owner_name,omitempty"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	Kind        string     `json:"kind"`
+	IsPublic    bool       `json:"is_public"`
+	ItemCount   int        `json:"item_count"`
+	Items       []ListItem `json:"items,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Film d'une liste, Position commence à 1
+type ListItem struct {
+	Movie    Movie     `json:"movie"`
+	Position int       `json:"position"`
+	Note     *string   `json:"note,omitempty"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+const listColumns = `l.id, l.owner_id, u.name, l.name, l.description, l.kind, l.is_public,
+		(SELECT count(*) FROM movie_list_items i WHERE i.list_id = l.id), l.created_at, l.updated_at`
+
+func scanList(row interface{ Scan(...any) error }, l *MovieList, extra ...any) error {
+	dest := append(extra, &l.ID, &l.OwnerID, &l.OwnerName, &l.Name, &l.Description, &l.Kind, &l.IsPublic,
+		&l.ItemCount, &l.CreatedAt, &l.UpdatedAt)
+	return row.Scan(dest...)
+}
+
+// Crée une liste vide
+func (m ListModel) AddList(list MovieList) (MovieList, error) {
+	query := `
+		INSERT INTO movie_lists (owner_id, name, description, kind, is_public)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	var id int
+	err := m.DB.QueryRow(query, list.OwnerID, list.Name, list.Description, list.Kind, list.IsPublic).Scan(&id)
+	if err != nil {
+		return MovieList{}, err
+	}
+
+	return m.GetListByID(id)
+}
+
+// Renvoie une liste avec tous ses films, dans l'ordre
+func (m ListModel) GetListByID(id int) (MovieList, error) {
+	query := `
+		SELECT ` + listColumns + `
+		FROM movie_lists l
+		LEFT JOIN users u ON u.id = l.owner_id
+		WHERE l.id = $1`
+
+	var list MovieList
+	if err := scanList(m.DB.QueryRow(query, id), &list); err != nil {
+		return MovieList{}, err
+	}
+
+	queryItems := `
+		SELECT ` + listMovieColumns + `, i.position, i.note, i.added_at
+		FROM movie_list_items i
+		JOIN movies m ON m.id = i.movie_id
+		WHERE i.list_id = $1
+		ORDER BY i.position ASC`
+
+	rows, err := m.DB.Query(queryItems, id)
+	if err != nil {
+		return MovieList{}, err
+	}
+	defer rows.Close()
+
+	typeMap := pgtype.NewMap()
+	list.Items = []ListItem{}
+
+	for rows.Next() {
+		var item ListItem
+		mv := &item.Movie
+
+		err := rows.Scan(&mv.ID, &mv.Title, &mv.ReleaseYear, &mv.Rating, &mv.Review, &mv.CreatedAt, &mv.UpdatedAt,
+			typeMap.SQLScanner(&mv.Genres), &item.Position, &item.Note, &item.AddedAt)
+		if err != nil {
+			return MovieList{}, err
+		}
+		list.Items = append(list.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return MovieList{}, err
+	}
+
+	return list, nil
+}
+
+// Renvoie les listes (sans leurs films) visibles par viewerID : les listes
+// publiques et les siennes, ou seulement les siennes avec ownOnly.
+func (m ListModel) GetLists(searchName string, viewerID int, ownOnly bool, filters Filters) ([]MovieList, Metadata, error) {
+	visibility := "(l.is_public OR l.owner_id = $2)"
+	if ownOnly {
+		visibility = "l.owner_id = $2"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s
+		FROM movie_lists l
+		LEFT JOIN users u ON u.id = l.owner_id
+		WHERE l.name ILIKE '%%' || $1 || '%%' AND %s
+		ORDER BY l.updated_at DESC, l.id ASC
+		LIMIT $3 OFFSET $4`, listColumns, visibility)
+
+	rows, err := m.DB.Query(query, searchName, viewerID, filters.PageSize, (filters.Page-1)*filters.PageSize)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	lists := []MovieList{}
+
+	for rows.Next() {
+		var list MovieList
+		if err := scanList(rows, &list, &totalRecords); err != nil {
+			return nil, Metadata{}, err
+		}
+		lists = append(lists, list)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return lists, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Renvoie les listes visibles par viewerID qui contiennent le film
+func (m ListModel) GetMovieLists(movieID, viewerID int) ([]MovieList, error) {
+	query := `
+		SELECT ` + listColumns + `
+		FROM movie_lists l
+		JOIN movie_list_items i ON i.list_id = l.id
+		LEFT JOIN users u ON u.id = l.owner_id
+		WHERE i.movie_id = $1 AND (l.is_public OR l.owner_id = $2)
+		ORDER BY l.name ASC, l.id ASC`
+
+	rows, err := m.DB.Query(query, movieID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []MovieList{}
+	for rows.Next() {
+		var list MovieList
+		if err := scanList(rows, &list); err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
+
+// Modifie le nom, la description, le type et la visibilité d'une liste
+func (m ListModel) UpdateList(list MovieList) error {
+	query := `
+		UPDATE movie_lists SET name = $1, description = $2, kind = $3, is_public = $4, updated_at = NOW()
+		WHERE id = $5`
+
+	res, err := m.DB.Exec(query, list.Name, list.Description, list.Kind, list.IsPublic, list.ID)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(res)
+}
+
+func (m ListModel) DeleteList(id int) error {
+	res, err := m.DB.Exec("DELETE FROM movie_lists WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(res)
+}
+
+// Ajoute un film à la liste, à la position demandée ou à la fin si position vaut nil.
+// Renvoie sql.ErrNoRows si le film n'existe pas.
+func (m ListModel) AddListItem(listID, movieID int, position *int, note *string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO movie_list_items (list_id, movie_id, position, note)
+		VALUES ($1, $2, COALESCE($3::int,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM movie_list_items WHERE list_id = $1)), $4)`
+
+	if _, err := tx.Exec(query, listID, movieID, position, note); err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23503":
+			return sql.ErrNoRows
+		case isUniqueViolation(err):
+			return ErrAlreadyInList
+		}
+		return err
+	}
+
+	if err := renumberPositions(tx, "movie_list_items", "list_id", []int64{int64(listID)}, movieID, true); err != nil {
+		return err
+	}
+
+	if err := touchLists(tx, []int64{int64(listID)}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Déplace un film de la liste à la position demandée, les autres films
+// sont décalés. Renvoie sql.ErrNoRows si le film n'est pas dans la liste.
+func (m ListModel) MoveListItem(listID, movieID, position int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Verrouille la liste pendant la renumérotation
+	if _, err := tx.Exec("SELECT 1 FROM movie_lists WHERE id = $1 FOR UPDATE", listID); err != nil {
+		return err
+	}
+
+	var current int
+	queryCurrent := "SELECT position FROM movie_list_items WHERE list_id = $1 AND movie_id = $2"
+	if err := tx.QueryRow(queryCurrent, listID, movieID).Scan(&current); err != nil {
+		return err
+	}
+
+	queryMove := "UPDATE movie_list_items SET position = $1 WHERE list_id = $2 AND movie_id = $3"
+	if _, err := tx.Exec(queryMove, position, listID, movieID); err != nil {
+		return err
+	}
+
+	// En montant, le film passe avant celui qui occupait la place ; en descendant, après
+	ids := []int64{int64(listID)}
+	if err := renumberPositions(tx, "movie_list_items", "list_id", ids, movieID, position < current); err != nil {
+		return err
+	}
+
+	if err := touchLists(tx, ids); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Retire un film de la liste, renvoie sql.ErrNoRows s'il n'y était pas
+func (m ListModel) RemoveListItem(listID, movieID int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM movie_list_items WHERE list_id = $1 AND movie_id = $2", listID, movieID)
+	if err != nil {
+		return err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return err
+	}
+
+	ids := []int64{int64(listID)}
+	if err := renumberPositions(tx, "movie_list_items", "list_id", ids, 0, false); err != nil {
+		return err
+	}
+
+	if err := touchLists(tx, ids); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Marque les listes comme modifiées
+func touchLists(q dbtx, listIDs []int64) error {
+	if len(listIDs) == 0 {
+		return nil
+	}
+
+	_, err := q.Exec("UPDATE movie_lists SET updated_at = NOW() WHERE id = ANY($1::int[])", listIDs)
+	return err
+}
+
+// Vérifie que les données de la liste
+// respectent certaines règles métiers
+func (l *MovieList) Validate() error {
+	l.Name = strings.TrimSpace(l.Name)
+
+	if l.Name == "" || len(l.Name) > 100 {
+		return errors.New("name must be between 1 and 100 characters long")
+	}
+
+	if l.Description != nil && len(*l.Description) > 1000 {
+		return errors.New("description must not exceed 1000 characters")
+	}
+
+	if l.Kind == "" {
+		l.Kind = ListKindList
+	}
+	if l.Kind != ListKindList && l.Kind != ListKindCollection {
+		return errors.New("kind must be one of list, collection")
+	}
+
+	return nil
+}
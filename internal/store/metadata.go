@@ -0,0 +1,169 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Classifications acceptées : MPAA (États-Unis) et visa du CNC (France)
+var (
+	MPAARatings = []string{"G", "PG", "PG-13", "R", "NC-17"}
+	CNCRatings  = []string{"TP", "-12", "-16", "-18"}
+)
+
+var (
+	imdbIDPattern   = regexp.MustCompile(`^tt\d{7,10}$`)
+	languagePattern = regexp.MustCompile(`^[a-z]{2}$`) // ISO 639-1
+	countryPattern  = regexp.MustCompile(`^[A-Z]{2}$`) // ISO 3166-1 alpha-2
+)
+
+const (
+	maxOriginalTitleLength = 200
+	maxSynopsisLength      = 5000
+	maxTaglineLength       = 300
+	maxCountries           = 20
+)
+
+// Colonnes des métadonnées étendues, dans l'ordre de metadataDest et metadataArgs
+var metadataFields = []string{
+	"runtime", "original_title", "original_language", "countries", "mpaa_rating", "cnc_rating",
+	"synopsis", "tagline", "budget", "box_office", "imdb_id", "tmdb_id",
+}
+
+// Liste des colonnes de métadonnées, préfixées par l'alias de la table s'il y en a un
+func metadataColumns(alias string) string {
+	if alias == "" {
+		return strings.Join(metadataFields, ", ")
+	}
+	return alias + "." + strings.Join(metadataFields, ", "+alias+".")
+}
+
+// Paramètres des métadonnées à partir de $start : "$5, $6, ..." pour un INSERT,
+// "runtime = $5, ..." pour un UPDATE
+func metadataParams(start int, assign bool) string {
+	params := make([]string, len(metadataFields))
+	for i, field := range metadataFields {
+		params[i] = fmt.Sprintf("$%d", start+i)
+		if assign {
+			params[i] = field + " = " + params[i]
+		}
+	}
+	return strings.Join(params, ", ")
+}
+
+// Destinations du Scan des colonnes de metadataColumns
+func (m *Movie) metadataDest(typeMap *pgtype.Map) []any {
+	return []any{
+		&m.Runtime, &m.OriginalTitle, &m.OriginalLanguage, typeMap.SQLScanner(&m.Countries), &m.MPAARating, &m.CNCRating,
+		&m.Synopsis, &m.Tagline, &m.Budget, &m.BoxOffice, &m.IMDbID, &m.TMDBID,
+	}
+}
+
+// Valeurs des colonnes de metadataColumns pour un INSERT ou un UPDATE
+func (m Movie) metadataArgs() []any {
+	countries := m.Countries
+	if countries == nil {
+		countries = []string{}
+	}
+
+	return []any{
+		m.Runtime, m.OriginalTitle, m.OriginalLanguage, countries, m.MPAARating, m.CNCRating,
+		m.Synopsis, m.Tagline, m.Budget, m.BoxOffice, m.IMDbID, m.TMDBID,
+	}
+}
+
+// Vérifie les métadonnées étendues et normalise leur écriture
+// (codes en minuscules ou majuscules, chaînes vides ramenées à nil)
+func (m *Movie) validateMetadata() error {
+	for _, s := range []**string{&m.OriginalTitle, &m.OriginalLanguage, &m.MPAARating, &m.CNCRating, &m.Synopsis, &m.Tagline, &m.IMDbID} {
+		trimOptional(s)
+	}
+
+	if m.Runtime != nil && *m.Runtime <= 0 {
+		return errors.New("runtime must be a positive number of minutes")
+	}
+
+	if m.OriginalTitle != nil && len(*m.OriginalTitle) > maxOriginalTitleLength {
+		return fmt.Errorf("original_title must not exceed %d characters", maxOriginalTitleLength)
+	}
+
+	if m.OriginalLanguage != nil {
+		*m.OriginalLanguage = strings.ToLower(*m.OriginalLanguage)
+		if !languagePattern.MatchString(*m.OriginalLanguage) {
+			return errors.New("original_language must be an ISO 639-1 code (e.g. en, fr)")
+		}
+	}
+
+	if len(m.Countries) > maxCountries {
+		return fmt.Errorf("countries must not contain more than %d entries", maxCountries)
+	}
+	countries := make([]string, 0, len(m.Countries))
+	for _, c := range m.Countries {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if !countryPattern.MatchString(c) {
+			return fmt.Errorf("country '%s' must be an ISO 3166-1 alpha-2 code (e.g. US, FR)", c)
+		}
+		if !slices.Contains(countries, c) {
+			countries = append(countries, c)
+		}
+	}
+	m.Countries = countries
+
+	if m.MPAARating != nil {
+		*m.MPAARating = strings.ToUpper(*m.MPAARating)
+		if !slices.Contains(MPAARatings, *m.MPAARating) {
+			return fmt.Errorf("mpaa_rating must be one of %s", strings.Join(MPAARatings, ", "))
+		}
+	}
+
+	if m.CNCRating != nil {
+		*m.CNCRating = strings.ToUpper(*m.CNCRating)
+		if !slices.Contains(CNCRatings, *m.CNCRating) {
+			return fmt.Errorf("cnc_rating must be one of %s", strings.Join(CNCRatings, ", "))
+		}
+	}
+
+	if m.Synopsis != nil && len(*m.Synopsis) > maxSynopsisLength {
+		return fmt.Errorf("synopsis must not exceed %d characters", maxSynopsisLength)
+	}
+
+	if m.Tagline != nil && len(*m.Tagline) > maxTaglineLength {
+		return fmt.Errorf("tagline must not exceed %d characters", maxTaglineLength)
+	}
+
+	if m.Budget != nil && *m.Budget < 0 {
+		return errors.New("budget must not be negative")
+	}
+
+	if m.BoxOffice != nil && *m.BoxOffice < 0 {
+		return errors.New("box_office must not be negative")
+	}
+
+	if m.IMDbID != nil && !imdbIDPattern.MatchString(*m.IMDbID) {
+		return errors.New("imdb_id must look like tt0133093")
+	}
+
+	if m.TMDBID != nil && *m.TMDBID <= 0 {
+		return errors.New("tmdb_id must be a positive integer")
+	}
+
+	return nil
+}
+
+// Retire les espaces autour de la chaîne, et la remplace par nil si elle est vide
+func trimOptional(s **string) {
+	if *s == nil {
+		return
+	}
+	trimmed := strings.TrimSpace(**s)
+	if trimmed == "" {
+		*s = nil
+		return
+	}
+	*s = &trimmed
+}
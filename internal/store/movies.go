@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
@@ -14,13 +15,51 @@ type MovieModel struct {
 	DB *sql.DB
 }
 
+var ErrGenreNotFound = errors.New("genre not found")
+
+const maxReviewLength = 1000
+
+// Interface commune à *sql.DB et *sql.Tx, pour partager
+// les requêtes entre les appels simples et les transactions
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 type Movie struct {
 	ID          int      `json:"id"`
 	Title       string   `json:"title"`
 	ReleaseYear int      `json:"release_year"`
 	Rating      *float64 `json:"rating"`
-	Review      *string  `json:"review"`
+	Review      *string  `json:"review"` // critique éditoriale
 	Genres      []string `json:"genres"`
+	// Métadonnées étendues, toutes facultatives
+	Runtime          *int     `json:"runtime"` // en minutes
+	OriginalTitle    *string  `json:"original_title"`
+	OriginalLanguage *string  `json:"original_language"` // ISO 639-1
+	Countries        []string `json:"countries"`         // ISO 3166-1 alpha-2
+	MPAARating       *string  `json:"mpaa_rating"`
+	CNCRating        *string  `json:"cnc_rating"`
+	Synopsis         *string  `json:"synopsis"`
+	Tagline          *string  `json:"tagline"`
+	Budget           *int64   `json:"budget"`     // en dollars US
+	BoxOffice        *int64   `json:"box_office"` // recettes mondiales, en dollars US
+	IMDbID           *string  `json:"imdb_id"`
+	TMDBID           *int     `json:"tmdb_id"`
+
+	Credits     []Credit       `json:"credits,omitempty"`
+	UserRatings *RatingSummary `json:"user_ratings,omitempty"`
+	Poster      *Poster        `json:"poster,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// Trace d'un film supprimé, pour que les clients
+// qui synchronisent le catalogue puissent l'effacer
+type DeletedMovie struct {
+	ID        int       `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
 }
 
 type Filters struct {
@@ -28,6 +67,23 @@ type Filters struct {
 	PageSize     int
 	Sort         string
 	SortSafelist []string
+	UpdatedSince *time.Time
+	Director     string
+	Actor        string
+	// Métadonnées étendues
+	Language      string
+	Country       string
+	Certification string // MPAA ou CNC
+	MinRuntime    *int
+	MaxRuntime    *int
+	IMDbID        string
+	TMDBID        *int
+	// Filtres personnels, appliqués pour l'utilisateur UserID
+	UserID      int
+	Watched     *bool
+	InWatchlist *bool
+	// Champs lus en base (?fields=), tous si vide
+	Fields []string
 }
 
 type Metadata struct {
@@ -43,34 +99,20 @@ type Metadata struct {
 // Renvoie la liste des films (recherchés si searchTitle
 // n'est pas vide ainsi que l'erreur s'il y'en a une.
 func (m MovieModel) GetMovies(searchTitle string, filters Filters) ([]Movie, Metadata, error) {
-	orderBy := "id"
-	direction := "ASC"
-
-	if filters.Sort != "" {
-		if strings.HasPrefix(filters.Sort, "-") {
-			direction = "DESC"
-			filters.Sort = strings.TrimPrefix(filters.Sort, "-")
-		}
-
-		for _, safeValue := range filters.SortSafelist {
-			if filters.Sort == safeValue {
-				orderBy = safeValue
-				break
-			}
-		}
-	}
-
 	limit := filters.PageSize
 	offset := (filters.Page - 1) * filters.PageSize
 
+	where, args := movieConditions(searchTitle, filters, nil)
+	args = append(args, limit, offset)
+
 	query := fmt.Sprintf(`
-		SELECT count(*) OVER(), id, title, release_year, ROUND(rating::numeric, 1), review 
-		FROM movies 
-		WHERE title ILIKE '%%' || $1 || '%%' 
-		ORDER BY %s %s, id ASC
-		LIMIT $2 OFFSET $3`, orderBy, direction)
+		SELECT count(*) OVER(), %s
+		FROM movies m
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, movieColumns(filters.Fields), where, filters.orderBy(), len(args)-1, len(args))
 
-	rows, err := m.DB.Query(query, searchTitle, limit, offset)
+	rows, err := m.DB.Query(query, args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
@@ -79,12 +121,11 @@ func (m MovieModel) GetMovies(searchTitle string, filters Filters) ([]Movie, Met
 
 	totalRecords := 0
 	var moviesList []Movie
+	typeMap := pgtype.NewMap()
 
 	for rows.Next() {
 		var m Movie
-
-		err := rows.Scan(&totalRecords, &m.ID, &m.Title, &m.ReleaseYear, &m.Rating, &m.Review)
-		if err != nil {
+		if err := rows.Scan(append([]any{&totalRecords}, m.fieldsDest(filters.Fields, typeMap)...)...); err != nil {
 			return nil, Metadata{}, err
 		}
 		moviesList = append(moviesList, m)
@@ -99,6 +140,82 @@ func (m MovieModel) GetMovies(searchTitle string, filters Filters) ([]Movie, Met
 	return moviesList, metadata, nil
 }
 
+// Renvoie les films demandés, sans leurs genres, dans l'ordre des IDs.
+// Les IDs inconnus sont ignorés.
+func (m MovieModel) GetMoviesByIDs(ids []int) ([]Movie, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM unnest($1::int[]) WITH ORDINALITY AS wanted(id, position)
+		JOIN movies m ON m.id = wanted.id
+		ORDER BY wanted.position`, movieListColumns())
+
+	movies := []Movie{}
+	typeMap := pgtype.NewMap()
+
+	err := scanRows(m.DB, query, []any{ids}, func(rows *sql.Rows) error {
+		var movie Movie
+		if err := rows.Scan(movie.listDest(typeMap)...); err != nil {
+			return err
+		}
+		movies = append(movies, movie)
+		return nil
+	})
+
+	return movies, err
+}
+
+// Colonnes des listes de films (sans les genres), lues par listDest
+func movieListColumns() string {
+	return movieColumns(nil)
+}
+
+func (m *Movie) listDest(typeMap *pgtype.Map) []any {
+	return m.fieldsDest(nil, typeMap)
+}
+
+// Parcourt tous les films correspondant à la recherche, genres compris,
+// sans pagination, et appelle fn pour chacun d'eux au fil de la lecture.
+// S'arrête et renvoie l'erreur de fn si elle en renvoie une.
+func (m MovieModel) ExportMovies(searchTitle string, filters Filters, fn func(Movie) error) error {
+	where, args := movieConditions(searchTitle, filters, nil)
+
+	query := fmt.Sprintf(`
+		SELECT m.id, m.title, m.release_year, ROUND(m.rating::numeric, 1), m.review, m.created_at, m.updated_at,
+			COALESCE(array_agg(g.name ORDER BY g.name) FILTER (WHERE g.id IS NOT NULL), '{}'), %s
+		FROM movies m
+		LEFT JOIN movie_genres mg ON mg.movie_id = m.id
+		LEFT JOIN genres g ON g.id = mg.genre_id
+		%s
+		GROUP BY m.id
+		ORDER BY %s`, metadataColumns("m"), where, filters.orderBy())
+
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Permet de lire le tableau text[] des genres via database/sql
+	// (une Map par requête, elle n'est pas faite pour être partagée)
+	typeMap := pgtype.NewMap()
+
+	for rows.Next() {
+		var movie Movie
+
+		dest := []any{&movie.ID, &movie.Title, &movie.ReleaseYear, &movie.Rating, &movie.Review,
+			&movie.CreatedAt, &movie.UpdatedAt, typeMap.SQLScanner(&movie.Genres)}
+		if err := rows.Scan(append(dest, movie.metadataDest(typeMap)...)...); err != nil {
+			return err
+		}
+
+		if err := fn(movie); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 // Ajoute un film et lui attribut un ID,
 // renvoie ce même film et nil si l'ajour est bien fait,
 // une struct Movie vide et une erreur sinon.
@@ -109,21 +226,21 @@ func (m MovieModel) AddMovie(movie Movie) (Movie, error) {
 	}
 	defer tx.Rollback()
 
+	if err := findDuplicate(tx, movie.Title, movie.ReleaseYear, 0); err != nil {
+		return Movie{}, err
+	}
+
 	queryMovie := `
-		INSERT INTO movies (title, release_year, rating, review)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id`
-
-	err = tx.QueryRow(
-		queryMovie,
-		movie.Title,
-		movie.ReleaseYear,
-		movie.Rating,
-		movie.Review,
-	).Scan(&movie.ID)
+		INSERT INTO movies (title, release_year, rating, review, ` + metadataColumns("") + `)
+		VALUES ($1, $2, $3, $4, ` + metadataParams(5, false) + `)
+		RETURNING id, created_at, updated_at`
+
+	args := append([]any{movie.Title, movie.ReleaseYear, movie.Rating, movie.Review}, movie.metadataArgs()...)
+
+	err = tx.QueryRow(queryMovie, args...).Scan(&movie.ID, &movie.CreatedAt, &movie.UpdatedAt)
 
 	if err != nil {
-		return Movie{}, err
+		return Movie{}, translateDuplicate(err)
 	}
 
 	for _, genreName := range movie.Genres {
@@ -132,7 +249,7 @@ func (m MovieModel) AddMovie(movie Movie) (Movie, error) {
 
 		err = tx.QueryRow(queryGetGenre, genreName).Scan(&genreID)
 		if err != nil {
-			return Movie{}, fmt.Errorf("genre '%s' not found", genreName)
+			return Movie{}, fmt.Errorf("%w: '%s'", ErrGenreNotFound, genreName)
 		}
 
 		queryLink := "INSERT INTO movie_genres (movie_id, genre_id) VALUES ($1, $2)"
@@ -143,6 +260,10 @@ func (m MovieModel) AddMovie(movie Movie) (Movie, error) {
 		}
 	}
 
+	if err := recordMovieEvents(tx, movieEvent{Type: EventMovieCreated, MovieID: movie.ID}); err != nil {
+		return Movie{}, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return Movie{}, err
 	}
@@ -157,13 +278,28 @@ func (m MovieModel) GetMoviebyID(id int) (Movie, error) {
 	return m.getMovieWithGenresSimple(id)
 }
 
+// Lit seulement les champs demandés d'un film (tous si fields est vide),
+// sans ses relations, renvoie sql.ErrNoRows s'il n'existe pas.
+func (m MovieModel) GetMovieFields(id int, fields []string) (Movie, error) {
+	query := "SELECT " + movieColumns(fields) + " FROM movies m WHERE m.id = $1"
+
+	var movie Movie
+	err := m.DB.QueryRow(query, id).Scan(movie.fieldsDest(fields, pgtype.NewMap())...)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	return movie, nil
+}
+
 func (m MovieModel) getMovieWithGenresSimple(id int) (Movie, error) {
 	queryMovie := `
-        SELECT id, title, release_year, ROUND(rating::numeric, 1), review
-        FROM movies WHERE id = $1`
+        SELECT m.id, m.title, m.release_year, ROUND(m.rating::numeric, 1), m.review, m.created_at, m.updated_at, ` + metadataColumns("m") + `
+        FROM movies m WHERE m.id = $1`
 
 	var movie Movie
-	err := m.DB.QueryRow(queryMovie, id).Scan(&movie.ID, &movie.Title, &movie.ReleaseYear, &movie.Rating, &movie.Review)
+	dest := []any{&movie.ID, &movie.Title, &movie.ReleaseYear, &movie.Rating, &movie.Review, &movie.CreatedAt, &movie.UpdatedAt}
+	err := m.DB.QueryRow(queryMovie, id).Scan(append(dest, movie.metadataDest(pgtype.NewMap())...)...)
 	if err != nil {
 		return Movie{}, err
 	}
@@ -189,47 +325,191 @@ func (m MovieModel) getMovieWithGenresSimple(id int) (Movie, error) {
 	}
 	movie.Genres = genres
 
+	movie.Credits, err = getMovieCredits(m.DB, id)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	movie.UserRatings, err = getRatingSummary(m.DB, id)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	poster, err := getPoster(m.DB, id)
+	switch {
+	case err == nil:
+		movie.Poster = &poster
+	case !errors.Is(err, sql.ErrNoRows):
+		return Movie{}, err
+	}
+
 	return movie, nil
 }
 
-// Supprime un film par son ID,
-// renvoie une erreur si elle n'a pas
+// Supprime un film par son ID et garde une trace
+// de la suppression, renvoie une erreur si elle n'a pas
 // pu supprimer ce film, nil sinon.
 func (m MovieModel) DeleteMovie(id int) error {
-	query := "DELETE FROM movies WHERE id = $1"
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	res, err := m.DB.Exec(query, id)
+	event, err := deleteMovie(tx, id)
 	if err != nil {
 		return err
 	}
 
+	if err := recordMovieEvents(tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Supprime le film et renvoie son événement de suppression,
+// à enregistrer par l'appelant avant le commit
+func deleteMovie(q dbtx, id int) (movieEvent, error) {
+	// Listes qui contiennent le film, à renuméroter une fois qu'il en est retiré
+	owners := make([][]int64, len(positionedTables))
+	for i, t := range positionedTables {
+		ids, err := positionOwners(q, t.table, t.owner, id)
+		if err != nil {
+			return movieEvent{}, err
+		}
+		owners[i] = ids
+	}
+
+	event, err := deletedMovieEvent(q, id)
+	if err != nil {
+		return movieEvent{}, err
+	}
+
+	query := "DELETE FROM movies WHERE id = $1"
+
+	res, err := q.Exec(query, id)
+	if err != nil {
+		return movieEvent{}, err
+	}
+
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		return err
+		return movieEvent{}, err
 	}
 
 	if rowsAffected == 0 {
-		return sql.ErrNoRows
+		return movieEvent{}, sql.ErrNoRows
 	}
 
-	return nil
+	queryTombstone := `
+		INSERT INTO movie_tombstones (movie_id, deleted_at)
+		VALUES ($1, NOW())
+		ON CONFLICT (movie_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at`
+
+	if _, err := q.Exec(queryTombstone, id); err != nil {
+		return movieEvent{}, err
+	}
+
+	for i, t := range positionedTables {
+		if err := renumberPositions(q, t.table, t.owner, owners[i], 0, false); err != nil {
+			return movieEvent{}, err
+		}
+		if t.table == "movie_list_items" {
+			if err := touchLists(q, owners[i]); err != nil {
+				return movieEvent{}, err
+			}
+		}
+	}
+
+	return event, nil
+}
+
+// Date du dernier changement du catalogue : modification ou suppression
+// d'un film, zéro s'il est vide. Elle couvre tout le catalogue et pas
+// seulement une recherche, pour qu'un film qui entre dans la recherche
+// ou en sort, ou qui décale les pages, la fasse aussi avancer.
+func (m MovieModel) LastModified() (time.Time, error) {
+	query := `
+		SELECT GREATEST(
+			(SELECT MAX(updated_at) FROM movies),
+			(SELECT MAX(deleted_at) FROM movie_tombstones))`
+
+	var latest sql.NullTime
+	if err := m.DB.QueryRow(query).Scan(&latest); err != nil {
+		return time.Time{}, err
+	}
+	return latest.Time, nil
+}
+
+// Renvoie les films supprimés après la date donnée,
+// du plus ancien au plus récent.
+func (m MovieModel) GetDeletedMovies(since time.Time) ([]DeletedMovie, error) {
+	query := `
+		SELECT movie_id, deleted_at
+		FROM movie_tombstones
+		WHERE deleted_at > $1
+		ORDER BY deleted_at ASC, movie_id ASC`
+
+	rows, err := m.DB.Query(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted := []DeletedMovie{}
+	for rows.Next() {
+		var d DeletedMovie
+		if err := rows.Scan(&d.ID, &d.DeletedAt); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, d)
+	}
+
+	return deleted, rows.Err()
 }
 
 // Met à jour tous les champs d'un Movie,
 // renvoie une erreur s'il l'update ne s'est pas fait.
 func (m MovieModel) UpdateMovie(movie Movie) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateMovie(tx, movie); err != nil {
+		return err
+	}
+
+	if err := recordMovieEvents(tx, movieEvent{Type: EventMovieUpdated, MovieID: movie.ID}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func updateMovie(q dbtx, movie Movie) error {
+	if err := findDuplicate(q, movie.Title, movie.ReleaseYear, movie.ID); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE movies
-		SET title = $1, release_year = $2, rating = $3, review = $4
+		SET title = $1, release_year = $2, rating = $3, review = $4, updated_at = NOW(), ` + metadataParams(6, true) + `
 		WHERE id = $5`
 
 	// On execute le query avec les arguments
-	res, err := m.DB.Exec(query, movie.Title, movie.ReleaseYear, movie.Rating, movie.Review, movie.ID)
+	args := append([]any{movie.Title, movie.ReleaseYear, movie.Rating, movie.Review, movie.ID}, movie.metadataArgs()...)
+	res, err := q.Exec(query, args...)
 	if err != nil {
-		return err
+		return translateDuplicate(err)
 	}
 	// On vérifie que la commande a bien modifié une ligne
 	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
 	if rowsAffected == 0 {
 		return sql.ErrNoRows
 	}
@@ -266,12 +546,139 @@ func (m *Movie) Validate() error {
 
 	// Règle Review : peut être vide mais ne peut exceder 1000 caractères
 	if m.Review != nil {
-		if len(*m.Review) > 1000 {
+		if len(*m.Review) > maxReviewLength {
 			return errors.New("review must not exceed 1000 characters")
 		}
 	}
 
-	return nil
+	return m.validateMetadata()
+}
+
+// Construit la clause WHERE commune aux requêtes qui listent des films
+// (la table movies doit avoir l'alias m). Les valeurs sont ajoutées
+// à la suite de args, qui est renvoyé complété.
+func movieConditions(searchTitle string, f Filters, args []any) (string, []any) {
+	param := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Le titre recherché peut être celui du film ou l'une de ses traductions
+	title := param(searchTitle)
+	conditions := []string{
+		fmt.Sprintf(`(m.title ILIKE '%%' || %s || '%%' OR EXISTS (
+			SELECT 1 FROM movie_translations t
+			WHERE t.movie_id = m.id AND t.title ILIKE '%%' || %s || '%%'))`, title, title),
+	}
+
+	if f.UpdatedSince != nil {
+		conditions = append(conditions, "m.updated_at > "+param(*f.UpdatedSince))
+	}
+
+	// Une personne se cherche par son ID ou par une partie de son nom
+	credits := []struct{ role, person string }{
+		{CreditDirector, f.Director},
+		{CreditActor, f.Actor},
+	}
+	for _, credit := range credits {
+		if credit.person == "" {
+			continue
+		}
+		role, p := credit.role, param(credit.person)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM movie_credits c JOIN people p ON p.id = c.person_id
+			WHERE c.movie_id = m.id AND c.role = '%s'
+			AND (p.id::text = %s OR p.name ILIKE '%%' || %s || '%%'))`, role, p, p))
+	}
+
+	if f.Language != "" {
+		conditions = append(conditions, "m.original_language = "+param(strings.ToLower(f.Language)))
+	}
+	if f.Country != "" {
+		conditions = append(conditions, param(strings.ToUpper(f.Country))+" = ANY(m.countries)")
+	}
+	if f.Certification != "" {
+		c := param(strings.ToUpper(f.Certification))
+		conditions = append(conditions, fmt.Sprintf("(m.mpaa_rating = %s OR m.cnc_rating = %s)", c, c))
+	}
+	if f.MinRuntime != nil {
+		conditions = append(conditions, "m.runtime >= "+param(*f.MinRuntime))
+	}
+	if f.MaxRuntime != nil {
+		conditions = append(conditions, "m.runtime <= "+param(*f.MaxRuntime))
+	}
+	if f.IMDbID != "" {
+		conditions = append(conditions, "m.imdb_id = "+param(f.IMDbID))
+	}
+	if f.TMDBID != nil {
+		conditions = append(conditions, "m.tmdb_id = "+param(*f.TMDBID))
+	}
+
+	personal := []struct {
+		table string
+		value *bool
+	}{
+		{"watched_movies", f.Watched},
+		{"watchlist_items", f.InWatchlist},
+	}
+	for _, filter := range personal {
+		if filter.value == nil {
+			continue
+		}
+		exists := "EXISTS"
+		if !*filter.value {
+			exists = "NOT EXISTS"
+		}
+		conditions = append(conditions, fmt.Sprintf(
+			"%s (SELECT 1 FROM %s x WHERE x.movie_id = m.id AND x.user_id = %s)",
+			exists, filter.table, param(f.UserID)))
+	}
+
+	return "WHERE " + strings.Join(conditions, "\n\t\tAND "), args
+}
+
+// Expressions des tris qui ne sont pas de simples colonnes de movies
+var sortExpressions = map[string]string{
+	"average_rating":  averageRatingSQL,
+	"rating_count":    "m.user_rating_count",
+	"weighted_rating": weightedRatingSQL,
+}
+
+// Renvoie la colonne et le sens du tri demandé,
+// "id ASC" si la colonne n'est pas dans la SortSafelist
+func (f Filters) sortColumn() (string, string) {
+	orderBy := "id"
+	direction := "ASC"
+
+	if f.Sort != "" {
+		sort := f.Sort
+		if strings.HasPrefix(sort, "-") {
+			direction = "DESC"
+			sort = strings.TrimPrefix(sort, "-")
+		}
+
+		for _, safeValue := range f.SortSafelist {
+			if sort == safeValue {
+				orderBy = safeValue
+				break
+			}
+		}
+	}
+
+	return orderBy, direction
+}
+
+// Renvoie la clause ORDER BY (sans le mot-clé) pour la table movies
+// d'alias m. Les films sans valeur sont toujours rangés à la fin.
+func (f Filters) orderBy() string {
+	column, direction := f.sortColumn()
+
+	expr, ok := sortExpressions[column]
+	if !ok {
+		expr = "m." + column
+	}
+
+	return fmt.Sprintf("%s %s NULLS LAST, m.id ASC", expr, direction)
 }
 
 // Permet de calculer les métadonnées
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -36,6 +37,42 @@ func TestMovie_Validate(t *testing.T) {
 			movie:   Movie{Title: "Future", ReleaseYear: time.Now().Year() + 5},
 			wantErr: true,
 		},
+		{
+			name: "Valid Metadata",
+			movie: Movie{Title: "The Matrix", ReleaseYear: 1999, Runtime: ptr(136), OriginalLanguage: ptr("EN"),
+				Countries: []string{"us", "AU"}, MPAARating: ptr("R"), CNCRating: ptr("-12"), IMDbID: ptr("tt0133093")},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid IMDb ID",
+			movie:   Movie{Title: "The Matrix", ReleaseYear: 1999, IMDbID: ptr("0133093")},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Language",
+			movie:   Movie{Title: "The Matrix", ReleaseYear: 1999, OriginalLanguage: ptr("eng")},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Country",
+			movie:   Movie{Title: "The Matrix", ReleaseYear: 1999, Countries: []string{"USA"}},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Certification",
+			movie:   Movie{Title: "The Matrix", ReleaseYear: 1999, MPAARating: ptr("X")},
+			wantErr: true,
+		},
+		{
+			name:    "Negative Budget",
+			movie:   Movie{Title: "The Matrix", ReleaseYear: 1999, Budget: ptr(int64(-1))},
+			wantErr: true,
+		},
+		{
+			name:    "Zero Runtime",
+			movie:   Movie{Title: "The Matrix", ReleaseYear: 1999, Runtime: ptr(0)},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -52,3 +89,25 @@ func TestMovie_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestMovie_ValidateNormalizesMetadata(t *testing.T) {
+	movie := Movie{Title: "Amélie", ReleaseYear: 2001, OriginalLanguage: ptr(" FR "), Countries: []string{"fr", "de", "FR"}, Tagline: ptr("  ")}
+
+	if err := movie.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	if *movie.OriginalLanguage != "fr" {
+		t.Errorf("original_language = %q, want fr", *movie.OriginalLanguage)
+	}
+	if strings.Join(movie.Countries, ",") != "FR,DE" {
+		t.Errorf("countries = %v, want [FR DE]", movie.Countries)
+	}
+	if movie.Tagline != nil {
+		t.Errorf("tagline = %q, want nil", *movie.Tagline)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
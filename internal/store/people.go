@@ -0,0 +1,219 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type PersonModel struct {
+	DB *sql.DB
+}
+
+type Person struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	BirthYear *int      `json:"birth_year"`
+	Biography *string   `json:"biography"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Un film auquel une personne a participé, et à quel titre
+type FilmographyEntry struct {
+	Movie        MovieRef `json:"movie"`
+	Role         string   `json:"role"`
+	Character    *string  `json:"character,omitempty"`
+	BillingOrder *int     `json:"billing_order,omitempty"`
+}
+
+// Ajoute une personne et lui attribue un ID.
+func (m PersonModel) AddPerson(person Person) (Person, error) {
+	query := `
+		INSERT INTO people (name, birth_year, biography)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`
+
+	err := m.DB.QueryRow(query, person.Name, person.BirthYear, person.Biography).
+		Scan(&person.ID, &person.CreatedAt, &person.UpdatedAt)
+	if err != nil {
+		return Person{}, err
+	}
+
+	return person, nil
+}
+
+// Recherche une personne par son ID,
+// renvoie sql.ErrNoRows si elle n'existe pas.
+func (m PersonModel) GetPersonByID(id int) (Person, error) {
+	query := `
+		SELECT id, name, birth_year, biography, created_at, updated_at
+		FROM people WHERE id = $1`
+
+	var person Person
+	err := m.DB.QueryRow(query, id).Scan(&person.ID, &person.Name, &person.BirthYear,
+		&person.Biography, &person.CreatedAt, &person.UpdatedAt)
+	if err != nil {
+		return Person{}, err
+	}
+
+	return person, nil
+}
+
+// Renvoie la liste paginée des personnes dont
+// le nom contient searchName (toutes si vide).
+func (m PersonModel) GetPeople(searchName string, filters Filters) ([]Person, Metadata, error) {
+	limit := filters.PageSize
+	offset := (filters.Page - 1) * filters.PageSize
+
+	query := `
+		SELECT count(*) OVER(), id, name, birth_year, biography, created_at, updated_at
+		FROM people
+		WHERE name ILIKE '%' || $1 || '%'
+		ORDER BY name ASC, id ASC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := m.DB.Query(query, searchName, limit, offset)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	people := []Person{}
+
+	for rows.Next() {
+		var p Person
+		err := rows.Scan(&totalRecords, &p.ID, &p.Name, &p.BirthYear, &p.Biography, &p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		people = append(people, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return people, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Met à jour tous les champs d'une personne et marque ses films comme
+// modifiés (leur générique affiche son nom). Renvoie sql.ErrNoRows si elle n'existe pas.
+func (m PersonModel) UpdatePerson(person Person) error {
+	query := `
+		WITH touched AS (` + touchPersonMovies("$4") + `)
+		UPDATE people
+		SET name = $1, birth_year = $2, biography = $3, updated_at = NOW()
+		WHERE id = $4`
+
+	res, err := m.DB.Exec(query, person.Name, person.BirthYear, person.Biography, person.ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Supprime une personne et tous ses crédits, et marque ses films comme
+// modifiés. Renvoie sql.ErrNoRows si elle n'existe pas.
+func (m PersonModel) DeletePerson(id int) error {
+	query := `
+		WITH touched AS (` + touchPersonMovies("$1") + `)
+		DELETE FROM people WHERE id = $1`
+
+	res, err := m.DB.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Requête qui marque comme modifiés les films dont le générique contient
+// la personne du paramètre param ($1...)
+func touchPersonMovies(param string) string {
+	return "UPDATE movies SET updated_at = NOW() WHERE id IN (SELECT movie_id FROM movie_credits WHERE person_id = " + param + ")"
+}
+
+// Renvoie les films d'une personne, du plus récent au plus ancien,
+// ou sql.ErrNoRows si la personne n'existe pas.
+func (m PersonModel) GetFilmography(id int) ([]FilmographyEntry, error) {
+	var exists bool
+	if err := m.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM people WHERE id = $1)", id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+
+	query := `
+		SELECT m.id, m.title, m.release_year, c.role, c.character_name, c.billing_order
+		FROM movie_credits c
+		JOIN movies m ON m.id = c.movie_id
+		WHERE c.person_id = $1
+		ORDER BY m.release_year DESC, m.title ASC, ` + creditRoleOrder
+
+	rows, err := m.DB.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []FilmographyEntry{}
+	for rows.Next() {
+		var e FilmographyEntry
+		err := rows.Scan(&e.Movie.ID, &e.Movie.Title, &e.Movie.ReleaseYear, &e.Role, &e.Character, &e.BillingOrder)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Vérifie que les données de la personne
+// respectent certaines règles métiers
+func (p *Person) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+
+	// Règle Nom : entre 1 et 100 caractères
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(p.Name) > 100 {
+		return errors.New("name must not exceed 100 characters")
+	}
+
+	// Règle Année de naissance : entre 1800 et l'année courante (peut être vide)
+	if p.BirthYear != nil {
+		if *p.BirthYear < 1800 || *p.BirthYear > time.Now().Year() {
+			return errors.New("birth_year must be between 1800 and the current year")
+		}
+	}
+
+	// Règle Biographie : peut être vide mais ne peut exceder 2000 caractères
+	if p.Biography != nil && len(*p.Biography) > 2000 {
+		return errors.New("biography must not exceed 2000 characters")
+	}
+
+	return nil
+}
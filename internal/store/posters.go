@@ -0,0 +1,158 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Préfixe des URL des fichiers servis depuis le BlobStore
+const ImagesURLPrefix = "/images/"
+
+type PosterModel struct {
+	DB *sql.DB
+}
+
+// Affiche d'un film. Les fichiers sont rangés sous une version tirée
+// de leur contenu : une URL ne change jamais de contenu et peut être
+// mise en cache indéfiniment.
+type Poster struct {
+	MovieID    int               `json:"-"`
+	Version    string            `json:"-"`
+	Format     string            `json:"format"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	Sizes      []string          `json:"-"` // vignettes disponibles (w185, w342, w500)
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// Renvoie l'affiche du film, sql.ErrNoRows s'il n'en a pas
+func (m PosterModel) GetPoster(movieID int) (Poster, error) {
+	return getPoster(m.DB, movieID)
+}
+
+func getPoster(q dbtx, movieID int) (Poster, error) {
+	query := `
+		SELECT movie_id, version, format, width, height, sizes, updated_at
+		FROM movie_posters WHERE movie_id = $1`
+
+	var p Poster
+	err := q.QueryRow(query, movieID).Scan(&p.MovieID, &p.Version, &p.Format, &p.Width, &p.Height,
+		pgtype.NewMap().SQLScanner(&p.Sizes), &p.UpdatedAt)
+	if err != nil {
+		return Poster{}, err
+	}
+
+	p.setURLs()
+	return p, nil
+}
+
+// Enregistre l'affiche du film (les fichiers doivent déjà être stockés)
+// et marque le film comme modifié. Renvoie sql.ErrNoRows si le film n'existe pas.
+func (m PosterModel) SetPoster(p Poster) (Poster, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return Poster{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE movies SET updated_at = NOW() WHERE id = $1", p.MovieID)
+	if err != nil {
+		return Poster{}, err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return Poster{}, err
+	}
+
+	sizes := p.Sizes
+	if sizes == nil {
+		sizes = []string{}
+	}
+
+	query := `
+		INSERT INTO movie_posters (movie_id, version, format, width, height, sizes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (movie_id) DO UPDATE SET
+			version = EXCLUDED.version, format = EXCLUDED.format, width = EXCLUDED.width,
+			height = EXCLUDED.height, sizes = EXCLUDED.sizes, updated_at = NOW()
+		RETURNING updated_at`
+
+	err = tx.QueryRow(query, p.MovieID, p.Version, p.Format, p.Width, p.Height, sizes).Scan(&p.UpdatedAt)
+	if err != nil {
+		return Poster{}, err
+	}
+
+	if err := recordMovieEvents(tx, movieEvent{Type: EventMovieUpdated, MovieID: p.MovieID}); err != nil {
+		return Poster{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Poster{}, err
+	}
+
+	p.setURLs()
+	return p, nil
+}
+
+// Retire l'affiche du film, renvoie sql.ErrNoRows s'il n'en avait pas
+func (m PosterModel) DeletePoster(movieID int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM movie_posters WHERE movie_id = $1", movieID)
+	if err != nil {
+		return err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE movies SET updated_at = NOW() WHERE id = $1", movieID); err != nil {
+		return err
+	}
+
+	if err := recordMovieEvents(tx, movieEvent{Type: EventMovieUpdated, MovieID: movieID}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Dossier de toutes les affiches d'un film
+func PosterPrefix(movieID int) string {
+	return fmt.Sprintf("posters/%d", movieID)
+}
+
+// Dossier d'une version de l'affiche
+func (p Poster) Prefix() string {
+	return PosterPrefix(p.MovieID) + "/" + p.Version
+}
+
+// Clé de l'original, qui garde son format
+func (p Poster) OriginalKey() string {
+	ext := p.Format
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+	return p.Prefix() + "/original." + ext
+}
+
+// Clé d'une vignette, toujours en JPEG
+func (p Poster) ThumbnailKey(size string) string {
+	return p.Prefix() + "/" + size + ".jpg"
+}
+
+func (p *Poster) setURLs() {
+	p.URL = ImagesURLPrefix + p.OriginalKey()
+	p.Thumbnails = make(map[string]string, len(p.Sizes))
+	for _, size := range p.Sizes {
+		p.Thumbnails[size] = ImagesURLPrefix + p.ThumbnailKey(size)
+	}
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Poids de l'a priori bayésien : un film est noté comme s'il avait
+// reçu ratingPriorWeight votes égaux à la moyenne de tout le catalogue
+const ratingPriorWeight = 10
+
+// Moyenne des votes pondérée (bayésienne), NULL pour un film sans vote.
+// La moyenne globale est une sous-requête non corrélée, calculée une seule fois.
+var weightedRatingSQL = fmt.Sprintf(`ROUND((CASE WHEN m.user_rating_count = 0 THEN NULL ELSE
+		(%[1]d * (SELECT COALESCE(SUM(user_rating_sum)::float8 / NULLIF(SUM(user_rating_count), 0), 5) FROM movies)
+		+ m.user_rating_sum) / (%[1]d + m.user_rating_count) END)::numeric, 2)`, ratingPriorWeight)
+
+const averageRatingSQL = "ROUND((m.user_rating_sum::float8 / NULLIF(m.user_rating_count, 0))::numeric, 2)"
+
+var ErrInvalidScore = errors.New("score must be an integer between 0 and 10")
+
+type RatingModel struct {
+	DB *sql.DB
+}
+
+// Note donnée à un film par un utilisateur
+type UserRating struct {
+	MovieID   int       `json:"movie_id"`
+	UserID    int       `json:"user_id"`
+	Score     int       `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Notes des utilisateurs d'un film. Distribution (nombre de votes
+// pour chaque note de 0 à 10) n'est remplie que pour le détail d'un film.
+type RatingSummary struct {
+	Average       *float64    `json:"average"`
+	Count         int         `json:"count"`
+	WeightedScore *float64    `json:"weighted_score"`
+	Distribution  map[int]int `json:"distribution,omitempty"`
+}
+
+// Enregistre (ou remplace) la note d'un utilisateur pour un film
+// et met à jour les totaux du film. Renvoie sql.ErrNoRows si le film n'existe pas.
+func (m RatingModel) SetRating(userID, movieID, score int) (UserRating, error) {
+	if score < 0 || score > 10 {
+		return UserRating{}, ErrInvalidScore
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return UserRating{}, err
+	}
+	defer tx.Rollback()
+
+	// Verrouille le film : les totaux sont recalculés de façon sérialisée
+	var id int
+	if err := tx.QueryRow("SELECT id FROM movies WHERE id = $1 FOR UPDATE", movieID).Scan(&id); err != nil {
+		return UserRating{}, err
+	}
+
+	query := `
+		INSERT INTO user_ratings (user_id, movie_id, score)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, movie_id) DO UPDATE SET score = EXCLUDED.score, updated_at = NOW()
+		RETURNING user_id, movie_id, score, created_at, updated_at`
+
+	var rating UserRating
+	err = tx.QueryRow(query, userID, movieID, score).
+		Scan(&rating.UserID, &rating.MovieID, &rating.Score, &rating.CreatedAt, &rating.UpdatedAt)
+	if err != nil {
+		return UserRating{}, err
+	}
+
+	if err := refreshRatingTotals(tx, movieID); err != nil {
+		return UserRating{}, err
+	}
+
+	return rating, tx.Commit()
+}
+
+// Renvoie la note d'un utilisateur pour un film,
+// ou sql.ErrNoRows s'il ne l'a pas noté.
+func (m RatingModel) GetRating(userID, movieID int) (UserRating, error) {
+	query := `
+		SELECT user_id, movie_id, score, created_at, updated_at
+		FROM user_ratings WHERE user_id = $1 AND movie_id = $2`
+
+	var rating UserRating
+	err := m.DB.QueryRow(query, userID, movieID).
+		Scan(&rating.UserID, &rating.MovieID, &rating.Score, &rating.CreatedAt, &rating.UpdatedAt)
+	if err != nil {
+		return UserRating{}, err
+	}
+
+	return rating, nil
+}
+
+// Supprime la note d'un utilisateur, renvoie sql.ErrNoRows s'il n'y en avait pas.
+func (m RatingModel) DeleteRating(userID, movieID int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	if err := tx.QueryRow("SELECT id FROM movies WHERE id = $1 FOR UPDATE", movieID).Scan(&id); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM user_ratings WHERE user_id = $1 AND movie_id = $2", userID, movieID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := refreshRatingTotals(tx, movieID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Recalcule le nombre et la somme des votes gardés sur la ligne du film,
+// ce qui permet de trier le catalogue sans agréger user_ratings. Le film
+// est marqué comme modifié : ses notes ont changé pour les synchronisations
+// et pour Last-Modified.
+func refreshRatingTotals(q dbtx, movieID int) error {
+	query := `
+		UPDATE movies SET
+			user_rating_count = (SELECT count(*) FROM user_ratings WHERE movie_id = $1),
+			user_rating_sum = (SELECT COALESCE(SUM(score), 0) FROM user_ratings WHERE movie_id = $1),
+			updated_at = NOW()
+		WHERE id = $1`
+
+	_, err := q.Exec(query, movieID)
+	return err
+}
+
+// Renvoie le résumé complet (distribution comprise) des notes d'un film
+func getRatingSummary(q dbtx, movieID int) (*RatingSummary, error) {
+	query := `
+		SELECT m.user_rating_count, ` + averageRatingSQL + `, ` + weightedRatingSQL + `
+		FROM movies m WHERE m.id = $1`
+
+	summary := &RatingSummary{Distribution: make(map[int]int, 11)}
+	err := q.QueryRow(query, movieID).Scan(&summary.Count, &summary.Average, &summary.WeightedScore)
+	if err != nil {
+		return nil, err
+	}
+
+	for score := 0; score <= 10; score++ {
+		summary.Distribution[score] = 0
+	}
+
+	rows, err := q.Query("SELECT score, count(*) FROM user_ratings WHERE movie_id = $1 GROUP BY score", movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var score, count int
+		if err := rows.Scan(&score, &count); err != nil {
+			return nil, err
+		}
+		summary.Distribution[score] = count
+	}
+
+	return summary, rows.Err()
+}
@@ -0,0 +1,269 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Poids des points communs entre deux films
+const (
+	sharedGenreWeight = 2.0
+	yearWindow        = 20 // au-delà de cet écart (en années), la proximité ne compte plus
+)
+
+// Points d'une personne commune selon son rôle
+const creditWeightSQL = `CASE c1.role WHEN 'director' THEN 3.0 WHEN 'writer' THEN 1.5 WHEN 'actor' THEN 1.0 ELSE 0.5 END`
+
+// Note utilisée pour départager les films : éditoriale, sinon moyenne des utilisateurs
+const ratingBonusSQL = `COALESCE(m.rating, m.user_rating_sum::float8 / NULLIF(m.user_rating_count, 0), 5) / 10.0`
+
+type RecommendationModel struct {
+	DB *sql.DB
+}
+
+// Personne présente dans les deux films, avec le même rôle
+type SharedCredit struct {
+	PersonID int    `json:"person_id"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+}
+
+// Film recommandé. Reason résume en une phrase ce qui le rapproche
+// du film de départ (ou des films aimés, listés dans Because).
+type Recommendation struct {
+	Movie        MovieRef       `json:"movie"`
+	Rating       *float64       `json:"rating"`
+	Score        float64        `json:"score"`
+	SharedGenres []string       `json:"shared_genres"`
+	SharedPeople []SharedCredit `json:"shared_people"`
+	Because      []MovieRef     `json:"because,omitempty"`
+	Reason       string         `json:"reason"`
+}
+
+// Renvoie les films les plus proches de movieID : genres et personnes
+// en commun, proximité de l'année de sortie, puis note.
+// Renvoie sql.ErrNoRows si le film n'existe pas.
+func (m RecommendationModel) GetSimilarMovies(movieID, limit int) ([]Recommendation, error) {
+	if err := movieExists(m.DB, movieID); err != nil {
+		return nil, err
+	}
+
+	seeds := "SELECT $1::int AS movie_id, 1.0::float8 AS weight"
+	yearBonus := fmt.Sprintf(
+		"GREATEST(0, 1 - abs(m.release_year - (SELECT release_year FROM movies WHERE id = $1)) / %d.0)", yearWindow)
+
+	recommendations, err := m.recommend(seeds, "TRUE", yearBonus, movieID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un seul film de départ : inutile de le rappeler
+	for i := range recommendations {
+		recommendations[i].Because = nil
+		recommendations[i].Reason = explainRecommendation(recommendations[i], false)
+	}
+
+	return recommendations, nil
+}
+
+// Renvoie des films pour l'utilisateur, à partir de ceux qu'il a bien notés (7 ou plus)
+// et de ceux qu'il a vus (sauf s'il les a mal notés). Les films déjà vus ou notés sont exclus.
+func (m RecommendationModel) GetUserRecommendations(userID, limit int) ([]Recommendation, error) {
+	// Poids de chaque film de départ : une note de 10 compte plus qu'un simple visionnage
+	seeds := `
+		SELECT movie_id, max(weight) AS weight FROM (
+			SELECT movie_id, (score - 5) / 5.0 AS weight
+			FROM user_ratings WHERE user_id = $1 AND score >= 7
+			UNION ALL
+			SELECT movie_id, CASE WHEN watch_count > 1 THEN 0.6 ELSE 0.3 END
+			FROM watched_movies w WHERE user_id = $1
+			AND NOT EXISTS (SELECT 1 FROM user_ratings r WHERE r.user_id = $1 AND r.movie_id = w.movie_id AND r.score < 5)
+		) s
+		GROUP BY movie_id
+		ORDER BY weight DESC, movie_id
+		LIMIT 50`
+
+	exclude := `
+		l.candidate NOT IN (SELECT movie_id FROM user_ratings WHERE user_id = $1)
+		AND l.candidate NOT IN (SELECT movie_id FROM watched_movies WHERE user_id = $1)`
+
+	recommendations, err := m.recommend(seeds, exclude, "0", userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range recommendations {
+		recommendations[i].Reason = explainRecommendation(recommendations[i], true)
+	}
+
+	return recommendations, nil
+}
+
+// Classe les films liés aux films de départ (requête seeds : movie_id, weight).
+// Chaque genre ou personne en commun rapporte des points pondérés par le poids
+// du film de départ ; yearBonus et la note s'y ajoutent.
+func (m RecommendationModel) recommend(seeds, exclude, yearBonus string, arg, limit int) ([]Recommendation, error) {
+	query := fmt.Sprintf(`
+		WITH seed AS (%[1]s),
+		links AS (
+			SELECT mg2.movie_id AS candidate, s.movie_id AS seed, s.weight * %[2]f AS points,
+				'genre' AS kind, g.name AS label, NULL::int AS person_id
+			FROM seed s
+			JOIN movie_genres mg1 ON mg1.movie_id = s.movie_id
+			JOIN movie_genres mg2 ON mg2.genre_id = mg1.genre_id AND mg2.movie_id <> s.movie_id
+			JOIN genres g ON g.id = mg1.genre_id
+			UNION ALL
+			SELECT c2.movie_id, s.movie_id, s.weight * %[3]s, c1.role, p.name, p.id
+			FROM seed s
+			JOIN movie_credits c1 ON c1.movie_id = s.movie_id
+			JOIN movie_credits c2 ON c2.person_id = c1.person_id AND c2.role = c1.role AND c2.movie_id <> s.movie_id
+			JOIN people p ON p.id = c1.person_id
+		),
+		scored AS (
+			SELECT l.candidate, sum(l.points) AS points,
+				jsonb_agg(DISTINCT jsonb_build_object('kind', l.kind, 'name', l.label, 'person_id', l.person_id)) AS shared,
+				jsonb_agg(DISTINCT jsonb_build_object('id', sm.id, 'title', sm.title, 'release_year', sm.release_year)) AS because
+			FROM links l
+			JOIN movies sm ON sm.id = l.seed
+			WHERE l.candidate NOT IN (SELECT movie_id FROM seed) AND %[4]s
+			GROUP BY l.candidate
+		)
+		SELECT m.id, m.title, m.release_year, ROUND(m.rating::numeric, 1),
+			ROUND((sc.points + %[5]s + %[6]s)::numeric, 2) AS score, sc.shared, sc.because
+		FROM scored sc
+		JOIN movies m ON m.id = sc.candidate
+		ORDER BY score DESC, m.id ASC
+		LIMIT $2`, seeds, sharedGenreWeight, creditWeightSQL, exclude, yearBonus, ratingBonusSQL)
+
+	rows, err := m.DB.Query(query, arg, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recommendations := []Recommendation{}
+
+	for rows.Next() {
+		var rec Recommendation
+		var shared, because []byte
+
+		err := rows.Scan(&rec.Movie.ID, &rec.Movie.Title, &rec.Movie.ReleaseYear, &rec.Rating, &rec.Score, &shared, &because)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := rec.setShared(shared); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(because, &rec.Because); err != nil {
+			return nil, err
+		}
+		sort.Slice(rec.Because, func(i, j int) bool { return rec.Because[i].ID < rec.Because[j].ID })
+
+		recommendations = append(recommendations, rec)
+	}
+
+	return recommendations, rows.Err()
+}
+
+// Répartit les points communs (JSON) entre genres et personnes, dans un ordre stable
+func (rec *Recommendation) setShared(data []byte) error {
+	var shared []struct {
+		Kind     string `json:"kind"`
+		Name     string `json:"name"`
+		PersonID *int   `json:"person_id"`
+	}
+	if err := json.Unmarshal(data, &shared); err != nil {
+		return err
+	}
+
+	rec.SharedGenres = []string{}
+	rec.SharedPeople = []SharedCredit{}
+
+	for _, s := range shared {
+		if s.Kind == "genre" {
+			rec.SharedGenres = append(rec.SharedGenres, s.Name)
+		} else if s.PersonID != nil {
+			rec.SharedPeople = append(rec.SharedPeople, SharedCredit{PersonID: *s.PersonID, Name: s.Name, Role: s.Kind})
+		}
+	}
+
+	sort.Strings(rec.SharedGenres)
+	sort.Slice(rec.SharedPeople, func(i, j int) bool {
+		a, b := rec.SharedPeople[i], rec.SharedPeople[j]
+		if roleRank(a.Role) != roleRank(b.Role) {
+			return roleRank(a.Role) < roleRank(b.Role)
+		}
+		return a.Name < b.Name
+	})
+
+	return nil
+}
+
+// Même ordre que le générique (creditRoleOrder)
+func roleRank(role string) int {
+	for i, r := range []string{CreditDirector, CreditWriter, CreditActor, CreditComposer} {
+		if r == role {
+			return i
+		}
+	}
+	return 4
+}
+
+// Construit la phrase d'explication, par exemple
+// "shares 3 genres (Action, Drama, Sci-Fi) and director Lana Wachowski"
+func explainRecommendation(rec Recommendation, withBecause bool) string {
+	var parts []string
+
+	switch n := len(rec.SharedGenres); {
+	case n == 1:
+		parts = append(parts, "genre "+rec.SharedGenres[0])
+	case n > 1:
+		parts = append(parts, fmt.Sprintf("%d genres (%s)", n, strings.Join(rec.SharedGenres, ", ")))
+	}
+
+	// Les personnes sont regroupées par rôle, dans l'ordre du générique
+	for i := 0; i < len(rec.SharedPeople); {
+		role := rec.SharedPeople[i].Role
+		var names []string
+		for ; i < len(rec.SharedPeople) && rec.SharedPeople[i].Role == role; i++ {
+			names = append(names, rec.SharedPeople[i].Name)
+		}
+
+		if len(names) == 1 {
+			parts = append(parts, role+" "+names[0])
+		} else {
+			parts = append(parts, fmt.Sprintf("%d %ss (%s)", len(names), role, strings.Join(names, ", ")))
+		}
+	}
+
+	reason := "shares " + joinWithAnd(parts)
+	if len(parts) == 0 {
+		reason = "similar release year and rating"
+	}
+
+	if withBecause && len(rec.Because) > 0 {
+		titles := make([]string, 0, 3)
+		for _, b := range rec.Because {
+			if len(titles) == 3 {
+				titles = append(titles, fmt.Sprintf("%d more", len(rec.Because)-3))
+				break
+			}
+			titles = append(titles, b.Title)
+		}
+		reason = fmt.Sprintf("because you liked %s: %s", joinWithAnd(titles), reason)
+	}
+
+	return reason
+}
+
+// "a", "a and b", "a, b and c"
+func joinWithAnd(parts []string) string {
+	if len(parts) <= 1 {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
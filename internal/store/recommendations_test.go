@@ -0,0 +1,57 @@
+package store
+
+import "testing"
+
+func TestExplainRecommendation(t *testing.T) {
+	tests := []struct {
+		name        string
+		rec         Recommendation
+		withBecause bool
+		want        string
+	}{
+		{
+			name: "Genres and Director",
+			rec: Recommendation{
+				SharedGenres: []string{"Action", "Drama", "Sci-Fi"},
+				SharedPeople: []SharedCredit{{PersonID: 1, Name: "Lana Wachowski", Role: CreditDirector}},
+			},
+			want: "shares 3 genres (Action, Drama, Sci-Fi) and director Lana Wachowski",
+		},
+		{
+			name: "Several Actors",
+			rec: Recommendation{
+				SharedGenres: []string{"Sci-Fi"},
+				SharedPeople: []SharedCredit{
+					{PersonID: 2, Name: "Carrie-Anne Moss", Role: CreditActor},
+					{PersonID: 3, Name: "Keanu Reeves", Role: CreditActor},
+				},
+			},
+			want: "shares genre Sci-Fi and 2 actors (Carrie-Anne Moss, Keanu Reeves)",
+		},
+		{
+			name: "Nothing Shared",
+			rec:  Recommendation{},
+			want: "similar release year and rating",
+		},
+		{
+			name: "Because",
+			rec: Recommendation{
+				SharedGenres: []string{"Sci-Fi"},
+				Because: []MovieRef{
+					{ID: 1, Title: "The Matrix"}, {ID: 2, Title: "Dune"},
+					{ID: 3, Title: "Alien"}, {ID: 4, Title: "Brazil"},
+				},
+			},
+			withBecause: true,
+			want:        "because you liked The Matrix, Dune, Alien and 1 more: shares genre Sci-Fi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := explainRecommendation(tt.rec, tt.withBecause); got != tt.want {
+				t.Errorf("explainRecommendation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+const maxUserReviewLength = 5000
+
+var (
+	ErrDuplicateReview = errors.New("you have already reviewed this movie")
+	ErrOwnReviewVote   = errors.New("you cannot vote on your own review")
+	ErrInvalidStatus   = errors.New("status must be one of pending, approved, rejected")
+)
+
+type ReviewModel struct {
+	DB *sql.DB
+}
+
+// Critique d'un utilisateur. Seules les critiques approuvées
+// par un éditeur sont visibles publiquement.
+type Review struct {
+	ID             int       `json:"id"`
+	MovieID        int       `json:"movie_id"`
+	UserID         int       `json:"user_id"`
+	UserName       string    `json:"user_name"`
+	Body           string    `json:"body"`
+	Spoiler        bool      `json:"spoiler"`
+	Status         string    `json:"status"`
+	ModerationNote *string   `json:"moderation_note,omitempty"`
+	HelpfulCount   int       `json:"helpful_count"`
+	UnhelpfulCount int       `json:"unhelpful_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+const reviewColumns = `r.id, r.movie_id, r.user_id, u.name, r.body, r.spoiler, r.status, r.moderation_note,
+		r.helpful_count, r.unhelpful_count, r.created_at, r.updated_at`
+
+func scanReview(row interface{ Scan(...any) error }, r *Review, extra ...any) error {
+	dest := append(extra, &r.ID, &r.MovieID, &r.UserID, &r.UserName, &r.Body, &r.Spoiler, &r.Status,
+		&r.ModerationNote, &r.HelpfulCount, &r.UnhelpfulCount, &r.CreatedAt, &r.UpdatedAt)
+	return row.Scan(dest...)
+}
+
+// Ajoute une critique. Renvoie sql.ErrNoRows si le film n'existe pas
+// et ErrDuplicateReview si l'utilisateur a déjà critiqué ce film.
+func (m ReviewModel) AddReview(review Review) (Review, error) {
+	query := `
+		INSERT INTO reviews (movie_id, user_id, body, spoiler, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	var id int
+	err := m.DB.QueryRow(query, review.MovieID, review.UserID, review.Body, review.Spoiler, review.Status).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23503":
+			return Review{}, sql.ErrNoRows
+		case isUniqueViolation(err):
+			return Review{}, ErrDuplicateReview
+		}
+		return Review{}, err
+	}
+
+	return m.GetReviewByID(id)
+}
+
+func (m ReviewModel) GetReviewByID(id int) (Review, error) {
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.id = $1`
+
+	var review Review
+	if err := scanReview(m.DB.QueryRow(query, id), &review); err != nil {
+		return Review{}, err
+	}
+
+	return review, nil
+}
+
+// Renvoie les critiques approuvées d'un film. Avec hideSpoilers,
+// les critiques qui dévoilent l'intrigue sont écartées.
+func (m ReviewModel) GetMovieReviews(movieID int, hideSpoilers bool, filters Filters) ([]Review, Metadata, error) {
+	where := "WHERE r.movie_id = $1 AND r.status = 'approved'"
+	if hideSpoilers {
+		where += " AND NOT r.spoiler"
+	}
+
+	return m.listReviews(where, filters, movieID)
+}
+
+// Renvoie les critiques ayant ce statut, pour la file de modération
+func (m ReviewModel) GetReviewsByStatus(status string, filters Filters) ([]Review, Metadata, error) {
+	return m.listReviews("WHERE r.status = $1", filters, status)
+}
+
+func (m ReviewModel) listReviews(where string, filters Filters, args ...any) ([]Review, Metadata, error) {
+	column, direction := filters.sortColumn()
+	args = append(args, filters.PageSize, (filters.Page-1)*filters.PageSize)
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s
+		FROM reviews r
+		JOIN users u ON u.id = r.user_id
+		%s
+		ORDER BY r.%s %s, r.id ASC
+		LIMIT $%d OFFSET $%d`, reviewColumns, where, column, direction, len(args)-1, len(args))
+
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	reviews := []Review{}
+
+	for rows.Next() {
+		var review Review
+		if err := scanReview(rows, &review, &totalRecords); err != nil {
+			return nil, Metadata{}, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return reviews, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Modifie le texte, le drapeau spoiler et le statut d'une critique
+func (m ReviewModel) UpdateReview(review Review) error {
+	query := `
+		UPDATE reviews SET body = $1, spoiler = $2, status = $3, moderation_note = NULL, updated_at = NOW()
+		WHERE id = $4`
+
+	res, err := m.DB.Exec(query, review.Body, review.Spoiler, review.Status, review.ID)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(res)
+}
+
+func (m ReviewModel) DeleteReview(id int) error {
+	res, err := m.DB.Exec("DELETE FROM reviews WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(res)
+}
+
+// Approuve ou rejette une critique, avec une note facultative pour son auteur
+func (m ReviewModel) ModerateReview(id int, status string, note *string) (Review, error) {
+	if status != ReviewPending && status != ReviewApproved && status != ReviewRejected {
+		return Review{}, ErrInvalidStatus
+	}
+
+	query := `
+		UPDATE reviews SET status = $1, moderation_note = $2
+		WHERE id = $3`
+
+	res, err := m.DB.Exec(query, status, note, id)
+	if err != nil {
+		return Review{}, err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return Review{}, err
+	}
+
+	return m.GetReviewByID(id)
+}
+
+// Enregistre (ou remplace) le vote d'un utilisateur sur une critique approuvée
+// et renvoie la critique avec ses compteurs à jour.
+func (m ReviewModel) VoteReview(reviewID, userID int, helpful bool) (Review, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return Review{}, err
+	}
+	defer tx.Rollback()
+
+	var authorID int
+	queryLock := "SELECT user_id FROM reviews WHERE id = $1 AND status = 'approved' FOR UPDATE"
+	if err := tx.QueryRow(queryLock, reviewID).Scan(&authorID); err != nil {
+		return Review{}, err
+	}
+	if authorID == userID {
+		return Review{}, ErrOwnReviewVote
+	}
+
+	queryVote := `
+		INSERT INTO review_votes (review_id, user_id, helpful)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (review_id, user_id) DO UPDATE SET helpful = EXCLUDED.helpful`
+
+	if _, err := tx.Exec(queryVote, reviewID, userID, helpful); err != nil {
+		return Review{}, err
+	}
+
+	if err := refreshReviewVotes(tx, reviewID); err != nil {
+		return Review{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Review{}, err
+	}
+
+	return m.GetReviewByID(reviewID)
+}
+
+// Retire le vote d'un utilisateur, renvoie sql.ErrNoRows s'il n'avait pas voté
+func (m ReviewModel) DeleteReviewVote(reviewID, userID int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	if err := tx.QueryRow("SELECT id FROM reviews WHERE id = $1 FOR UPDATE", reviewID).Scan(&id); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM review_votes WHERE review_id = $1 AND user_id = $2", reviewID, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return err
+	}
+
+	if err := refreshReviewVotes(tx, reviewID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Recalcule les compteurs de votes gardés sur la critique
+func refreshReviewVotes(q dbtx, reviewID int) error {
+	query := `
+		UPDATE reviews SET
+			helpful_count = (SELECT count(*) FROM review_votes WHERE review_id = $1 AND helpful),
+			unhelpful_count = (SELECT count(*) FROM review_votes WHERE review_id = $1 AND NOT helpful)
+		WHERE id = $1`
+
+	_, err := q.Exec(query, reviewID)
+	return err
+}
+
+func checkRowsAffected(res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Vérifie que les données de la critique
+// respectent certaines règles métiers
+func (r *Review) Validate() error {
+	r.Body = strings.TrimSpace(r.Body)
+
+	if r.Body == "" {
+		return errors.New("body is required")
+	}
+
+	if len(r.Body) > maxUserReviewLength {
+		return fmt.Errorf("body must not exceed %d characters", maxUserReviewLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+)
+
+type StatsModel struct {
+	DB *sql.DB
+}
+
+// Film le mieux noté d'un genre
+type RatedMovie struct {
+	ID          int     `json:"id"`
+	Title       string  `json:"title"`
+	ReleaseYear int     `json:"release_year"`
+	Rating      float64 `json:"rating"`
+}
+
+type GenreStats struct {
+	Genre         string       `json:"genre"`
+	Count         int          `json:"count"`
+	AverageRating *float64     `json:"average_rating"`
+	TopRated      []RatedMovie `json:"top_rated"`
+}
+
+type DecadeStats struct {
+	Decade        int      `json:"decade"`
+	Count         int      `json:"count"`
+	AverageRating *float64 `json:"average_rating"`
+}
+
+type YearCount struct {
+	Year  int `json:"year"`
+	Count int `json:"count"`
+}
+
+// Nombre de films dont la note est dans [Min, Max[ (Max inclus pour la dernière tranche)
+type RatingBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+type CatalogueStats struct {
+	TotalMovies        int            `json:"total_movies"`
+	AverageRating      *float64       `json:"average_rating"`
+	MissingRating      int            `json:"missing_rating"`
+	MissingReview      int            `json:"missing_review"`
+	ByGenre            []GenreStats   `json:"by_genre"`
+	ByDecade           []DecadeStats  `json:"by_decade"`
+	ByYear             []YearCount    `json:"by_year"`
+	RatingDistribution []RatingBucket `json:"rating_distribution"`
+}
+
+// Nombre de films les mieux notés gardés pour chaque genre
+const topRatedPerGenre = 3
+
+// Calcule les statistiques des films correspondant à la recherche
+// (mêmes filtres que GetMovies). Toutes les requêtes lisent le même
+// instantané de la base.
+func (m StatsModel) GetStats(searchTitle string, filters Filters) (CatalogueStats, error) {
+	tx, err := m.DB.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return CatalogueStats{}, err
+	}
+	defer tx.Rollback()
+
+	where, args := movieConditions(searchTitle, filters, nil)
+
+	stats := CatalogueStats{
+		ByGenre:            []GenreStats{},
+		ByDecade:           []DecadeStats{},
+		ByYear:             []YearCount{},
+		RatingDistribution: make([]RatingBucket, 10),
+	}
+
+	queryTotals := `
+		SELECT count(*), ROUND(avg(m.rating)::numeric, 2),
+			count(*) FILTER (WHERE m.rating IS NULL),
+			count(*) FILTER (WHERE m.review IS NULL OR m.review = '')
+		FROM movies m
+		` + where
+
+	err = tx.QueryRow(queryTotals, args...).Scan(&stats.TotalMovies, &stats.AverageRating, &stats.MissingRating, &stats.MissingReview)
+	if err != nil {
+		return CatalogueStats{}, err
+	}
+
+	queryGenres := `
+		SELECT g.name, count(*), ROUND(avg(m.rating)::numeric, 2)
+		FROM movies m
+		JOIN movie_genres mg ON mg.movie_id = m.id
+		JOIN genres g ON g.id = mg.genre_id
+		` + where + `
+		GROUP BY g.name
+		ORDER BY count(*) DESC, g.name ASC`
+
+	err = scanRows(tx, queryGenres, args, func(rows *sql.Rows) error {
+		gs := GenreStats{TopRated: []RatedMovie{}}
+		if err := rows.Scan(&gs.Genre, &gs.Count, &gs.AverageRating); err != nil {
+			return err
+		}
+		stats.ByGenre = append(stats.ByGenre, gs)
+		return nil
+	})
+	if err != nil {
+		return CatalogueStats{}, err
+	}
+
+	queryTopRated := `
+		SELECT genre, id, title, release_year, rating FROM (
+			SELECT g.name AS genre, m.id, m.title, m.release_year, ROUND(m.rating::numeric, 1) AS rating,
+				row_number() OVER (PARTITION BY g.id ORDER BY m.rating DESC, m.id ASC) AS rank
+			FROM movies m
+			JOIN movie_genres mg ON mg.movie_id = m.id
+			JOIN genres g ON g.id = mg.genre_id
+			` + where + ` AND m.rating IS NOT NULL
+		) t
+		WHERE rank <= ` + strconv.Itoa(topRatedPerGenre) + `
+		ORDER BY genre, rank`
+
+	genreIndex := make(map[string]int, len(stats.ByGenre))
+	for i, gs := range stats.ByGenre {
+		genreIndex[gs.Genre] = i
+	}
+
+	err = scanRows(tx, queryTopRated, args, func(rows *sql.Rows) error {
+		var genre string
+		var movie RatedMovie
+		if err := rows.Scan(&genre, &movie.ID, &movie.Title, &movie.ReleaseYear, &movie.Rating); err != nil {
+			return err
+		}
+		if i, ok := genreIndex[genre]; ok {
+			stats.ByGenre[i].TopRated = append(stats.ByGenre[i].TopRated, movie)
+		}
+		return nil
+	})
+	if err != nil {
+		return CatalogueStats{}, err
+	}
+
+	queryDecades := `
+		SELECT (m.release_year / 10) * 10 AS decade, count(*), ROUND(avg(m.rating)::numeric, 2)
+		FROM movies m
+		` + where + `
+		GROUP BY decade
+		ORDER BY decade ASC`
+
+	err = scanRows(tx, queryDecades, args, func(rows *sql.Rows) error {
+		var ds DecadeStats
+		if err := rows.Scan(&ds.Decade, &ds.Count, &ds.AverageRating); err != nil {
+			return err
+		}
+		stats.ByDecade = append(stats.ByDecade, ds)
+		return nil
+	})
+	if err != nil {
+		return CatalogueStats{}, err
+	}
+
+	queryYears := `
+		SELECT m.release_year, count(*)
+		FROM movies m
+		` + where + `
+		GROUP BY m.release_year
+		ORDER BY m.release_year ASC`
+
+	err = scanRows(tx, queryYears, args, func(rows *sql.Rows) error {
+		var yc YearCount
+		if err := rows.Scan(&yc.Year, &yc.Count); err != nil {
+			return err
+		}
+		stats.ByYear = append(stats.ByYear, yc)
+		return nil
+	})
+	if err != nil {
+		return CatalogueStats{}, err
+	}
+
+	// Tranches d'un point : [0, 1[, [1, 2[, ..., [9, 10]
+	for i := range stats.RatingDistribution {
+		stats.RatingDistribution[i] = RatingBucket{Min: i, Max: i + 1}
+	}
+
+	queryDistribution := `
+		SELECT LEAST(floor(m.rating)::int, 9) AS bucket, count(*)
+		FROM movies m
+		` + where + ` AND m.rating IS NOT NULL
+		GROUP BY bucket`
+
+	err = scanRows(tx, queryDistribution, args, func(rows *sql.Rows) error {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return err
+		}
+		if bucket >= 0 && bucket < len(stats.RatingDistribution) {
+			stats.RatingDistribution[bucket].Count = count
+		}
+		return nil
+	})
+	if err != nil {
+		return CatalogueStats{}, err
+	}
+
+	return stats, tx.Commit()
+}
+
+// Exécute la requête et appelle fn pour chaque ligne
+func scanRows(q dbtx, query string, args []any, fn func(*sql.Rows) error) error {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
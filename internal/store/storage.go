@@ -1,22 +1,174 @@
 package store
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"time"
+)
 
 type MovieRepository interface {
 	AddMovie(Movie) (Movie, error)
 	GetMoviebyID(int) (Movie, error)
+	GetMovieFields(int, []string) (Movie, error)
+	GetMoviesByIDs([]int) ([]Movie, error)
 	GetMovies(string, Filters) ([]Movie, Metadata, error)
+	ExportMovies(string, Filters, func(Movie) error) error
 	UpdateMovie(Movie) error
 	DeleteMovie(int) error
+	GetDeletedMovies(time.Time) ([]DeletedMovie, error)
+	LastModified() (time.Time, error)
+	BulkApply([]BulkOperation, bool) ([]BulkResult, error)
+	FindDuplicates(float64, int, Filters) ([]DuplicatePair, Metadata, error)
+	MergeMovies(int, int) (Movie, error)
+	SetCredits(int, []Credit) ([]Credit, error)
+	GetMovieCredits(movieIDs []int) (map[int][]Credit, error)
+}
+
+type PeopleRepository interface {
+	AddPerson(Person) (Person, error)
+	GetPersonByID(int) (Person, error)
+	GetPeople(string, Filters) ([]Person, Metadata, error)
+	UpdatePerson(Person) error
+	DeletePerson(int) error
+	GetFilmography(int) ([]FilmographyEntry, error)
+}
+
+type GenreRepository interface {
+	GetGenres() ([]Genre, error)
+	GetMovieGenres(movieIDs []int) (map[int][]Genre, error)
+	GetGenreMovies(genreIDs []int, limit int) (map[int][]Movie, error)
+}
+
+type UserRepository interface {
+	AddUser(User, string) (User, error)
+	GetUserByTokenHash(string) (User, error)
+}
+
+type RatingRepository interface {
+	SetRating(userID, movieID, score int) (UserRating, error)
+	GetRating(userID, movieID int) (UserRating, error)
+	DeleteRating(userID, movieID int) error
+}
+
+type ReviewRepository interface {
+	AddReview(Review) (Review, error)
+	GetReviewByID(int) (Review, error)
+	GetMovieReviews(movieID int, hideSpoilers bool, filters Filters) ([]Review, Metadata, error)
+	GetReviewsByStatus(string, Filters) ([]Review, Metadata, error)
+	UpdateReview(Review) error
+	DeleteReview(int) error
+	ModerateReview(id int, status string, note *string) (Review, error)
+	VoteReview(reviewID, userID int, helpful bool) (Review, error)
+	DeleteReviewVote(reviewID, userID int) error
+}
+
+type WatchlistRepository interface {
+	GetWatchlist(userID int, filters Filters) ([]WatchlistItem, Metadata, error)
+	AddToWatchlist(userID, movieID int, position *int) (WatchlistItem, error)
+	MoveWatchlistItem(userID, movieID, position int) (WatchlistItem, error)
+	RemoveFromWatchlist(userID, movieID int) error
+	GetWatched(userID int, filters Filters) ([]WatchedMovie, Metadata, error)
+	MarkWatched(userID, movieID int, watchedAt *time.Time, watchCount *int) (WatchedMovie, error)
+	UnmarkWatched(userID, movieID int) error
+}
+
+type ListRepository interface {
+	AddList(MovieList) (MovieList, error)
+	GetListByID(int) (MovieList, error)
+	GetLists(searchName string, viewerID int, ownOnly bool, filters Filters) ([]MovieList, Metadata, error)
+	GetMovieLists(movieID, viewerID int) ([]MovieList, error)
+	UpdateList(MovieList) error
+	DeleteList(int) error
+	AddListItem(listID, movieID int, position *int, note *string) error
+	MoveListItem(listID, movieID, position int) error
+	RemoveListItem(listID, movieID int) error
+}
+
+type RecommendationRepository interface {
+	GetSimilarMovies(movieID, limit int) ([]Recommendation, error)
+	GetUserRecommendations(userID, limit int) ([]Recommendation, error)
+}
+
+type StatsRepository interface {
+	GetStats(string, Filters) (CatalogueStats, error)
+}
+
+type PosterRepository interface {
+	GetPoster(movieID int) (Poster, error)
+	SetPoster(Poster) (Poster, error)
+	DeletePoster(movieID int) error
+}
+
+type TranslationRepository interface {
+	GetMovieTranslations(movieID int) ([]MovieTranslation, error)
+	SetMovieTranslation(MovieTranslation) (MovieTranslation, error)
+	DeleteMovieTranslation(movieID int, language string) error
+	SetGenreTranslation(genreID int, language, name string) error
+	DeleteGenreTranslation(genreID int, language string) error
+	LocalizeMovies(movies []Movie, language string) error
+}
+
+type EventRepository interface {
+	GetEventsSince(afterID int64, genre string, limit int) ([]MovieEvent, error)
+	LastEventID() (int64, error)
+	DeleteEventsBefore(time.Time) (int64, error)
+	Listen(ctx context.Context, notify func()) error
+}
+
+type WebhookRepository interface {
+	AddWebhook(Webhook) (Webhook, error)
+	GetWebhookByID(int) (Webhook, error)
+	GetWebhooks() ([]Webhook, error)
+	UpdateWebhook(Webhook) (Webhook, error)
+	DeleteWebhook(int) error
+	GetDeliveries(webhookID int, filters Filters) ([]WebhookDelivery, Metadata, error)
+	ClaimDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error)
+	RecordAttempt(attempt WebhookAttempt, retryAt *time.Time, disableAfter int) (bool, error)
+}
+
+type IdempotencyRepository interface {
+	ReserveKey(principal, key, requestHash string, ttl time.Duration) (IdempotentResponse, bool, error)
+	GetKey(principal, key string) (IdempotentResponse, error)
+	CompleteKey(principal, key string, resp IdempotentResponse) error
+	ReleaseKey(principal, key string) error
+	DeleteExpiredKeys() (int64, error)
 }
 
 type Storage struct {
-	Movies MovieRepository
+	Movies          MovieRepository
+	Genres          GenreRepository
+	People          PeopleRepository
+	Users           UserRepository
+	Ratings         RatingRepository
+	Reviews         ReviewRepository
+	Watchlists      WatchlistRepository
+	Lists           ListRepository
+	Recommendations RecommendationRepository
+	Stats           StatsRepository
+	Translations    TranslationRepository
+	Posters         PosterRepository
+	Events          EventRepository
+	Webhooks        WebhookRepository
+	Idempotency     IdempotencyRepository
 }
 
 // Fonction pour initialiser le Storage avec la connexion DB
 func NewStorage(db *sql.DB) Storage {
 	return Storage{
-		Movies: MovieModel{DB: db},
+		Movies:          MovieModel{DB: db},
+		Genres:          GenreModel{DB: db},
+		People:          PersonModel{DB: db},
+		Users:           UserModel{DB: db},
+		Ratings:         RatingModel{DB: db},
+		Reviews:         ReviewModel{DB: db},
+		Watchlists:      WatchlistModel{DB: db},
+		Lists:           ListModel{DB: db},
+		Recommendations: RecommendationModel{DB: db},
+		Stats:           StatsModel{DB: db},
+		Translations:    TranslationModel{DB: db},
+		Posters:         PosterModel{DB: db},
+		Events:          EventModel{DB: db},
+		Webhooks:        WebhookModel{DB: db},
+		Idempotency:     IdempotencyModel{DB: db},
 	}
 }
@@ -0,0 +1,322 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Langues dans lesquelles le catalogue peut être traduit.
+// DefaultLanguage est utilisée quand aucune langue demandée n'est disponible.
+var SupportedLanguages = []string{"fr", "en"}
+
+const DefaultLanguage = "fr"
+
+var ErrUnsupportedLanguage = fmt.Errorf("language must be one of %s", strings.Join(SupportedLanguages, ", "))
+
+type TranslationModel struct {
+	DB *sql.DB
+}
+
+// Titre, synopsis et accroche d'un film dans une langue.
+// Synopsis et Tagline vides reprennent ceux du film.
+type MovieTranslation struct {
+	MovieID   int       `json:"movie_id"`
+	Language  string    `json:"language"`
+	Title     string    `json:"title"`
+	Synopsis  *string   `json:"synopsis"`
+	Tagline   *string   `json:"tagline"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Renvoie les traductions d'un film, triées par langue.
+// Renvoie sql.ErrNoRows si le film n'existe pas.
+func (m TranslationModel) GetMovieTranslations(movieID int) ([]MovieTranslation, error) {
+	if err := movieExists(m.DB, movieID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT movie_id, language, title, synopsis, tagline, updated_at
+		FROM movie_translations
+		WHERE movie_id = $1
+		ORDER BY language`
+
+	translations := []MovieTranslation{}
+
+	err := scanRows(m.DB, query, []any{movieID}, func(rows *sql.Rows) error {
+		var t MovieTranslation
+		if err := rows.Scan(&t.MovieID, &t.Language, &t.Title, &t.Synopsis, &t.Tagline, &t.UpdatedAt); err != nil {
+			return err
+		}
+		translations = append(translations, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// Crée ou remplace la traduction d'un film, et marque le film comme modifié
+// pour les synchronisations. Renvoie sql.ErrNoRows si le film n'existe pas.
+func (m TranslationModel) SetMovieTranslation(t MovieTranslation) (MovieTranslation, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return MovieTranslation{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE movies SET updated_at = NOW() WHERE id = $1", t.MovieID)
+	if err != nil {
+		return MovieTranslation{}, err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return MovieTranslation{}, err
+	}
+
+	query := `
+		INSERT INTO movie_translations (movie_id, language, title, synopsis, tagline)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (movie_id, language) DO UPDATE SET
+			title = EXCLUDED.title, synopsis = EXCLUDED.synopsis,
+			tagline = EXCLUDED.tagline, updated_at = NOW()
+		RETURNING updated_at`
+
+	err = tx.QueryRow(query, t.MovieID, t.Language, t.Title, t.Synopsis, t.Tagline).Scan(&t.UpdatedAt)
+	if err != nil {
+		return MovieTranslation{}, err
+	}
+
+	if err := recordMovieEvents(tx, movieEvent{Type: EventMovieUpdated, MovieID: t.MovieID}); err != nil {
+		return MovieTranslation{}, err
+	}
+
+	return t, tx.Commit()
+}
+
+// Supprime la traduction d'un film, renvoie sql.ErrNoRows si elle n'existe pas
+func (m TranslationModel) DeleteMovieTranslation(movieID int, language string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM movie_translations WHERE movie_id = $1 AND language = $2", movieID, language)
+	if err != nil {
+		return err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE movies SET updated_at = NOW() WHERE id = $1", movieID); err != nil {
+		return err
+	}
+
+	if err := recordMovieEvents(tx, movieEvent{Type: EventMovieUpdated, MovieID: movieID}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Crée ou remplace le libellé d'un genre dans une langue, et marque ses films
+// comme modifiés. Renvoie sql.ErrNoRows si le genre n'existe pas.
+func (m TranslationModel) SetGenreTranslation(genreID int, language, name string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO genre_translations (genre_id, language, name)
+		SELECT id, $2, $3 FROM genres WHERE id = $1
+		ON CONFLICT (genre_id, language) DO UPDATE SET name = EXCLUDED.name`
+
+	res, err := tx.Exec(query, genreID, language, name)
+	if err != nil {
+		return err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return err
+	}
+
+	if err := touchGenreMovies(tx, genreID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Supprime le libellé d'un genre dans une langue, renvoie sql.ErrNoRows s'il n'existe pas
+func (m TranslationModel) DeleteGenreTranslation(genreID int, language string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM genre_translations WHERE genre_id = $1 AND language = $2", genreID, language)
+	if err != nil {
+		return err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return err
+	}
+
+	if err := touchGenreMovies(tx, genreID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Les films d'un genre affichent son libellé traduit
+func touchGenreMovies(q dbtx, genreID int) error {
+	_, err := q.Exec("UPDATE movies SET updated_at = NOW() WHERE id IN (SELECT movie_id FROM movie_genres WHERE genre_id = $1)", genreID)
+	return err
+}
+
+// Remplace le titre, le synopsis, l'accroche et les genres des films par leur
+// traduction dans la langue demandée. Un champ sans traduction garde sa valeur.
+func (m TranslationModel) LocalizeMovies(movies []Movie, language string) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(movies))
+	for i, movie := range movies {
+		ids[i] = int64(movie.ID)
+	}
+
+	translations := make(map[int]MovieTranslation, len(movies))
+
+	queryMovies := `
+		SELECT movie_id, title, synopsis, tagline
+		FROM movie_translations
+		WHERE movie_id = ANY($1::int[]) AND language = $2`
+
+	err := scanRows(m.DB, queryMovies, []any{ids, language}, func(rows *sql.Rows) error {
+		var t MovieTranslation
+		if err := rows.Scan(&t.MovieID, &t.Title, &t.Synopsis, &t.Tagline); err != nil {
+			return err
+		}
+		translations[t.MovieID] = t
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	labels := make(map[string]string)
+
+	queryGenres := `
+		SELECT g.name, t.name
+		FROM genre_translations t
+		JOIN genres g ON g.id = t.genre_id
+		WHERE t.language = $1`
+
+	err = scanRows(m.DB, queryGenres, []any{language}, func(rows *sql.Rows) error {
+		var name, label string
+		if err := rows.Scan(&name, &label); err != nil {
+			return err
+		}
+		labels[name] = label
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range movies {
+		movies[i].localize(translations, labels)
+	}
+
+	return nil
+}
+
+func (mv *Movie) localize(translations map[int]MovieTranslation, labels map[string]string) {
+	if t, ok := translations[mv.ID]; ok {
+		mv.Title = t.Title
+		if t.Synopsis != nil {
+			mv.Synopsis = t.Synopsis
+		}
+		if t.Tagline != nil {
+			mv.Tagline = t.Tagline
+		}
+	}
+
+	for j, name := range mv.Genres {
+		if label, ok := labels[name]; ok {
+			mv.Genres[j] = label
+		}
+	}
+}
+
+// Renvoie le libellé du genre dans la langue demandée, sinon son nom
+func (g Genre) Label(language string) string {
+	if label, ok := g.Translations[language]; ok {
+		return label
+	}
+	return g.Name
+}
+
+// Renvoie nil si le film existe, sql.ErrNoRows sinon
+func movieExists(q dbtx, id int) error {
+	var exists bool
+	if err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM movies WHERE id = $1)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Vérifie que la langue fait partie des langues du catalogue
+func CheckLanguage(language string) error {
+	if !slices.Contains(SupportedLanguages, language) {
+		return ErrUnsupportedLanguage
+	}
+	return nil
+}
+
+// Vérifie que les données de la traduction
+// respectent certaines règles métiers
+func (t *MovieTranslation) Validate() error {
+	if err := CheckLanguage(t.Language); err != nil {
+		return err
+	}
+
+	t.Title = strings.TrimSpace(t.Title)
+	if t.Title == "" || len(t.Title) > maxOriginalTitleLength {
+		return fmt.Errorf("title must be between 1 and %d characters long", maxOriginalTitleLength)
+	}
+
+	trimOptional(&t.Synopsis)
+	trimOptional(&t.Tagline)
+
+	if t.Synopsis != nil && len(*t.Synopsis) > maxSynopsisLength {
+		return fmt.Errorf("synopsis must not exceed %d characters", maxSynopsisLength)
+	}
+	if t.Tagline != nil && len(*t.Tagline) > maxTaglineLength {
+		return fmt.Errorf("tagline must not exceed %d characters", maxTaglineLength)
+	}
+
+	return nil
+}
+
+// Vérifie le libellé traduit d'un genre
+func ValidateGenreLabel(name string) error {
+	if name == "" || len(name) > 50 {
+		return errors.New("name must be between 1 and 50 characters long")
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Rang de chaque rôle : un rôle a aussi les droits des rôles inférieurs
+var roleRanks = map[string]int{
+	RoleUser:   1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+var ErrDuplicateUserName = errors.New("a user with this name already exists")
+
+type UserModel struct {
+	DB *sql.DB
+}
+
+type User struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Utilisateur des requêtes sans Authorization
+var AnonymousUser = User{}
+
+// Compte de service correspondant à la clé API_KEY : il a tous les droits
+// sur le catalogue mais n'a pas de données personnelles (ID 0)
+var APIKeyUser = User{Name: "api-key", Role: RoleAdmin}
+
+func (u User) IsAnonymous() bool {
+	return u.Role == ""
+}
+
+// Vrai si l'utilisateur a au moins le rôle demandé
+func (u User) HasRole(role string) bool {
+	return roleRanks[u.Role] >= roleRanks[role]
+}
+
+// Seule l'empreinte des tokens est enregistrée en base
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Ajoute un utilisateur avec l'empreinte de son token.
+func (m UserModel) AddUser(user User, tokenHash string) (User, error) {
+	query := `
+		INSERT INTO users (name, role, token_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+
+	err := m.DB.QueryRow(query, user.Name, user.Role, tokenHash).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return User{}, ErrDuplicateUserName
+		}
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// Recherche l'utilisateur propriétaire d'un token,
+// renvoie sql.ErrNoRows si aucun ne correspond.
+func (m UserModel) GetUserByTokenHash(tokenHash string) (User, error) {
+	query := "SELECT id, name, role, created_at FROM users WHERE token_hash = $1"
+
+	var user User
+	err := m.DB.QueryRow(query, tokenHash).Scan(&user.ID, &user.Name, &user.Role, &user.CreatedAt)
+	if err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// Vérifie que les données de l'utilisateur
+// respectent certaines règles métiers
+func (u *User) Validate() error {
+	u.Name = strings.TrimSpace(u.Name)
+
+	if len(u.Name) < 2 || len(u.Name) > 50 {
+		return errors.New("name must be between 2 and 50 characters long")
+	}
+
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
+	if _, ok := roleRanks[u.Role]; !ok {
+		return errors.New("role must be one of user, editor, admin")
+	}
+
+	return nil
+}
@@ -0,0 +1,365 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrAlreadyInWatchlist = errors.New("movie is already in the watchlist")
+
+type WatchlistModel struct {
+	DB *sql.DB
+}
+
+// Film de la liste "à voir" d'un utilisateur, Position commence à 1
+type WatchlistItem struct {
+	Movie    Movie     `json:"movie"`
+	Position int       `json:"position"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// Film vu par un utilisateur. WatchedAt est la date du dernier visionnage.
+type WatchedMovie struct {
+	Movie      Movie     `json:"movie"`
+	WatchedAt  time.Time `json:"watched_at"`
+	WatchCount int       `json:"watch_count"`
+}
+
+// Colonnes du film (genres compris) pour les listes personnelles, alias m
+const listMovieColumns = `m.id, m.title, m.release_year, ROUND(m.rating::numeric, 1), m.review, m.created_at, m.updated_at,
+		COALESCE((SELECT array_agg(g.name ORDER BY g.name) FROM movie_genres mg
+			JOIN genres g ON g.id = mg.genre_id WHERE mg.movie_id = m.id), '{}')`
+
+// Renvoie la liste "à voir" de l'utilisateur, dans son ordre
+func (m WatchlistModel) GetWatchlist(userID int, filters Filters) ([]WatchlistItem, Metadata, error) {
+	query := `
+		SELECT count(*) OVER(), ` + listMovieColumns + `, w.position, w.added_at
+		FROM watchlist_items w
+		JOIN movies m ON m.id = w.movie_id
+		WHERE w.user_id = $1
+		ORDER BY w.position ASC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := m.DB.Query(query, userID, filters.PageSize, (filters.Page-1)*filters.PageSize)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	typeMap := pgtype.NewMap()
+	totalRecords := 0
+	items := []WatchlistItem{}
+
+	for rows.Next() {
+		var item WatchlistItem
+		mv := &item.Movie
+
+		err := rows.Scan(&totalRecords, &mv.ID, &mv.Title, &mv.ReleaseYear, &mv.Rating, &mv.Review,
+			&mv.CreatedAt, &mv.UpdatedAt, typeMap.SQLScanner(&mv.Genres), &item.Position, &item.AddedAt)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return items, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Ajoute un film à la liste, à la position demandée ou à la fin si position vaut nil.
+// Renvoie sql.ErrNoRows si le film n'existe pas.
+func (m WatchlistModel) AddToWatchlist(userID, movieID int, position *int) (WatchlistItem, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return WatchlistItem{}, err
+	}
+	defer tx.Rollback()
+
+	// Sans position, le film va à la fin de la liste
+	query := `
+		INSERT INTO watchlist_items (user_id, movie_id, position)
+		VALUES ($1, $2, COALESCE($3::int,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM watchlist_items WHERE user_id = $1)))`
+
+	if _, err := tx.Exec(query, userID, movieID, position); err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23503":
+			return WatchlistItem{}, sql.ErrNoRows
+		case isUniqueViolation(err):
+			return WatchlistItem{}, ErrAlreadyInWatchlist
+		}
+		return WatchlistItem{}, err
+	}
+
+	if err := renumberWatchlist(tx, userID, movieID, true); err != nil {
+		return WatchlistItem{}, err
+	}
+
+	item, err := getWatchlistItem(tx, userID, movieID)
+	if err != nil {
+		return WatchlistItem{}, err
+	}
+
+	return item, tx.Commit()
+}
+
+// Déplace un film de la liste à la position demandée, les autres films
+// sont décalés. Renvoie sql.ErrNoRows si le film n'est pas dans la liste.
+func (m WatchlistModel) MoveWatchlistItem(userID, movieID, position int) (WatchlistItem, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return WatchlistItem{}, err
+	}
+	defer tx.Rollback()
+
+	// Verrouille toute la liste pendant la renumérotation
+	if _, err := tx.Exec("SELECT 1 FROM watchlist_items WHERE user_id = $1 FOR UPDATE", userID); err != nil {
+		return WatchlistItem{}, err
+	}
+
+	var current int
+	queryCurrent := "SELECT position FROM watchlist_items WHERE user_id = $1 AND movie_id = $2"
+	if err := tx.QueryRow(queryCurrent, userID, movieID).Scan(&current); err != nil {
+		return WatchlistItem{}, err
+	}
+
+	queryMove := "UPDATE watchlist_items SET position = $1 WHERE user_id = $2 AND movie_id = $3"
+	if _, err := tx.Exec(queryMove, position, userID, movieID); err != nil {
+		return WatchlistItem{}, err
+	}
+
+	// En montant, le film passe avant celui qui occupait la place ; en descendant, après
+	if err := renumberWatchlist(tx, userID, movieID, position < current); err != nil {
+		return WatchlistItem{}, err
+	}
+
+	item, err := getWatchlistItem(tx, userID, movieID)
+	if err != nil {
+		return WatchlistItem{}, err
+	}
+
+	return item, tx.Commit()
+}
+
+// Retire un film de la liste, renvoie sql.ErrNoRows s'il n'y était pas
+func (m WatchlistModel) RemoveFromWatchlist(userID, movieID int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM watchlist_items WHERE user_id = $1 AND movie_id = $2", userID, movieID)
+	if err != nil {
+		return err
+	}
+	if err := checkRowsAffected(res); err != nil {
+		return err
+	}
+
+	if err := renumberWatchlist(tx, userID, 0, false); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Renvoie les films vus par l'utilisateur, triés par date de visionnage
+// (la plus récente en premier par défaut) ou par nombre de visionnages
+func (m WatchlistModel) GetWatched(userID int, filters Filters) ([]WatchedMovie, Metadata, error) {
+	column, direction := filters.sortColumn()
+	if column == "id" {
+		// watched_movies n'a pas de colonne id : tri par défaut sur la date
+		column = "watched_at"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s, w.watched_at, w.watch_count
+		FROM watched_movies w
+		JOIN movies m ON m.id = w.movie_id
+		WHERE w.user_id = $1
+		ORDER BY w.%s %s, m.id ASC
+		LIMIT $2 OFFSET $3`, listMovieColumns, column, direction)
+
+	rows, err := m.DB.Query(query, userID, filters.PageSize, (filters.Page-1)*filters.PageSize)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	typeMap := pgtype.NewMap()
+	totalRecords := 0
+	watched := []WatchedMovie{}
+
+	for rows.Next() {
+		var w WatchedMovie
+		mv := &w.Movie
+
+		err := rows.Scan(&totalRecords, &mv.ID, &mv.Title, &mv.ReleaseYear, &mv.Rating, &mv.Review,
+			&mv.CreatedAt, &mv.UpdatedAt, typeMap.SQLScanner(&mv.Genres), &w.WatchedAt, &w.WatchCount)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		watched = append(watched, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return watched, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Marque un film comme vu et le retire de la liste "à voir".
+// watchedAt (maintenant par défaut) et watchCount (1 par défaut)
+// ne remplacent les valeurs existantes que s'ils sont fournis.
+// Renvoie sql.ErrNoRows si le film n'existe pas.
+func (m WatchlistModel) MarkWatched(userID, movieID int, watchedAt *time.Time, watchCount *int) (WatchedMovie, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return WatchedMovie{}, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO watched_movies (user_id, movie_id, watched_at, watch_count)
+		VALUES ($1, $2, COALESCE($3::timestamptz, NOW()), COALESCE($4::int, 1))
+		ON CONFLICT (user_id, movie_id) DO UPDATE SET
+			watched_at = COALESCE($3::timestamptz, watched_movies.watched_at),
+			watch_count = COALESCE($4::int, watched_movies.watch_count)`
+
+	if _, err := tx.Exec(query, userID, movieID, watchedAt, watchCount); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return WatchedMovie{}, sql.ErrNoRows
+		}
+		return WatchedMovie{}, err
+	}
+
+	res, err := tx.Exec("DELETE FROM watchlist_items WHERE user_id = $1 AND movie_id = $2", userID, movieID)
+	if err != nil {
+		return WatchedMovie{}, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return WatchedMovie{}, err
+	}
+	if rowsAffected > 0 {
+		if err := renumberWatchlist(tx, userID, 0, false); err != nil {
+			return WatchedMovie{}, err
+		}
+	}
+
+	query = `
+		SELECT ` + listMovieColumns + `, w.watched_at, w.watch_count
+		FROM watched_movies w
+		JOIN movies m ON m.id = w.movie_id
+		WHERE w.user_id = $1 AND w.movie_id = $2`
+
+	var watched WatchedMovie
+	mv := &watched.Movie
+	typeMap := pgtype.NewMap()
+
+	err = tx.QueryRow(query, userID, movieID).Scan(&mv.ID, &mv.Title, &mv.ReleaseYear, &mv.Rating, &mv.Review,
+		&mv.CreatedAt, &mv.UpdatedAt, typeMap.SQLScanner(&mv.Genres), &watched.WatchedAt, &watched.WatchCount)
+	if err != nil {
+		return WatchedMovie{}, err
+	}
+
+	return watched, tx.Commit()
+}
+
+// Retire un film des films vus, renvoie sql.ErrNoRows s'il n'y était pas
+func (m WatchlistModel) UnmarkWatched(userID, movieID int) error {
+	res, err := m.DB.Exec("DELETE FROM watched_movies WHERE user_id = $1 AND movie_id = $2", userID, movieID)
+	if err != nil {
+		return err
+	}
+
+	return checkRowsAffected(res)
+}
+
+func getWatchlistItem(q dbtx, userID, movieID int) (WatchlistItem, error) {
+	query := `
+		SELECT ` + listMovieColumns + `, w.position, w.added_at
+		FROM watchlist_items w
+		JOIN movies m ON m.id = w.movie_id
+		WHERE w.user_id = $1 AND w.movie_id = $2`
+
+	var item WatchlistItem
+	mv := &item.Movie
+	typeMap := pgtype.NewMap()
+
+	err := q.QueryRow(query, userID, movieID).Scan(&mv.ID, &mv.Title, &mv.ReleaseYear, &mv.Rating, &mv.Review,
+		&mv.CreatedAt, &mv.UpdatedAt, typeMap.SQLScanner(&mv.Genres), &item.Position, &item.AddedAt)
+	if err != nil {
+		return WatchlistItem{}, err
+	}
+
+	return item, nil
+}
+
+// Tables de films ordonnés, avec la colonne qui identifie chaque liste
+var positionedTables = []struct{ table, owner string }{
+	{"watchlist_items", "user_id"},
+	{"movie_list_items", "list_id"},
+}
+
+// Renumérote de 1 à n les listes ownerIDs de la table donnée.
+// À position égale, movedID passe avant (movedFirst) ou après les autres films.
+func renumberPositions(q dbtx, table, owner string, ownerIDs []int64, movedID int, movedFirst bool) error {
+	if len(ownerIDs) == 0 {
+		return nil
+	}
+
+	tieBreak := "ASC"
+	if movedFirst {
+		tieBreak = "DESC"
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE %[1]s t SET position = o.rank
+		FROM (
+			SELECT %[2]s, movie_id, row_number() OVER (
+				PARTITION BY %[2]s ORDER BY position, (movie_id = $2) %[3]s, added_at, movie_id) AS rank
+			FROM %[1]s WHERE %[2]s = ANY($1::int[])
+		) o
+		WHERE t.%[2]s = o.%[2]s AND t.movie_id = o.movie_id AND t.position <> o.rank`, table, owner, tieBreak)
+
+	_, err := q.Exec(query, ownerIDs, movedID)
+	return err
+}
+
+func renumberWatchlist(q dbtx, userID, movedID int, movedFirst bool) error {
+	return renumberPositions(q, "watchlist_items", "user_id", []int64{int64(userID)}, movedID, movedFirst)
+}
+
+// Renvoie les listes (de la table donnée) qui contiennent le film
+func positionOwners(q dbtx, table, owner string, movieID int) ([]int64, error) {
+	rows, err := q.Query(fmt.Sprintf("SELECT %s FROM %s WHERE movie_id = $1", owner, table), movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
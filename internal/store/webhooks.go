@@ -0,0 +1,355 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+var (
+	ErrInvalidWebhookURL   = errors.New("url must be an absolute http or https URL")
+	ErrInvalidEventType    = errors.New("event_types must contain only movie.created, movie.updated, movie.deleted")
+	ErrWebhookSecretLength = errors.New("secret must be at least 16 characters long")
+)
+
+// Types d'événements auxquels un webhook peut s'abonner
+var movieEventTypes = []string{EventMovieCreated, EventMovieUpdated, EventMovieDeleted}
+
+type WebhookModel struct {
+	DB *sql.DB
+}
+
+// Abonnement d'un système partenaire aux changements du catalogue.
+// Le secret signe les envois ; il n'est renvoyé qu'à la création.
+type Webhook struct {
+	ID           int        `json:"id"`
+	URL          string     `json:"url"`
+	Secret       string     `json:"secret,omitempty"`
+	EventTypes   []string   `json:"event_types"`
+	Active       bool       `json:"active"`
+	FailureCount int        `json:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Envoi d'un événement à un webhook, avec l'historique de ses tentatives.
+// URL, Secret et Event ne servent qu'à l'envoi.
+type WebhookDelivery struct {
+	ID             int64            `json:"id"`
+	WebhookID      int              `json:"webhook_id"`
+	EventID        int64            `json:"event_id"`
+	EventType      string           `json:"event_type"`
+	Status         string           `json:"status"`
+	Attempts       int              `json:"attempts"`
+	NextAttemptAt  *time.Time       `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int             `json:"last_status_code,omitempty"`
+	LastError      *string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	DeliveredAt    *time.Time       `json:"delivered_at,omitempty"`
+	Log            []WebhookAttempt `json:"log"`
+	URL            string           `json:"-"`
+	Secret         string           `json:"-"`
+	Event          MovieEvent       `json:"-"`
+}
+
+// Une tentative d'envoi. StatusCode est absent si le partenaire
+// n'a pas répondu (Error donne alors la cause).
+type WebhookAttempt struct {
+	DeliveryID  int64     `json:"-"`
+	WebhookID   int       `json:"-"`
+	Attempt     int       `json:"attempt"`
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       *string   `json:"error,omitempty"`
+	DurationMS  int       `json:"duration_ms"`
+	Succeeded   bool      `json:"succeeded"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// Vérifie l'URL et les types d'événements, tous les types par défaut
+func (w *Webhook) Validate() error {
+	w.URL = strings.TrimSpace(w.URL)
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	if len(w.EventTypes) == 0 {
+		w.EventTypes = slices.Clone(movieEventTypes)
+	}
+	for _, t := range w.EventTypes {
+		if !slices.Contains(movieEventTypes, t) {
+			return ErrInvalidEventType
+		}
+	}
+	slices.Sort(w.EventTypes)
+	w.EventTypes = slices.Compact(w.EventTypes)
+
+	if w.Secret != "" && len(w.Secret) < 16 {
+		return ErrWebhookSecretLength
+	}
+
+	return nil
+}
+
+const webhookColumns = "id, url, event_types, active, failure_count, disabled_at, created_at, updated_at"
+
+func scanWebhook(row interface{ Scan(...any) error }, w *Webhook) error {
+	return row.Scan(&w.ID, &w.URL, pgtype.NewMap().SQLScanner(&w.EventTypes), &w.Active,
+		&w.FailureCount, &w.DisabledAt, &w.CreatedAt, &w.UpdatedAt)
+}
+
+// Crée un webhook actif
+func (m WebhookModel) AddWebhook(w Webhook) (Webhook, error) {
+	query := `
+		INSERT INTO webhooks (url, secret, event_types)
+		VALUES ($1, $2, $3)
+		RETURNING ` + webhookColumns
+
+	secret := w.Secret
+	if err := scanWebhook(m.DB.QueryRow(query, w.URL, w.Secret, w.EventTypes), &w); err != nil {
+		return Webhook{}, err
+	}
+	w.Secret = secret
+
+	return w, nil
+}
+
+// Renvoie un webhook sans son secret, sql.ErrNoRows s'il n'existe pas
+func (m WebhookModel) GetWebhookByID(id int) (Webhook, error) {
+	var w Webhook
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE id = $1"
+	if err := scanWebhook(m.DB.QueryRow(query, id), &w); err != nil {
+		return Webhook{}, err
+	}
+	return w, nil
+}
+
+// Renvoie tous les webhooks, sans leur secret
+func (m WebhookModel) GetWebhooks() ([]Webhook, error) {
+	webhooks := []Webhook{}
+	query := "SELECT " + webhookColumns + " FROM webhooks ORDER BY id"
+	err := scanRows(m.DB, query, nil, func(rows *sql.Rows) error {
+		var w Webhook
+		if err := scanWebhook(rows, &w); err != nil {
+			return err
+		}
+		webhooks = append(webhooks, w)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// Modifie l'URL, les événements et l'état du webhook (et le secret s'il est fourni).
+// Le réactiver remet à zéro son compteur d'échecs.
+// Renvoie sql.ErrNoRows s'il n'existe pas.
+func (m WebhookModel) UpdateWebhook(w Webhook) (Webhook, error) {
+	query := `
+		UPDATE webhooks SET
+			url = $2, event_types = $3,
+			secret = COALESCE(NULLIF($4, ''), secret),
+			failure_count = CASE WHEN $5 AND NOT active THEN 0 ELSE failure_count END,
+			disabled_at = CASE WHEN $5 THEN NULL ELSE COALESCE(disabled_at, NOW()) END,
+			active = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + webhookColumns
+
+	if err := scanWebhook(m.DB.QueryRow(query, w.ID, w.URL, w.EventTypes, w.Secret, w.Active), &w); err != nil {
+		return Webhook{}, err
+	}
+	w.Secret = ""
+
+	return w, nil
+}
+
+// Supprime un webhook et ses envois, renvoie sql.ErrNoRows s'il n'existe pas
+func (m WebhookModel) DeleteWebhook(id int) error {
+	res, err := m.DB.Exec("DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(res)
+}
+
+// Renvoie les envois d'un webhook, les plus récents en premier,
+// avec le détail de leurs tentatives
+func (m WebhookModel) GetDeliveries(webhookID int, filters Filters) ([]WebhookDelivery, Metadata, error) {
+	query := `
+		SELECT count(*) OVER(), d.id, d.webhook_id, d.event_id, e.type, d.status, d.attempts,
+			d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at
+		FROM webhook_deliveries d
+		JOIN movie_events e ON e.id = d.event_id
+		WHERE d.webhook_id = $1
+		ORDER BY d.id DESC
+		LIMIT $2 OFFSET $3`
+
+	totalRecords := 0
+	deliveries := []WebhookDelivery{}
+	index := map[int64]int{}
+	var ids []int64
+
+	args := []any{webhookID, filters.PageSize, (filters.Page - 1) * filters.PageSize}
+	err := scanRows(m.DB, query, args, func(rows *sql.Rows) error {
+		d := WebhookDelivery{Log: []WebhookAttempt{}}
+		err := rows.Scan(&totalRecords, &d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+		if err != nil {
+			return err
+		}
+		if d.Status != DeliveryPending {
+			d.NextAttemptAt = nil
+		}
+		index[d.ID] = len(deliveries)
+		ids = append(ids, d.ID)
+		deliveries = append(deliveries, d)
+		return nil
+	})
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	if len(ids) > 0 {
+		queryAttempts := `
+			SELECT delivery_id, attempt, status_code, error, duration_ms, succeeded, attempted_at
+			FROM webhook_attempts
+			WHERE delivery_id = ANY($1::bigint[])
+			ORDER BY delivery_id, attempt`
+
+		err = scanRows(m.DB, queryAttempts, []any{ids}, func(rows *sql.Rows) error {
+			var a WebhookAttempt
+			if err := rows.Scan(&a.DeliveryID, &a.Attempt, &a.StatusCode, &a.Error,
+				&a.DurationMS, &a.Succeeded, &a.AttemptedAt); err != nil {
+				return err
+			}
+			d := &deliveries[index[a.DeliveryID]]
+			d.Log = append(d.Log, a)
+			return nil
+		})
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+	}
+
+	return deliveries, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Réserve au plus limit envois arrivés à échéance, pour les webhooks actifs.
+// Un envoi réservé n'est pas repris par une autre instance avant la fin
+// du bail (lease), ce qui couvre aussi l'arrêt brutal de l'instance.
+func (m WebhookModel) ClaimDeliveries(limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.active
+			ORDER BY d.next_attempt_at, d.id
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM due, webhooks w, movie_events e
+		WHERE d.id = due.id AND w.id = d.webhook_id AND e.id = d.event_id
+		RETURNING d.id, d.webhook_id, d.attempts, d.created_at, w.url, w.secret,
+			e.id, e.type, e.movie_id, e.payload, e.created_at`
+
+	deliveries := []WebhookDelivery{}
+	err := scanRows(m.DB, query, []any{limit, lease.Seconds()}, func(rows *sql.Rows) error {
+		d := WebhookDelivery{Status: DeliveryPending}
+		var payload []byte
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Attempts, &d.CreatedAt, &d.URL, &d.Secret,
+			&d.Event.ID, &d.Event.Type, &d.Event.MovieID, &payload, &d.Event.CreatedAt); err != nil {
+			return err
+		}
+		d.Event.Payload = payload
+		d.EventID, d.EventType = d.Event.ID, d.Event.Type
+		deliveries = append(deliveries, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Enregistre le résultat d'une tentative et met à jour l'envoi :
+// réussi, reprogrammé à retryAt, ou abandonné si retryAt est nil.
+// Chaque échec compte pour le webhook, désactivé après disableAfter
+// échecs consécutifs ; renvoie true s'il vient d'être désactivé.
+func (m WebhookModel) RecordAttempt(a WebhookAttempt, retryAt *time.Time, disableAfter int) (bool, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	queryAttempt := `
+		INSERT INTO webhook_attempts (delivery_id, attempt, status_code, error, duration_ms, succeeded)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING`
+
+	_, err = tx.Exec(queryAttempt, a.DeliveryID, a.Attempt, a.StatusCode, a.Error, a.DurationMS, a.Succeeded)
+	if err != nil {
+		return false, err
+	}
+
+	status := DeliveryPending
+	switch {
+	case a.Succeeded:
+		status = DeliverySucceeded
+	case retryAt == nil:
+		status = DeliveryFailed
+	}
+
+	queryDelivery := `
+		UPDATE webhook_deliveries SET
+			status = $2, attempts = $3, next_attempt_at = COALESCE($4, next_attempt_at),
+			last_status_code = $5, last_error = $6,
+			delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() END
+		WHERE id = $1`
+
+	_, err = tx.Exec(queryDelivery, a.DeliveryID, status, a.Attempt, retryAt, a.StatusCode, a.Error)
+	if err != nil {
+		return false, err
+	}
+
+	disabled := false
+	if a.Succeeded {
+		_, err = tx.Exec("UPDATE webhooks SET failure_count = 0 WHERE id = $1", a.WebhookID)
+	} else {
+		queryFailure := `
+			UPDATE webhooks w SET
+				failure_count = w.failure_count + 1,
+				active = w.active AND w.failure_count + 1 < $2,
+				disabled_at = CASE WHEN w.active AND w.failure_count + 1 >= $2 THEN NOW() ELSE w.disabled_at END
+			FROM webhooks old
+			WHERE w.id = $1 AND old.id = w.id
+			RETURNING old.active AND NOT w.active`
+
+		err = tx.QueryRow(queryFailure, a.WebhookID, disableAfter).Scan(&disabled)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Webhook supprimé pendant l'envoi
+			err = nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return disabled, tx.Commit()
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWebhook_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook Webhook
+		wantErr bool
+	}{
+		{
+			name:    "Valid HTTPS",
+			webhook: Webhook{URL: "https://partner.example.com/hooks", EventTypes: []string{EventMovieCreated}},
+			wantErr: false,
+		},
+		{
+			name:    "Valid Local HTTP",
+			webhook: Webhook{URL: " http://127.0.0.1:8081/hooks "},
+			wantErr: false,
+		},
+		{
+			name:    "Relative URL",
+			webhook: Webhook{URL: "/hooks"},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported Scheme",
+			webhook: Webhook{URL: "ftp://partner.example.com/hooks"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Event Type",
+			webhook: Webhook{URL: "https://partner.example.com", EventTypes: []string{"movie.rated"}},
+			wantErr: true,
+		},
+		{
+			name:    "Short Secret",
+			webhook: Webhook{URL: "https://partner.example.com", Secret: "abc"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.webhook.Validate()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Sans types précisés, le webhook reçoit tous les événements
+	w := Webhook{URL: "https://partner.example.com", EventTypes: []string{}}
+	if err := w.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{EventMovieCreated, EventMovieDeleted, EventMovieUpdated}
+	if !slices.Equal(w.EventTypes, want) {
+		t.Errorf("EventTypes = %v, attendu %v", w.EventTypes, want)
+	}
+}